@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// backfillCmd represents the backfill command
+var backfillCmd = &cobra.Command{
+	Use:   "backfill [feed-url]",
+	Short: "fill in stored articles from their feeds and exit",
+	Long: `refetch the feed with the given rss link, or every feed when no link is given, and fill in the fields of already stored articles
+such as authors, categories, and content. Read, favorited, and saved articles keep their state and no new articles are added.
+Exits non-zero when any feed fails to backfill.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		c, store, err := connect(cfgFile)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		service := newService(c, store)
+		feeds, err := service.ListAllFeeds(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			feeds, err = feedsWithLink(feeds, args[0])
+			if err != nil {
+				return err
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		var updated, failed int
+		for _, f := range feeds {
+			n, err := service.BackfillFeed(ctx, f.ID)
+			updated += n
+			if err != nil {
+				failed++
+				fmt.Fprintf(out, "%s: failed after updating %d articles: %s\n", f.Title, n, err)
+				continue
+			}
+
+			fmt.Fprintf(out, "%s: %d articles updated\n", f.Title, n)
+		}
+
+		fmt.Fprintf(out, "backfilled %d feeds, %d articles updated, %d failed\n", len(feeds)-failed, updated, failed)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d feeds failed to backfill", failed, len(feeds))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backfillCmd)
+	backfillCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "config file path, searched for in ., $XDG_CONFIG_HOME/feedreader, and /etc/feedreader when not set")
+}
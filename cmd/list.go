@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kdwils/feedreader/storage"
+	"github.com/spf13/cobra"
+)
+
+var listFlags struct {
+	json      bool
+	limit     int
+	cursor    string
+	sort      string
+	unread    bool
+	read      bool
+	favorited bool
+	saved     bool
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list stored feeds or articles",
+	Long:  `list stored feeds or articles a page at a time, as a table or as json`,
+}
+
+var listFeedsCmd = &cobra.Command{
+	Use:          "feeds",
+	Short:        "list feeds with their unread article counts",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, store, err := connect(cfgFile)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		ctx := cmd.Context()
+		opts, err := listOptions()
+		if err != nil {
+			return err
+		}
+
+		feeds, err := store.ListFeeds(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		if listFlags.json {
+			return writeJSON(cmd.OutOrStdout(), feeds)
+		}
+
+		counts, err := store.UnreadCounts(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTITLE\tLINK\tUNREAD")
+		for _, f := range feeds.Feeds {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", f.ID, f.Title, f.RSSLink, counts[f.ID])
+		}
+		writeNextCursor(w, feeds.Cursor)
+		return w.Flush()
+	},
+}
+
+var listArticlesCmd = &cobra.Command{
+	Use:          "articles",
+	Short:        "list articles, defaulting to unread articles",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, store, err := connect(cfgFile)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		ctx := cmd.Context()
+		opts, err := listOptions()
+		if err != nil {
+			return err
+		}
+
+		var articles storage.ArticleList
+		switch {
+		case listFlags.read:
+			articles, err = store.ListReadArticles(ctx, opts)
+		case listFlags.favorited:
+			articles, err = store.ListFavoritedArticles(ctx, opts)
+		case listFlags.saved:
+			articles, err = store.ListSavedArticles(ctx, opts)
+		default:
+			articles, err = store.ListUnreadArticles(ctx, opts)
+		}
+		if err != nil {
+			return err
+		}
+
+		if listFlags.json {
+			return writeJSON(cmd.OutOrStdout(), articles)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTITLE\tLINK\tPUBLISHED")
+		for _, a := range articles.Articles {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.ID, a.Title, a.Link, time.Unix(a.PublishedUnix, 0).UTC().Format(time.RFC3339))
+		}
+		writeNextCursor(w, articles.Cursor)
+		return w.Flush()
+	},
+}
+
+// listOptions maps the paging flags onto storage options, capping the limit the same way the api does.
+// The cli reads the database directly so its cursors are left unsigned.
+func listOptions() (*storage.Options, error) {
+	query := map[string][]string{
+		"limit":  {strconv.Itoa(listFlags.limit)},
+		"cursor": {listFlags.cursor},
+		"sort":   {listFlags.sort},
+	}
+
+	return storage.ParseOptions(query, storage.DefaultPageSize(), nil)
+}
+
+func writeNextCursor(w io.Writer, cursor storage.Cursor) {
+	if cursor.HasNext {
+		fmt.Fprintf(w, "\nnext page: --cursor %s\n", cursor.Next)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.AddCommand(listFeedsCmd, listArticlesCmd)
+
+	listCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file path, searched for in ., $XDG_CONFIG_HOME/feedreader, and /etc/feedreader when not set")
+	listCmd.PersistentFlags().BoolVar(&listFlags.json, "json", false, "print the page as json")
+	listCmd.PersistentFlags().IntVar(&listFlags.limit, "limit", storage.DefaultLimit, "number of items per page")
+	listCmd.PersistentFlags().StringVar(&listFlags.cursor, "cursor", "", "cursor of the page to list, printed after the previous page")
+
+	listArticlesCmd.Flags().BoolVar(&listFlags.unread, "unread", false, "list unread articles")
+	listArticlesCmd.Flags().BoolVar(&listFlags.read, "read", false, "list read articles")
+	listArticlesCmd.Flags().BoolVar(&listFlags.favorited, "favorited", false, "list favorited articles")
+	listArticlesCmd.Flags().BoolVar(&listFlags.saved, "saved", false, "list articles saved to read later")
+	listArticlesCmd.Flags().StringVar(&listFlags.sort, "sort", string(storage.SortPublished), "field to sort articles by: published, added, or title")
+	listArticlesCmd.MarkFlagsMutuallyExclusive("unread", "read", "favorited", "saved")
+}
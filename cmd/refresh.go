@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/kdwils/feedreader/storage"
+	"github.com/spf13/cobra"
+)
+
+// refreshCmd represents the refresh command
+var refreshCmd = &cobra.Command{
+	Use:   "refresh [feed-url]",
+	Short: "refresh feeds once and exit",
+	Long: `refresh the feed with the given rss link, or every feed when no link is given, and exit.
+Useful for refreshing from a scheduler such as cron instead of running the poller. Exits non-zero when any feed fails to refresh.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		c, store, err := connect(cfgFile)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		service := newService(c, store)
+		feeds, err := service.ListAllFeeds(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			feeds, err = feedsWithLink(feeds, args[0])
+			if err != nil {
+				return err
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		var added, failed int
+		for _, f := range feeds {
+			new, err := service.RefreshFeed(ctx, f)
+			added += len(new)
+			if err != nil {
+				failed++
+				fmt.Fprintf(out, "%s: failed after adding %d articles: %s\n", f.Title, len(new), err)
+				continue
+			}
+
+			fmt.Fprintf(out, "%s: %d new articles\n", f.Title, len(new))
+		}
+
+		fmt.Fprintf(out, "refreshed %d feeds, %d new articles, %d failed\n", len(feeds)-failed, added, failed)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d feeds failed to refresh", failed, len(feeds))
+		}
+
+		return nil
+	},
+}
+
+// feedsWithLink returns the feed whose rss link is link
+func feedsWithLink(feeds []*storage.Feed, link string) ([]*storage.Feed, error) {
+	link = strings.TrimSpace(link)
+	for _, f := range feeds {
+		if f.RSSLink == link {
+			return []*storage.Feed{f}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no feed with the link %s", link)
+}
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+	refreshCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "config file path, searched for in ., $XDG_CONFIG_HOME/feedreader, and /etc/feedreader when not set")
+}
@@ -25,6 +25,6 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "config.yaml", "config file (default is $HOME/.feedreader.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path, searched for in ., $XDG_CONFIG_HOME/feedreader, and /etc/feedreader when not set")
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
@@ -2,16 +2,20 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/kdwils/feedreader/config"
-	"github.com/kdwils/feedreader/pkg/parser"
+	"github.com/kdwils/feedreader/pkg/metrics"
 	"github.com/kdwils/feedreader/poller"
+	"github.com/kdwils/feedreader/pruner"
 	"github.com/kdwils/feedreader/server"
 	"github.com/kdwils/feedreader/service"
-	"github.com/kdwils/feedreader/storage"
+	"github.com/kdwils/feedreader/webhook"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -22,43 +26,119 @@ var serveCmd = &cobra.Command{
 	Short: "serve the feedreader api",
 	Long:  `serve the feedreader api`,
 	Run: func(cmd *cobra.Command, args []string) {
-		logger, err := server.NewLogger()
+		c, store, err := connect(cfgFile)
 		if err != nil {
 			log.Fatal(err)
 		}
+		defer store.Close()
 
-		c, err := config.Init(cfgFile)
-		if err != nil {
-			logger.Fatal("unable to load configs", zap.Error(err))
-		}
-
-		store := storage.NewSQLiteStorage(c.SQLite.FilePath)
-		err = store.Connect()
+		logger, err := server.NewLogger(c.Logging.Level, c.Logging.Encoding)
 		if err != nil {
-			logger.Fatal("failed to connect to storage", zap.Error(err))
+			log.Fatal(err)
 		}
-		defer store.Close()
 
 		interval := c.Poller.Interval
 		if interval == 0 {
 			interval = time.Hour * 1
 		}
 
-		parser := parser.New(http.DefaultClient)
-		service := service.New(store, parser)
+		service := newService(c, store, service.WithLogger(logger))
+		registry := metrics.NewRegistry()
+
+		// a signal cancels ctx, which shuts down the server and stops the poller, pruner, and webhooks
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// the poller, pruner, and webhooks are waited for after the server shuts down so storage is not closed while they are using it
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		// the poller is always created so polls can be triggered through the api, it only ticks when enabled
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		poller := poller.New(ticker, service, logger,
+			poller.WithConcurrency(c.Poller.Concurrency),
+			poller.WithJitter(c.Poller.Jitter),
+			poller.WithRunOnStart(c.Poller.RunOnStart),
+			poller.WithStartDelay(c.Poller.StartDelay),
+			poller.WithFailureBackoff(interval, c.Poller.MaxBackoff),
+			poller.WithMetrics(registry),
+		)
 
 		if c.Poller.Enabled {
-			ticker := time.NewTicker(interval)
-			poller := poller.New(ticker, service, logger)
-			go poller.Poll(context.TODO())
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := poller.Poll(ctx)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					logger.Error("poller stopped", zap.Error(err))
+				}
+			}()
+		}
+
+		pruneInterval := c.Retention.Interval
+		if pruneInterval == 0 {
+			pruneInterval = time.Hour * 24
+		}
+
+		// like the poller, the pruner is always created so pruning can be triggered through the api
+		pruneTicker := time.NewTicker(pruneInterval)
+		defer pruneTicker.Stop()
+
+		pruner := pruner.New(pruneTicker, service, logger)
+		if c.Retention.Enabled {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pruner.Prune(ctx)
+			}()
+		}
+
+		if len(c.Webhooks.URLs) > 0 {
+			notifier, err := webhook.New(service, logger, c.Webhooks.URLs,
+				webhook.WithSecret(c.Webhooks.Secret),
+				webhook.WithTimeout(c.Webhooks.Timeout),
+				webhook.WithRetry(c.Webhooks.MaxAttempts, c.Webhooks.RetryBackoff),
+			)
+			if err != nil {
+				logger.Fatal("invalid webhooks", zap.Error(err))
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				notifier.Notify(ctx)
+			}()
+		}
+
+		proxies, err := parseTrustedProxies(c.TrustedProxies)
+		if err != nil {
+			logger.Fatal("invalid trusted proxies", zap.Error(err))
+		}
+
+		opts := []server.Option{
+			server.WithTrustedProxies(proxies...),
+			server.WithPageSize(c.Pagination.DefaultLimit, c.Pagination.MaxLimit),
+			server.WithCursorKey(c.Pagination.CursorKey),
+			server.WithAPIKeys(c.Auth.Keys, c.Auth.Bypass...),
+			server.WithRegistry(registry),
+			server.WithPoller(&poller),
+			server.WithPruner(&pruner),
+			server.WithCORS(c.CORS.AllowedOrigins, c.CORS.AllowedMethods, c.CORS.AllowedHeaders),
+			server.WithExcerptLength(c.Articles.ExcerptLength),
+			server.WithRequestLogging(c.Logging.RequestBodies, c.Logging.MaxBodySize, c.Logging.RedactHeaders),
+		}
+		if c.RateLimit.Enabled {
+			opts = append(opts, server.WithRateLimit(c.RateLimit.Rate, c.RateLimit.Burst, c.RateLimit.MaxClients, c.RateLimit.TrustForwardedFor))
 		}
 
-		s := server.New(service, logger)
-		s.Serve(c.Port)
+		s := server.New(service, logger, opts...)
+		s.Serve(ctx, c.Port)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(serveCmd)
-	serveCmd.Flags().StringVarP(&cfgFile, "config", "c", "config.yaml", "config file path")
+	serveCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "config file path, searched for in ., $XDG_CONFIG_HOME/feedreader, and /etc/feedreader when not set")
 }
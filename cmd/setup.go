@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/kdwils/feedreader/config"
+	"github.com/kdwils/feedreader/pkg/parser"
+	"github.com/kdwils/feedreader/service"
+	"github.com/kdwils/feedreader/storage"
+)
+
+// connect loads the config file and connects to the storage it names. The caller closes the storage.
+func connect(file string) (*config.Config, storage.Storage, error) {
+	c, err := config.Init(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load configs: %w", err)
+	}
+
+	store := storage.NewSQLiteStorage(c.SQLite.FilePath,
+		storage.WithPragmas(storage.Pragmas{
+			JournalMode: c.SQLite.JournalMode,
+			Synchronous: c.SQLite.Synchronous,
+			BusyTimeout: c.SQLite.BusyTimeout,
+			ForeignKeys: c.SQLite.ForeignKeys,
+		}),
+		storage.WithPool(storage.Pool{
+			MaxOpenConns:    c.SQLite.MaxOpenConns,
+			MaxIdleConns:    c.SQLite.MaxIdleConns,
+			ConnMaxLifetime: c.SQLite.ConnMaxLifetime,
+		}),
+		storage.WithQueryTimeout(c.SQLite.QueryTimeout),
+	)
+	err = store.Connect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to storage: %w", err)
+	}
+
+	return c, store, nil
+}
+
+// parseTrustedProxies parses each proxy as a CIDR range, or as a single address when it has no prefix length
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// newService creates a service that fetches feeds with the configured http settings
+func newService(c *config.Config, store storage.Storage, opts ...service.Option) service.Service {
+	client := &http.Client{
+		Timeout:       c.HTTP.Timeout,
+		CheckRedirect: parser.CheckRedirect,
+	}
+	p := parser.New(client,
+		parser.WithUserAgent(c.HTTP.UserAgent),
+		parser.WithTimeout(c.HTTP.Timeout),
+		parser.WithRetry(c.HTTP.MaxAttempts, c.HTTP.RetryBackoff),
+		parser.WithMaxBodySize(c.HTTP.MaxBodySize),
+	)
+
+	opts = append(opts,
+		service.WithCrossFeedDeduplication(c.Articles.DeduplicateAcrossFeeds),
+		service.WithRetention(storage.Retention{MaxAge: c.Retention.MaxAge, MaxPerFeed: c.Retention.MaxPerFeed}),
+	)
+	return service.New(store, p, opts...)
+}
@@ -0,0 +1,9 @@
+package config
+
+// Articles describes how articles are stored and presented by the api
+type Articles struct {
+	// ExcerptLength the most characters of a listed article's excerpt, 0 leaves excerpts out
+	ExcerptLength int `json:"excerptLength" yaml:"excerptLength" mapstructure:"excerptLength"`
+	// DeduplicateAcrossFeeds whether a refresh skips an article another feed already has under the same canonical link or guid
+	DeduplicateAcrossFeeds bool `json:"deduplicateAcrossFeeds" yaml:"deduplicateAcrossFeeds" mapstructure:"deduplicateAcrossFeeds"`
+}
@@ -0,0 +1,9 @@
+package config
+
+// Auth describes the api keys requests must present. Auth is disabled when no keys are configured.
+type Auth struct {
+	// Keys the api keys accepted in the Authorization or X-API-Key header
+	Keys []string `json:"keys" yaml:"keys" mapstructure:"keys"`
+	// Bypass paths that can be requested without a key, e.g. a health check
+	Bypass []string `json:"bypass" yaml:"bypass" mapstructure:"bypass"`
+}
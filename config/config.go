@@ -1,26 +1,108 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	SQLite SQLite `mapstructure:"sqlite"`
-	Port   int    `mapstructure:"port"`
-	Poller Poller `mapstructure:"poller"`
+	SQLite     SQLite     `mapstructure:"sqlite"`
+	Port       int        `mapstructure:"port"`
+	Poller     Poller     `mapstructure:"poller"`
+	HTTP       HTTP       `mapstructure:"http"`
+	Pagination Pagination `mapstructure:"pagination"`
+	Auth       Auth       `mapstructure:"auth"`
+	CORS       CORS       `mapstructure:"cors"`
+	RateLimit  RateLimit  `mapstructure:"rateLimit"`
+	Articles   Articles   `mapstructure:"articles"`
+	Logging    Logging    `mapstructure:"logging"`
+	Webhooks   Webhooks   `mapstructure:"webhooks"`
+	Retention  Retention  `mapstructure:"retention"`
+	// TrustedProxies the addresses or CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP headers identify the client
+	TrustedProxies []string `mapstructure:"trustedProxies"`
 }
 
+// Init loads configuration from the file, falling back to defaults when the file does not exist. Environment variables prefixed with FEEDREADER_ override both, so the reader can be configured with environment variables alone.
+// Without a file config.yaml is searched for in the working directory, $XDG_CONFIG_HOME/feedreader, and /etc/feedreader.
 func Init(file string) (*Config, error) {
 	v := viper.New()
-	v.AddConfigPath(".")
-	v.SetConfigName("config")
 	v.SetConfigType("yaml")
+	if file != "" {
+		v.SetConfigFile(file)
+	} else {
+		for _, path := range searchPaths() {
+			v.AddConfigPath(path)
+		}
+		v.SetConfigName("config")
+	}
+
+	// every key needs a default for environment variables to be read for it when no file sets it
+
+	v.SetDefault("port", 8080)
+	v.SetDefault("trustedProxies", []string(nil))
+	v.SetDefault("sqlite.filePath", "feedreader.db")
+	v.SetDefault("sqlite.journalMode", "WAL")
+	v.SetDefault("sqlite.synchronous", "NORMAL")
+	v.SetDefault("sqlite.busyTimeout", 5*time.Second)
+	v.SetDefault("sqlite.foreignKeys", true)
+	v.SetDefault("sqlite.maxOpenConns", 1)
+	v.SetDefault("sqlite.maxIdleConns", 1)
+	v.SetDefault("sqlite.connMaxLifetime", time.Duration(0))
+	v.SetDefault("sqlite.queryTimeout", 10*time.Second)
+	v.SetDefault("poller.enabled", false)
+	v.SetDefault("poller.interval", time.Hour)
+	v.SetDefault("poller.concurrency", 4)
+	v.SetDefault("poller.jitter", 30*time.Second)
+	v.SetDefault("poller.maxBackoff", 24*time.Hour)
+	v.SetDefault("poller.runOnStart", true)
+	v.SetDefault("poller.startDelay", time.Duration(0))
+	v.SetDefault("http.timeout", 30*time.Second)
+	v.SetDefault("http.userAgent", "feedreader/1.0")
+	v.SetDefault("http.maxAttempts", 3)
+	v.SetDefault("http.retryBackoff", time.Second)
+	v.SetDefault("http.maxBodySize", 5<<20)
+	v.SetDefault("pagination.defaultLimit", 10)
+	v.SetDefault("pagination.maxLimit", 100)
+	v.SetDefault("pagination.cursorKey", "")
+	v.SetDefault("auth.keys", []string(nil))
+	v.SetDefault("auth.bypass", []string(nil))
+	v.SetDefault("cors.allowedOrigins", []string(nil))
+	v.SetDefault("cors.allowedMethods", []string{"GET", "HEAD", "POST", "PATCH", "DELETE"})
+	v.SetDefault("cors.allowedHeaders", []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID"})
+	v.SetDefault("rateLimit.enabled", false)
+	v.SetDefault("rateLimit.rate", 10)
+	v.SetDefault("rateLimit.burst", 20)
+	v.SetDefault("rateLimit.maxClients", 10000)
+	v.SetDefault("rateLimit.trustForwardedFor", false)
+	v.SetDefault("articles.excerptLength", 200)
+	v.SetDefault("articles.deduplicateAcrossFeeds", false)
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.encoding", "json")
+	v.SetDefault("logging.requestBodies", false)
+	v.SetDefault("logging.maxBodySize", 4096)
+	v.SetDefault("logging.redactHeaders", []string{"Authorization", "X-API-Key", "Cookie"})
+	v.SetDefault("webhooks.urls", []string(nil))
+	v.SetDefault("webhooks.secret", "")
+	v.SetDefault("webhooks.timeout", 10*time.Second)
+	v.SetDefault("webhooks.maxAttempts", 3)
+	v.SetDefault("webhooks.retryBackoff", time.Second)
+	v.SetDefault("retention.enabled", false)
+	v.SetDefault("retention.interval", 24*time.Hour)
+	v.SetDefault("retention.maxAge", 30*24*time.Hour)
+	v.SetDefault("retention.maxPerFeed", 0)
 
 	err := v.ReadInConfig()
 	if err != nil {
-		return nil, err
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
 	}
 
 	v.SetEnvPrefix("FEEDREADER")
@@ -29,5 +111,21 @@ func Init(file string) (*Config, error) {
 
 	c := new(Config)
 	err = v.Unmarshal(c)
-	return c, err
+	if err != nil {
+		return nil, err
+	}
+
+	return c, c.SQLite.Validate()
+}
+
+// searchPaths are the directories searched in order for a config file when none is given
+func searchPaths() []string {
+	paths := []string{"."}
+	// the user config dir is $XDG_CONFIG_HOME, or ~/.config when it is not set
+	dir, err := os.UserConfigDir()
+	if err == nil {
+		paths = append(paths, filepath.Join(dir, "feedreader"))
+	}
+
+	return append(paths, "/etc/feedreader")
 }
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	t.Run("poller", func(t *testing.T) {
+		c, err := Init("testing/config.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &Config{
+			Port: 8080,
+			SQLite: SQLite{
+				FilePath:        "db.sqlite",
+				JournalMode:     "WAL",
+				Synchronous:     "NORMAL",
+				BusyTimeout:     5 * time.Second,
+				ForeignKeys:     true,
+				MaxOpenConns:    1,
+				MaxIdleConns:    1,
+				ConnMaxLifetime: 0,
+				QueryTimeout:    10 * time.Second,
+			},
+			Poller: Poller{
+				Enabled:     true,
+				Interval:    10 * time.Minute,
+				Concurrency: 4,
+				Jitter:      30 * time.Second,
+				RunOnStart:  true,
+				MaxBackoff:  24 * time.Hour,
+			},
+			HTTP: HTTP{
+				Timeout:      30 * time.Second,
+				UserAgent:    "feedreader/1.0",
+				MaxAttempts:  3,
+				RetryBackoff: time.Second,
+				MaxBodySize:  5 << 20,
+			},
+			Pagination: Pagination{
+				DefaultLimit: 10,
+				MaxLimit:     100,
+			},
+			CORS: CORS{
+				AllowedMethods: []string{"GET", "HEAD", "POST", "PATCH", "DELETE"},
+				AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID"},
+			},
+			RateLimit: RateLimit{
+				Rate:       10,
+				Burst:      20,
+				MaxClients: 10000,
+			},
+			Articles: Articles{
+				ExcerptLength: 200,
+			},
+			Logging: Logging{
+				Level:         "info",
+				Encoding:      "json",
+				MaxBodySize:   4096,
+				RedactHeaders: []string{"Authorization", "X-API-Key", "Cookie"},
+			},
+			Webhooks: Webhooks{
+				Timeout:      10 * time.Second,
+				MaxAttempts:  3,
+				RetryBackoff: time.Second,
+			},
+			Retention: Retention{
+				Interval: 24 * time.Hour,
+				MaxAge:   30 * 24 * time.Hour,
+			},
+		}
+
+		assert.Equal(t, want, c)
+	})
+
+	t.Run("no file uses defaults", func(t *testing.T) {
+		c, err := Init("testing/missing.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := &Config{
+			Port: 8080,
+			SQLite: SQLite{
+				FilePath:        "feedreader.db",
+				JournalMode:     "WAL",
+				Synchronous:     "NORMAL",
+				BusyTimeout:     5 * time.Second,
+				ForeignKeys:     true,
+				MaxOpenConns:    1,
+				MaxIdleConns:    1,
+				ConnMaxLifetime: 0,
+				QueryTimeout:    10 * time.Second,
+			},
+			Poller: Poller{
+				Enabled:     false,
+				Interval:    time.Hour,
+				Concurrency: 4,
+				Jitter:      30 * time.Second,
+				RunOnStart:  true,
+				MaxBackoff:  24 * time.Hour,
+			},
+			HTTP: HTTP{
+				Timeout:      30 * time.Second,
+				UserAgent:    "feedreader/1.0",
+				MaxAttempts:  3,
+				RetryBackoff: time.Second,
+				MaxBodySize:  5 << 20,
+			},
+			Pagination: Pagination{
+				DefaultLimit: 10,
+				MaxLimit:     100,
+			},
+			CORS: CORS{
+				AllowedMethods: []string{"GET", "HEAD", "POST", "PATCH", "DELETE"},
+				AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID"},
+			},
+			RateLimit: RateLimit{
+				Rate:       10,
+				Burst:      20,
+				MaxClients: 10000,
+			},
+			Articles: Articles{
+				ExcerptLength: 200,
+			},
+			Logging: Logging{
+				Level:         "info",
+				Encoding:      "json",
+				MaxBodySize:   4096,
+				RedactHeaders: []string{"Authorization", "X-API-Key", "Cookie"},
+			},
+			Webhooks: Webhooks{
+				Timeout:      10 * time.Second,
+				MaxAttempts:  3,
+				RetryBackoff: time.Second,
+			},
+			Retention: Retention{
+				Interval: 24 * time.Hour,
+				MaxAge:   30 * 24 * time.Hour,
+			},
+		}
+
+		assert.Equal(t, want, c)
+	})
+
+	t.Run("partial file", func(t *testing.T) {
+		c, err := Init("testing/partial.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 9000, c.Port)
+		assert.Equal(t, "feedreader.db", c.SQLite.FilePath)
+		assert.Equal(t, time.Hour, c.Poller.Interval)
+	})
+
+	t.Run("env override", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "env.sqlite")
+		t.Setenv("FEEDREADER_PORT", "9090")
+		t.Setenv("FEEDREADER_SQLITE_FILEPATH", filePath)
+		t.Setenv("FEEDREADER_POLLER_INTERVAL", "5m")
+
+		c, err := Init("testing/config.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 9090, c.Port)
+		assert.Equal(t, filePath, c.SQLite.FilePath)
+		assert.Equal(t, 5*time.Minute, c.Poller.Interval)
+	})
+
+	t.Run("env only", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "env.sqlite")
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		t.Setenv("FEEDREADER_PORT", "9090")
+		t.Setenv("FEEDREADER_SQLITE_FILEPATH", filePath)
+		t.Setenv("FEEDREADER_AUTH_KEYS", "secret")
+
+		c, err := Init("")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 9090, c.Port)
+		assert.Equal(t, filePath, c.SQLite.FilePath)
+		assert.Equal(t, []string{"secret"}, c.Auth.Keys)
+		assert.Equal(t, time.Hour, c.Poller.Interval)
+	})
+
+	t.Run("searches the xdg config dir", func(t *testing.T) {
+		home := t.TempDir()
+		dir := filepath.Join(home, "feedreader")
+		err := os.Mkdir(dir, 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("port: 7000\npoller:\n  interval: 2m\n"), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("XDG_CONFIG_HOME", home)
+		t.Setenv("FEEDREADER_SQLITE_FILEPATH", filepath.Join(t.TempDir(), "db.sqlite"))
+		t.Setenv("FEEDREADER_PORT", "9090")
+
+		c, err := Init("")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 9090, c.Port)
+		assert.Equal(t, 2*time.Minute, c.Poller.Interval)
+	})
+
+	t.Run("unwritable sqlite directory", func(t *testing.T) {
+		t.Setenv("FEEDREADER_SQLITE_FILEPATH", filepath.Join(t.TempDir(), "missing", "db.sqlite"))
+
+		_, err := Init("testing/config.yaml")
+		assert.Error(t, err)
+	})
+}
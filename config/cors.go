@@ -0,0 +1,11 @@
+package config
+
+// CORS describes which cross origin requests browsers are allowed to make
+type CORS struct {
+	// AllowedOrigins origins allowed to call the api, e.g. https://reader.example.com. An empty list only allows same origin requests and * allows any origin.
+	AllowedOrigins []string `json:"allowedOrigins" yaml:"allowedOrigins" mapstructure:"allowedOrigins"`
+	// AllowedMethods methods cross origin requests may use
+	AllowedMethods []string `json:"allowedMethods" yaml:"allowedMethods" mapstructure:"allowedMethods"`
+	// AllowedHeaders headers cross origin requests may send
+	AllowedHeaders []string `json:"allowedHeaders" yaml:"allowedHeaders" mapstructure:"allowedHeaders"`
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// HTTP describes configuration for the client used to fetch feeds
+type HTTP struct {
+	// Timeout how long a single feed fetch may take before it is cancelled
+	Timeout time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+	// UserAgent the User-Agent header sent with feed requests
+	UserAgent string `json:"userAgent" yaml:"userAgent" mapstructure:"userAgent"`
+	// MaxAttempts how many times a feed fetch is attempted when it fails with a server or connection error
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts" mapstructure:"maxAttempts"`
+	// RetryBackoff the delay before the first retry, doubling after each attempt
+	RetryBackoff time.Duration `json:"retryBackoff" yaml:"retryBackoff" mapstructure:"retryBackoff"`
+	// MaxBodySize the largest feed body in bytes that will be read
+	MaxBodySize int64 `json:"maxBodySize" yaml:"maxBodySize" mapstructure:"maxBodySize"`
+}
@@ -0,0 +1,15 @@
+package config
+
+// Logging describes what the server logs and how
+type Logging struct {
+	// Level the lowest level logged, one of debug, info, warn, or error
+	Level string `json:"level" yaml:"level" mapstructure:"level"`
+	// Encoding json for structured logs or console for human readable logs during local development
+	Encoding string `json:"encoding" yaml:"encoding" mapstructure:"encoding"`
+	// RequestBodies logs request bodies of up to MaxBodySize bytes along with each request, requests are only logged at debug level
+	RequestBodies bool `json:"requestBodies" yaml:"requestBodies" mapstructure:"requestBodies"`
+	// MaxBodySize the largest request body logged in bytes
+	MaxBodySize int `json:"maxBodySize" yaml:"maxBodySize" mapstructure:"maxBodySize"`
+	// RedactHeaders request headers whose values are left out of request logs
+	RedactHeaders []string `json:"redactHeaders" yaml:"redactHeaders" mapstructure:"redactHeaders"`
+}
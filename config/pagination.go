@@ -0,0 +1,11 @@
+package config
+
+// Pagination describes the page sizes the api serves
+type Pagination struct {
+	// DefaultLimit the page size used when a request does not ask for one
+	DefaultLimit int `json:"defaultLimit" yaml:"defaultLimit" mapstructure:"defaultLimit"`
+	// MaxLimit the largest page size a request can ask for
+	MaxLimit int `json:"maxLimit" yaml:"maxLimit" mapstructure:"maxLimit"`
+	// CursorKey the secret pagination cursors are signed with, a random key is used when empty so cursors stop working on restart
+	CursorKey string `json:"cursorKey" yaml:"cursorKey" mapstructure:"cursorKey"`
+}
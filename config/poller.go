@@ -6,6 +6,16 @@ import "time"
 type Poller struct {
 	// Enabled whether to automatically update database with new articles
 	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
-	// Interval time interval in minutes for polling for feed updates
+	// Interval how often to poll for feed updates as a duration string, e.g. 10m
 	Interval time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// Concurrency the maximum number of feeds refreshed at the same time
+	Concurrency int `json:"concurrency" yaml:"concurrency" mapstructure:"concurrency"`
+	// Jitter the maximum random delay added before each poll, e.g. 30s
+	Jitter time.Duration `json:"jitter" yaml:"jitter" mapstructure:"jitter"`
+	// RunOnStart whether to refresh every feed when the poller starts rather than waiting for the first interval
+	RunOnStart bool `json:"runOnStart" yaml:"runOnStart" mapstructure:"runOnStart"`
+	// StartDelay how long to wait after starting before the refresh on start, e.g. 10s
+	StartDelay time.Duration `json:"startDelay" yaml:"startDelay" mapstructure:"startDelay"`
+	// MaxBackoff the longest a feed that keeps failing to refresh is skipped for, e.g. 24h
+	MaxBackoff time.Duration `json:"maxBackoff" yaml:"maxBackoff" mapstructure:"maxBackoff"`
 }
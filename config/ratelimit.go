@@ -0,0 +1,15 @@
+package config
+
+// RateLimit describes how many requests each client may make
+type RateLimit struct {
+	// Enabled whether requests are rate limited
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// Rate the requests per second a client is allowed once its burst is used up
+	Rate float64 `json:"rate" yaml:"rate" mapstructure:"rate"`
+	// Burst how many requests a client can make at once
+	Burst int `json:"burst" yaml:"burst" mapstructure:"burst"`
+	// MaxClients how many client addresses are tracked before the least recently seen is forgotten
+	MaxClients int `json:"maxClients" yaml:"maxClients" mapstructure:"maxClients"`
+	// TrustForwardedFor identify clients by the X-Forwarded-For header of any peer, only safe when the server can only be reached through a proxy that sets it. Prefer TrustedProxies.
+	TrustForwardedFor bool `json:"trustForwardedFor" yaml:"trustForwardedFor" mapstructure:"trustForwardedFor"`
+}
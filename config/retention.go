@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+// Retention describes how long read articles are kept before they are pruned. Unread, favorited, and saved articles are always kept.
+type Retention struct {
+	// Enabled whether to prune articles on the interval, pruning can still be triggered through the api when disabled
+	Enabled bool `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	// Interval how often to prune articles as a duration string, e.g. 24h
+	Interval time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// MaxAge how long after it was published a read article is pruned, e.g. 720h, 0 keeps articles regardless of age
+	MaxAge time.Duration `json:"maxAge" yaml:"maxAge" mapstructure:"maxAge"`
+	// MaxPerFeed how many of each feed's newest articles are kept before older read ones are pruned, 0 keeps articles regardless of count
+	MaxPerFeed int `json:"maxPerFeed" yaml:"maxPerFeed" mapstructure:"maxPerFeed"`
+}
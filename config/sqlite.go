@@ -1,5 +1,45 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
 type SQLite struct {
 	FilePath string `yaml:"filePath" json:"filePath" mapstructure:"filePath"`
+	// JournalMode the sqlite journal mode, WAL lets feeds be refreshed while the api reads
+	JournalMode string `yaml:"journalMode" json:"journalMode" mapstructure:"journalMode"`
+	// Synchronous how often sqlite waits for writes to reach the disk, e.g. NORMAL or FULL
+	Synchronous string `yaml:"synchronous" json:"synchronous" mapstructure:"synchronous"`
+	// BusyTimeout how long to wait for a lock held by another connection before failing
+	BusyTimeout time.Duration `yaml:"busyTimeout" json:"busyTimeout" mapstructure:"busyTimeout"`
+	// ForeignKeys enforces foreign key constraints
+	ForeignKeys bool `yaml:"foreignKeys" json:"foreignKeys" mapstructure:"foreignKeys"`
+	// MaxOpenConns the most connections open at once, 1 serializes writes so they wait their turn instead of failing with database is locked
+	MaxOpenConns int `yaml:"maxOpenConns" json:"maxOpenConns" mapstructure:"maxOpenConns"`
+	// MaxIdleConns the most unused connections kept open
+	MaxIdleConns int `yaml:"maxIdleConns" json:"maxIdleConns" mapstructure:"maxIdleConns"`
+	// ConnMaxLifetime how long a connection is reused before it is closed, 0 reuses connections forever
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" json:"connMaxLifetime" mapstructure:"connMaxLifetime"`
+	// QueryTimeout how long a query may run before it is canceled, 0 disables the timeout
+	QueryTimeout time.Duration `yaml:"queryTimeout" json:"queryTimeout" mapstructure:"queryTimeout"`
+}
+
+// Validate ensures the file path is set and the directory it lives in can be written to
+func (s SQLite) Validate() error {
+	if s.FilePath == "" {
+		return errors.New("sqlite file path is empty")
+	}
+
+	dir := filepath.Dir(s.FilePath)
+	f, err := os.CreateTemp(dir, ".feedreader-*")
+	if err != nil {
+		return fmt.Errorf("sqlite directory %s is not writable: %w", dir, err)
+	}
+	f.Close()
+
+	return os.Remove(f.Name())
 }
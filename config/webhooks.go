@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// Webhooks describes the webhooks that are sent each new article
+type Webhooks struct {
+	// URLs the http or https urls each new article is posted to
+	URLs []string `json:"urls" yaml:"urls" mapstructure:"urls"`
+	// Secret signs each request in the X-Feedreader-Signature header when set, so receivers can check it came from the reader
+	Secret string `json:"secret" yaml:"secret" mapstructure:"secret"`
+	// Timeout how long each attempt at delivering to a webhook may take before it is cancelled
+	Timeout time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+	// MaxAttempts how many times a delivery is attempted when the webhook fails with a server or connection error
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts" mapstructure:"maxAttempts"`
+	// RetryBackoff the delay before the first retry, doubling after each attempt
+	RetryBackoff time.Duration `json:"retryBackoff" yaml:"retryBackoff" mapstructure:"retryBackoff"`
+}
@@ -0,0 +1,57 @@
+// Package events publishes what happens to articles to the parts of the reader that react to it, such as the streaming endpoints
+package events
+
+import (
+	"time"
+
+	"github.com/kdwils/feedreader/pkg/broker"
+	"github.com/kdwils/feedreader/storage"
+)
+
+// DefaultBuffer is how many events a subscriber can fall behind by before its oldest are dropped
+const DefaultBuffer = 64
+
+// Type names what happened to the article of an event
+type Type string
+
+const (
+	// ArticleCreated is published once a new article is stored, whether it was found by a refresh or created directly
+	ArticleCreated Type = "article.created"
+)
+
+// Event is something that happened to an article
+type Event struct {
+	Type    Type             `json:"type"`
+	Article *storage.Article `json:"article"`
+	Time    time.Time        `json:"time"`
+}
+
+// Bus delivers every published event to each subscriber without blocking the publisher.
+// Each subscriber has its own buffer, and one that falls behind has its oldest events dropped without holding up the others.
+type Bus struct {
+	broker *broker.Broker[Event]
+}
+
+// NewBus creates a bus whose subscribers can each fall behind by up to buffer events
+func NewBus(buffer int) *Bus {
+	return &Bus{broker: broker.New[Event](buffer)}
+}
+
+// Publish sends the event to every current subscriber, setting its time to now when it has none
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.broker.Publish(e)
+}
+
+// Subscribe returns a channel receiving every event published from now on. Call unsubscribe once the caller stops reading, it closes the channel.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	return b.broker.Subscribe()
+}
+
+// Subscribers returns the number of current subscribers
+func (b *Bus) Subscribers() int {
+	return b.broker.Subscribers()
+}
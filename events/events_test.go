@@ -0,0 +1,70 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/kdwils/feedreader/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func articleCreated(id string) Event {
+	return Event{Type: ArticleCreated, Article: &storage.Article{ID: id}}
+}
+
+func TestBus_FanOut(t *testing.T) {
+	b := NewBus(4)
+
+	var subscribers []<-chan Event
+	for i := 0; i < 3; i++ {
+		events, unsubscribe := b.Subscribe()
+		defer unsubscribe()
+		subscribers = append(subscribers, events)
+	}
+
+	b.Publish(articleCreated("1"))
+	b.Publish(articleCreated("2"))
+
+	for _, events := range subscribers {
+		first, second := <-events, <-events
+		assert.Equal(t, "1", first.Article.ID)
+		assert.Equal(t, "2", second.Article.ID)
+		assert.Equal(t, ArticleCreated, first.Type)
+		assert.False(t, first.Time.IsZero(), "the time is set when publishing")
+	}
+}
+
+func TestBus_SlowSubscriber(t *testing.T) {
+	b := NewBus(2)
+
+	slow, unsubscribeSlow := b.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := b.Subscribe()
+	defer unsubscribeFast()
+
+	// publishing never waits for the slow subscriber, and the fast one still sees every event
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		b.Publish(articleCreated(id))
+		assert.Equal(t, id, (<-fast).Article.ID)
+	}
+
+	// the slow subscriber keeps the newest events that fit in its buffer
+	assert.Equal(t, "4", (<-slow).Article.ID)
+	assert.Equal(t, "5", (<-slow).Article.ID)
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	b := NewBus(1)
+
+	events, unsubscribe := b.Subscribe()
+	assert.Equal(t, 1, b.Subscribers())
+
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 0, b.Subscribers())
+
+	_, ok := <-events
+	assert.False(t, ok, "unsubscribing closes the channel")
+
+	// publishing with no subscribers does nothing
+	b.Publish(articleCreated("1"))
+}
@@ -0,0 +1,78 @@
+package broker
+
+import "sync"
+
+// Broker fans out published messages to every subscriber. A subscriber that falls behind has its oldest unread messages dropped rather than blocking the publisher, so it catches up on the latest ones.
+type Broker[T any] struct {
+	mu          sync.Mutex
+	buffer      int
+	subscribers map[chan T]struct{}
+}
+
+// New creates a broker whose subscriber channels hold up to buffer unread messages, at least one
+func New[T any](buffer int) *Broker[T] {
+	if buffer < 1 {
+		buffer = 1
+	}
+
+	return &Broker[T]{
+		buffer:      buffer,
+		subscribers: make(map[chan T]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving every message published from now on. Calling unsubscribe stops delivery and closes the channel.
+func (b *Broker[T]) Subscribe() (<-chan T, func()) {
+	ch := make(chan T, b.buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends the message to every current subscriber
+func (b *Broker[T]) Publish(msg T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		send(ch, msg)
+	}
+}
+
+// send delivers the message, dropping the oldest unread messages of a full channel to make room.
+// Only the publisher sends, and it holds the lock, so once a message is dropped there is room unless the subscriber read it first.
+func send[T any](ch chan T, msg T) {
+	for {
+		select {
+		case ch <- msg:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of current subscribers
+func (b *Broker[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
@@ -0,0 +1,36 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	b := New[int](1)
+
+	first, unsubscribeFirst := b.Subscribe()
+	second, unsubscribeSecond := b.Subscribe()
+	defer unsubscribeSecond()
+	assert.Equal(t, 2, b.Subscribers())
+
+	b.Publish(1)
+	assert.Equal(t, 1, <-first)
+	assert.Equal(t, 1, <-second)
+
+	// second is not read, so its oldest message is dropped for the newest instead of blocking
+	b.Publish(2)
+	assert.Equal(t, 2, <-first)
+	b.Publish(3)
+	assert.Equal(t, 3, <-first)
+	assert.Equal(t, 3, <-second)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, ok := <-first
+	assert.False(t, ok)
+	assert.Equal(t, 1, b.Subscribers())
+
+	b.Publish(4)
+	assert.Equal(t, 4, <-second)
+}
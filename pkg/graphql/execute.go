@@ -0,0 +1,619 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Request is a query and the variables it is run with, as clients send it
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is the result of a request. Data is left out when the request could not be executed, such as when the query does not parse.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a problem with the request, or with a field when Path locates one
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// errNull signals a null in a non null position, which makes the closest nullable parent null instead
+var errNull = errors.New("null in a non null position")
+
+// Execute parses and runs the request against the schema. Fields that fail are returned as null alongside an error, so the rest of the response is still usable.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return requestError(err)
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return requestError(err)
+	}
+
+	var root *Object
+	switch op.kind {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		return requestError(fmt.Errorf("%s operations are not supported", op.kind))
+	}
+
+	maxDepth := s.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	v := &validator{doc: doc, op: op, maxDepth: maxDepth}
+	err = v.selectionSet(root, op.selection, 1, nil)
+	if err != nil {
+		return requestError(err)
+	}
+
+	e := &executor{ctx: ctx, doc: doc}
+	e.variables, err = e.coerceVariables(op, req.Variables)
+	if err != nil {
+		return requestError(err)
+	}
+
+	data, err := e.selectionSet(root, nil, op.selection, nil)
+	if err != nil {
+		return &Response{Errors: e.errors}
+	}
+
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func requestError(err error) *Response {
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+// operation returns the operation to run, which must be named when the document has more than one
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("an operation name is required when the query has more than one operation")
+		}
+		return d.operations[0], nil
+	}
+
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// validator checks the query against the schema before any resolver runs, so a mistake in the query is not reported after mutations have been made
+type validator struct {
+	doc      *document
+	op       *operation
+	maxDepth int
+}
+
+func (v *validator) selectionSet(obj *Object, selections []selection, depth int, spreading []string) error {
+	if depth > v.maxDepth {
+		return fmt.Errorf("the query is nested more than %d levels deep", v.maxDepth)
+	}
+
+	for _, s := range selections {
+		switch s := s.(type) {
+		case *field:
+			err := v.field(obj, s, depth)
+			if err != nil {
+				return err
+			}
+		case *inlineFragment:
+			err := v.fragment(obj, s.typeCondition, s.directives)
+			if err != nil {
+				return err
+			}
+			err = v.selectionSet(obj, s.selection, depth, spreading)
+			if err != nil {
+				return err
+			}
+		case *fragmentSpread:
+			f, ok := v.doc.fragments[s.name]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", s.name)
+			}
+			for _, name := range spreading {
+				if name == s.name {
+					return fmt.Errorf("fragment %q spreads itself", s.name)
+				}
+			}
+			err := v.fragment(obj, f.typeCondition, s.directives)
+			if err != nil {
+				return err
+			}
+			err = v.selectionSet(obj, f.selection, depth, append(spreading, s.name))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) field(obj *Object, f *field, depth int) error {
+	err := v.directives(f.directives)
+	if err != nil {
+		return err
+	}
+
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || len(f.selection) > 0 {
+			return errors.New("__typename has no arguments or subfields")
+		}
+		return nil
+	}
+
+	def, ok := obj.Fields[f.name]
+	if !ok {
+		return fmt.Errorf("cannot query field %q on type %s", f.name, obj.Name)
+	}
+
+	for _, a := range f.arguments {
+		if _, ok := def.Args[a.name]; !ok {
+			return fmt.Errorf("unknown argument %q on field %s.%s", a.name, obj.Name, f.name)
+		}
+		err := v.variables(a.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	child := objectType(def.Type)
+	switch {
+	case child != nil && len(f.selection) == 0:
+		return fmt.Errorf("field %q of type %s must have a selection of subfields", f.name, def.Type)
+	case child == nil && len(f.selection) > 0:
+		return fmt.Errorf("field %q of type %s has no subfields", f.name, def.Type)
+	case child != nil:
+		return v.selectionSet(child, f.selection, depth+1, nil)
+	default:
+		return nil
+	}
+}
+
+// fragment checks a fragment applies to the object. There are no interfaces or unions, so the condition has to name the object itself.
+func (v *validator) fragment(obj *Object, typeCondition string, directives []*directive) error {
+	if typeCondition != "" && typeCondition != obj.Name {
+		return fmt.Errorf("fragment on %s cannot be spread within %s", typeCondition, obj.Name)
+	}
+
+	return v.directives(directives)
+}
+
+func (v *validator) directives(directives []*directive) error {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return fmt.Errorf("unknown directive @%s", d.name)
+		}
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			return fmt.Errorf("@%s takes a single if argument", d.name)
+		}
+		err := v.variables(d.arguments[0].value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// variables checks every variable in the value is defined by the operation
+func (v *validator) variables(val value) error {
+	switch val := val.(type) {
+	case variableValue:
+		for _, def := range v.op.variables {
+			if def.name == val.name {
+				return nil
+			}
+		}
+		return fmt.Errorf("variable $%s is not defined", val.name)
+	case listValue:
+		for _, item := range val.values {
+			err := v.variables(item)
+			if err != nil {
+				return err
+			}
+		}
+	case objectValue:
+		for _, f := range val.fields {
+			err := v.variables(f.value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// objectType returns the object a field's type holds, or nil for leaf types
+func objectType(t Type) *Object {
+	for {
+		switch typ := t.(type) {
+		case *NonNull:
+			t = typ.OfType
+		case *List:
+			t = typ.OfType
+		case *Object:
+			return typ
+		default:
+			return nil
+		}
+	}
+}
+
+type executor struct {
+	ctx       context.Context
+	doc       *document
+	variables map[string]any
+	errors    []*Error
+}
+
+// coerceVariables applies the defaults of variables that were not given. A variable's value is checked against the type of the arguments it is used for.
+func (e *executor) coerceVariables(op *operation, given map[string]any) (map[string]any, error) {
+	variables := make(map[string]any)
+	for _, def := range op.variables {
+		v, ok := given[def.name]
+		switch {
+		case ok:
+			variables[def.name] = v
+		case def.defaultValue != nil:
+			v, err := e.literal(def.defaultValue)
+			if err != nil {
+				return nil, err
+			}
+			variables[def.name] = v
+		case def.nonNull:
+			return nil, fmt.Errorf("variable $%s of required type %s was not provided", def.name, def.typ)
+		}
+
+		if def.nonNull && variables[def.name] == nil {
+			return nil, fmt.Errorf("variable $%s of required type %s cannot be null", def.name, def.typ)
+		}
+	}
+
+	return variables, nil
+}
+
+// literal returns the Go value of a value written in the query
+func (e *executor) literal(v value) (any, error) {
+	switch v := v.(type) {
+	case variableValue:
+		return e.variables[v.name], nil
+	case intValue:
+		i, err := strconv.ParseInt(v.raw, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a 32 bit integer", v.raw)
+		}
+		return int(i), nil
+	case floatValue:
+		return strconv.ParseFloat(v.raw, 64)
+	case stringValue:
+		return v.value, nil
+	case booleanValue:
+		return v.value, nil
+	case nullValue:
+		return nil, nil
+	case enumValue:
+		return enumName(v.name), nil
+	case listValue:
+		values := make([]any, 0, len(v.values))
+		for _, item := range v.values {
+			value, err := e.literal(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case objectValue:
+		values := make(map[string]any, len(v.fields))
+		for _, f := range v.fields {
+			value, err := e.literal(f.value)
+			if err != nil {
+				return nil, err
+			}
+			values[f.name] = value
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unexpected value %T", v)
+	}
+}
+
+// arguments coerces the arguments given to the field and fills in the defaults of the rest
+func (e *executor) arguments(def *Field, arguments []*argument) (Args, error) {
+	args := make(Args)
+	for _, a := range arguments {
+		// an argument set to a variable that was not given is treated as if it was not given
+		variable, fromVariable := a.value.(variableValue)
+		if _, given := e.variables[variable.name]; fromVariable && !given {
+			continue
+		}
+
+		v, err := e.literal(a.value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", a.name, err)
+		}
+		args[a.name], err = coerceArgument(def.Args[a.name].Type, v, fromVariable)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", a.name, err)
+		}
+	}
+
+	for name, arg := range def.Args {
+		if _, ok := args[name]; ok {
+			continue
+		}
+		if arg.Default != nil {
+			args[name] = arg.Default
+			continue
+		}
+		if _, required := arg.Type.(*NonNull); required {
+			return nil, fmt.Errorf("argument %q of type %s is required", name, arg.Type)
+		}
+	}
+
+	return args, nil
+}
+
+// included evaluates the @skip and @include directives of a selection
+func (e *executor) included(directives []*directive) (bool, error) {
+	for _, d := range directives {
+		v, err := e.literal(d.arguments[0].value)
+		if err != nil {
+			return false, err
+		}
+		condition, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s(if:) expects a Boolean", d.name)
+		}
+		if d.name == "skip" && condition || d.name == "include" && !condition {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// collectFields flattens fragments into the fields they select, grouping the fields asked for under the same response key
+func (e *executor) collectFields(selections []selection, keys []string, grouped map[string][]*field) ([]string, error) {
+	for _, s := range selections {
+		var directives []*directive
+		var nested []selection
+		switch s := s.(type) {
+		case *field:
+			ok, err := e.included(s.directives)
+			if err != nil {
+				return keys, err
+			}
+			if !ok {
+				continue
+			}
+			key := s.responseKey()
+			if _, ok := grouped[key]; !ok {
+				keys = append(keys, key)
+			}
+			grouped[key] = append(grouped[key], s)
+			continue
+		case *inlineFragment:
+			directives, nested = s.directives, s.selection
+		case *fragmentSpread:
+			directives, nested = s.directives, e.doc.fragments[s.name].selection
+		}
+
+		ok, err := e.included(directives)
+		if err != nil {
+			return keys, err
+		}
+		if !ok {
+			continue
+		}
+		keys, err = e.collectFields(nested, keys, grouped)
+		if err != nil {
+			return keys, err
+		}
+	}
+
+	return keys, nil
+}
+
+func (e *executor) selectionSet(obj *Object, source any, selections []selection, path []any) (*orderedMap, error) {
+	grouped := make(map[string][]*field)
+	keys, err := e.collectFields(selections, nil, grouped)
+	if err != nil {
+		e.addError(err, path)
+		return nil, errNull
+	}
+
+	result := &orderedMap{values: make(map[string]any, len(keys))}
+	for _, key := range keys {
+		// the path of each field is its own copy since it is kept in errors
+		fieldPath := append(append(make([]any, 0, len(path)+1), path...), key)
+		v, err := e.field(obj, source, grouped[key], fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		result.set(key, v)
+	}
+
+	return result, nil
+}
+
+func (e *executor) field(obj *Object, source any, fields []*field, path []any) (any, error) {
+	f := fields[0]
+	if f.name == "__typename" {
+		return obj.Name, nil
+	}
+
+	def := obj.Fields[f.name]
+	args, err := e.arguments(def, f.arguments)
+	var v any
+	if err == nil {
+		v, err = e.resolve(def, source, f.name, args)
+	}
+	if err != nil {
+		e.addError(err, path)
+		if _, required := def.Type.(*NonNull); required {
+			return nil, errNull
+		}
+		return nil, nil
+	}
+
+	return e.complete(def.Type, fields, v, path)
+}
+
+func (e *executor) resolve(def *Field, source any, name string, args Args) (v any, err error) {
+	if def.Resolve == nil {
+		return defaultResolve(source, name), nil
+	}
+
+	// a resolver that panics fails its field rather than the server
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("resolver panicked: %v", r)
+		}
+	}()
+
+	return def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+}
+
+// complete shapes the resolved value into the field's type. A null in a non null position is returned as errNull, which a nullable position turns into null.
+func (e *executor) complete(t Type, fields []*field, value any, path []any) (any, error) {
+	nonNull, required := t.(*NonNull)
+	if required {
+		t = nonNull.OfType
+	}
+
+	v, err := e.completeNullable(t, fields, value, path)
+	if err == nil && v == nil && required {
+		e.addError(errors.New("cannot return null for a non null field"), path)
+		err = errNull
+	}
+	if err != nil && !required {
+		return nil, nil
+	}
+
+	return v, err
+}
+
+func (e *executor) completeNullable(t Type, fields []*field, value any, path []any) (any, error) {
+	if isNil(value) {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Object:
+		var selections []selection
+		for _, f := range fields {
+			selections = append(selections, f.selection...)
+		}
+		v, err := e.selectionSet(t, value, selections, path)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	case *List:
+		items := reflect.ValueOf(value)
+		for items.Kind() == reflect.Pointer {
+			items = items.Elem()
+		}
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("expected a list, the resolver returned %T", value), path)
+			return nil, nil
+		}
+
+		list := make([]any, 0, items.Len())
+		for i := 0; i < items.Len(); i++ {
+			itemPath := append(append(make([]any, 0, len(path)+1), path...), i)
+			v, err := e.complete(t.OfType, fields, items.Index(i).Interface(), itemPath)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case *Enum:
+		return fmt.Sprint(value), nil
+	default:
+		return value, nil
+	}
+}
+
+func (e *executor) addError(err error, path []any) {
+	e.errors = append(e.errors, &Error{Message: err.Error(), Path: path})
+}
+
+// isNil reports whether v is nil, including nil pointers, slices, and maps held in an interface
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// orderedMap is an object of the response, its fields marshal in the order the query asked for them
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) set(key string, v any) {
+	m.keys = append(m.keys, key)
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
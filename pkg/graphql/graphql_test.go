@@ -0,0 +1,246 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+type testPost struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Author *testAuthor
+	Tags   []string `json:"tags"`
+}
+
+func testSchema() *Schema {
+	author := &Object{Name: "Author", Fields: Fields{
+		"name":  {Type: &NonNull{OfType: String}},
+		"email": {Type: String},
+	}}
+	post := &Object{Name: "Post", Fields: Fields{
+		"id":     {Type: &NonNull{OfType: ID}},
+		"title":  {Type: String},
+		"tags":   {Type: &List{OfType: String}},
+		"author": {Type: author},
+		"broken": {Type: String, Resolve: func(p ResolveParams) (any, error) {
+			return nil, errors.New("broken")
+		}},
+		"required": {Type: &NonNull{OfType: String}, Resolve: func(p ResolveParams) (any, error) {
+			return nil, nil
+		}},
+	}}
+	posts := []*testPost{
+		{ID: "1", Title: "first", Author: &testAuthor{Name: "kyle", Email: "kyle@example.com"}, Tags: []string{"go"}},
+		{ID: "2", Title: "second", Tags: []string{}},
+		{ID: "3", Title: "third"},
+	}
+	post.Fields["related"] = &Field{Type: &List{OfType: post}, Resolve: func(p ResolveParams) (any, error) {
+		return posts, nil
+	}}
+
+	return &Schema{
+		Query: &Object{Name: "Query", Fields: Fields{
+			"posts": {
+				Type: &NonNull{OfType: &List{OfType: &NonNull{OfType: post}}},
+				Args: map[string]*Argument{
+					"first": {Type: Int, Default: 2},
+					"order": {Type: &Enum{Name: "Order", Values: []string{"ASC", "DESC"}}, Default: "ASC"},
+				},
+				Resolve: func(p ResolveParams) (any, error) {
+					first, _ := p.Args.Int("first")
+					if first > len(posts) {
+						first = len(posts)
+					}
+					if p.Args.String("order") == "DESC" {
+						return []*testPost{posts[2], posts[1], posts[0]}[:first], nil
+					}
+					return posts[:first], nil
+				},
+			},
+			"post": {
+				Type: post,
+				Args: map[string]*Argument{"id": {Type: &NonNull{OfType: ID}}},
+				Resolve: func(p ResolveParams) (any, error) {
+					for _, post := range posts {
+						if post.ID == p.Args.String("id") {
+							return post, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+		}},
+		Mutation: &Object{Name: "Mutation", Fields: Fields{
+			"rename": {
+				Type: post,
+				Args: map[string]*Argument{
+					"id":    {Type: &NonNull{OfType: ID}},
+					"title": {Type: &NonNull{OfType: String}},
+				},
+				Resolve: func(p ResolveParams) (any, error) {
+					return &testPost{ID: p.Args.String("id"), Title: p.Args.String("title")}, nil
+				},
+			},
+		}},
+		MaxDepth: 4,
+	}
+}
+
+func TestSchema_Execute(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   Request
+		want      string
+		wantError string
+	}{
+		{
+			name:    "nested fields in query order",
+			request: Request{Query: `{ posts { title id author { name } tags } }`},
+			want:    `{"data":{"posts":[{"title":"first","id":"1","author":{"name":"kyle"},"tags":["go"]},{"title":"second","id":"2","author":null,"tags":[]}]}}`,
+		},
+		{
+			name:    "arguments and aliases",
+			request: Request{Query: `query Latest { latest: posts(first: 1, order: DESC) { id } one: post(id: 1) { title } }`},
+			want:    `{"data":{"latest":[{"id":"3"}],"one":{"title":"first"}}}`,
+		},
+		{
+			name: "variables",
+			request: Request{
+				Query:     `query ($first: Int = 1, $id: ID!) { posts(first: $first) { id } post(id: $id) { title } }`,
+				Variables: map[string]any{"id": "2"},
+			},
+			want: `{"data":{"posts":[{"id":"1"}],"post":{"title":"second"}}}`,
+		},
+		{
+			name: "json numbers",
+			request: Request{
+				Query:     `query ($first: Int) { posts(first: $first) { id } }`,
+				Variables: map[string]any{"first": float64(3)},
+			},
+			want: `{"data":{"posts":[{"id":"1"},{"id":"2"},{"id":"3"}]}}`,
+		},
+		{
+			name:    "fragments and directives",
+			request: Request{Query: `query ($withTags: Boolean!) { post(id: "1") { ...names ... on Post @include(if: $withTags) { tags } id @skip(if: true) } } fragment names on Post { title author { name } }`, Variables: map[string]any{"withTags": false}},
+			want:    `{"data":{"post":{"title":"first","author":{"name":"kyle"}}}}`,
+		},
+		{
+			name:    "typename",
+			request: Request{Query: `{ post(id: "1") { __typename } }`},
+			want:    `{"data":{"post":{"__typename":"Post"}}}`,
+		},
+		{
+			name:    "field error",
+			request: Request{Query: `{ post(id: "1") { id broken } }`},
+			want:    `{"data":{"post":{"id":"1","broken":null}},"errors":[{"message":"broken","path":["post","broken"]}]}`,
+		},
+		{
+			name:    "null in a non null field nulls its parent",
+			request: Request{Query: `{ post(id: "1") { id required } }`},
+			want:    `{"data":{"post":null},"errors":[{"message":"cannot return null for a non null field","path":["post","required"]}]}`,
+		},
+		{
+			name:    "null propagates through non null lists to the nearest nullable field",
+			request: Request{Query: `{ posts { id required } }`},
+			want:    `{"errors":[{"message":"cannot return null for a non null field","path":["posts",0,"required"]}]}`,
+		},
+		{
+			name:    "mutation",
+			request: Request{Query: `mutation ($title: String!) { rename(id: "1", title: $title) { id title } }`, Variables: map[string]any{"title": "renamed"}},
+			want:    `{"data":{"rename":{"id":"1","title":"renamed"}}}`,
+		},
+		{
+			name:    "named operation",
+			request: Request{Query: `query A { post(id: "1") { id } } query B { post(id: "2") { id } }`, OperationName: "B"},
+			want:    `{"data":{"post":{"id":"2"}}}`,
+		},
+		{name: "syntax error", request: Request{Query: `{ posts { id }`}, wantError: `syntax error at 1:15: expected a name, found end of query`},
+		{name: "unknown field", request: Request{Query: `{ posts { body } }`}, wantError: `cannot query field "body" on type Post`},
+		{name: "unknown argument", request: Request{Query: `{ posts(last: 1) { id } }`}, wantError: `unknown argument "last" on field Query.posts`},
+		{name: "missing subfields", request: Request{Query: `{ posts }`}, wantError: `field "posts" of type [Post!]! must have a selection of subfields`},
+		{name: "subfields of a leaf", request: Request{Query: `{ posts { id { value } } }`}, wantError: `field "id" of type ID! has no subfields`},
+		{name: "undefined variable", request: Request{Query: `{ post(id: $id) { id } }`}, wantError: `variable $id is not defined`},
+		{name: "missing variable", request: Request{Query: `query ($id: ID!) { post(id: $id) { id } }`}, wantError: `variable $id of required type ID! was not provided`},
+		{name: "fragment cycle", request: Request{Query: `{ post(id: "1") { ...a } } fragment a on Post { ...b } fragment b on Post { ...a }`}, wantError: `fragment "a" spreads itself`},
+		{name: "too deep", request: Request{Query: `{ posts { related { related { related { id } } } } }`}, wantError: `the query is nested more than 4 levels deep`},
+		{name: "ambiguous operation", request: Request{Query: `query A { posts { id } } query B { posts { id } }`}, wantError: `an operation name is required when the query has more than one operation`},
+		{name: "subscriptions", request: Request{Query: `subscription { posts { id } }`}, wantError: `subscription operations are not supported`},
+		{
+			name:    "wrong argument type",
+			request: Request{Query: `{ posts(first: "two") { id } }`},
+			want:    `{"errors":[{"message":"argument \"first\": expected Int, found \"two\"","path":["posts"]}]}`,
+		},
+		{
+			name:    "string for an enum",
+			request: Request{Query: `{ posts(order: "DESC") { id } }`},
+			want:    `{"errors":[{"message":"argument \"order\": expected one of ASC, DESC, found \"DESC\"","path":["posts"]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testSchema().Execute(context.Background(), tt.request)
+			if tt.wantError != "" {
+				assert.Nil(t, resp.Data)
+				if assert.Len(t, resp.Errors, 1) {
+					assert.Equal(t, tt.wantError, resp.Errors[0].Message)
+				}
+				return
+			}
+
+			b, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.JSONEq(t, tt.want, string(b))
+		})
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	m := &orderedMap{values: make(map[string]any)}
+	m.set("z", 1)
+	m.set("a", "two")
+	m.set("m", nil)
+
+	b, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"z":1,"a":"two","m":null}`, string(b))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{name: "shorthand", query: `{ a }`},
+		{name: "comments and commas", query: "# a comment\n{ a, b # another\n c }"},
+		{name: "values", query: `{ a(int: -1, float: 1.5e3, string: "tab\tand é", bool: true, null: null, enum: DESC, list: [1, 2], object: {a: 1}) }`},
+		{name: "unterminated string", query: `{ a(b: "c) }`, wantErr: "syntax error at 1:8: unterminated string"},
+		{name: "leading zero", query: `{ a(b: 01) }`, wantErr: "syntax error at 1:8: invalid number, unexpected leading zero"},
+		{name: "empty selection", query: `{ }`, wantErr: "syntax error at 1:3: a selection set cannot be empty"},
+		{name: "unexpected character", query: `{ a% }`, wantErr: `syntax error at 1:4: unexpected character '%'`},
+		{name: "no operations", query: `fragment a on A { b }`, wantErr: "the document has no operations"},
+		{name: "duplicate arguments", query: `{ a(b: 1, b: 2) }`, wantErr: "there can be only one argument named b"},
+		{name: "variable in a default", query: `query ($a: Int = $b) { a }`, wantErr: `syntax error at 1:18: unexpected "$"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.query)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,790 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed request, its operations and the fragments they spread
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind      string
+	name      string
+	variables []*variableDefinition
+	selection []selection
+}
+
+type variableDefinition struct {
+	name string
+	// typ is the variable's type as written, such as [String!]!
+	typ          string
+	nonNull      bool
+	defaultValue value
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selection     []selection
+}
+
+type selection interface {
+	isSelection()
+}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selection  []selection
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selection     []selection
+}
+
+func (*field) isSelection()          {}
+func (*fragmentSpread) isSelection() {}
+func (*inlineFragment) isSelection() {}
+
+// responseKey is the name the field's value is returned under
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+
+	return f.name
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+}
+
+type argument struct {
+	name  string
+	value value
+}
+
+// value is a literal or variable written in the query
+type value interface {
+	isValue()
+}
+
+type variableValue struct{ name string }
+type intValue struct{ raw string }
+type floatValue struct{ raw string }
+type stringValue struct{ value string }
+type booleanValue struct{ value bool }
+type nullValue struct{}
+type enumValue struct{ name string }
+type listValue struct{ values []value }
+type objectValue struct{ fields []*argument }
+
+func (variableValue) isValue() {}
+func (intValue) isValue()      {}
+func (floatValue) isValue()    {}
+func (stringValue) isValue()   {}
+func (booleanValue) isValue()  {}
+func (nullValue) isValue()     {}
+func (enumValue) isValue()     {}
+func (listValue) isValue()     {}
+func (objectValue) isValue()   {}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lexer splits a query into tokens, skipping whitespace, commas, and comments which carry no meaning
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "...", pos: start}, nil
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+		return token{}, l.errorf(start, "unexpected character %q", r)
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+
+	first := l.pos
+	digits := l.digits()
+	if digits == 0 {
+		return token{}, l.errorf(start, "invalid number")
+	}
+	if digits > 1 && l.src[first] == '0' {
+		return token{}, l.errorf(start, "invalid number, unexpected leading zero")
+	}
+
+	kind := tokenInt
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		kind = tokenFloat
+		if l.digits() == 0 {
+			return token{}, l.errorf(start, "invalid number")
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		kind = tokenFloat
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if l.digits() == 0 {
+			return token{}, l.errorf(start, "invalid number")
+		}
+	}
+
+	// a number runs into a following name, as in 1a, which is an error rather than two tokens
+	if l.pos < len(l.src) && (l.src[l.pos] == '_' || l.src[l.pos] == '.' || isLetter(l.src[l.pos])) {
+		return token{}, l.errorf(start, "invalid number")
+	}
+
+	return token{kind: kind, value: l.src[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) digits() int {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+
+	return l.pos - start
+}
+
+// string reads a quoted string. Block strings are not supported since queries have no use for them.
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		return token{}, l.errorf(start, "block strings are not supported")
+	}
+
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenString, value: b.String(), pos: start}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.errorf(start, "unterminated string")
+		case c == '\\':
+			err := l.escape(&b)
+			if err != nil {
+				return token{}, err
+			}
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+var escapes = map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+func (l *lexer) escape(b *strings.Builder) error {
+	start := l.pos
+	if l.pos+1 >= len(l.src) {
+		return l.errorf(start, "unterminated string")
+	}
+
+	if c, ok := escapes[l.src[l.pos+1]]; ok {
+		b.WriteByte(c)
+		l.pos += 2
+		return nil
+	}
+	if l.src[l.pos+1] != 'u' || l.pos+6 > len(l.src) {
+		return l.errorf(start, "invalid escape sequence")
+	}
+
+	r, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 32)
+	if err != nil {
+		return l.errorf(start, "invalid unicode escape sequence")
+	}
+	b.WriteRune(rune(r))
+	l.pos += 6
+	return nil
+}
+
+func (l *lexer) errorf(pos int, format string, args ...any) error {
+	line, column := 1, 1
+	for _, r := range l.src[:pos] {
+		if r == '\n' {
+			line, column = line+1, 1
+			continue
+		}
+		column++
+	}
+
+	return fmt.Errorf("syntax error at %d:%d: %s", line, column, fmt.Sprintf(format, args...))
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser builds a document from the tokens of a query, looking one token ahead
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+// parse parses an executable document, the operations and fragments a client sends. Type system definitions are not accepted.
+func parse(query string) (*document, error) {
+	p := &parser{lexer: &lexer{src: query}}
+	err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.token.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			selection, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selection: selection})
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokenName, "fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("there can be only one fragment named %s", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("the document has no operations")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+
+	p.token = t
+	return nil
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.token.kind == kind && p.token.value == value
+}
+
+// skip advances past the token when it is the punctuator, reporting whether it was
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(tokenPunctuator, punctuator) {
+		return false, nil
+	}
+
+	return true, p.advance()
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(tokenPunctuator, punctuator) {
+		return p.lexer.errorf(p.token.pos, "expected %q, found %s", punctuator, p.describe())
+	}
+
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.lexer.errorf(p.token.pos, "expected a name, found %s", p.describe())
+	}
+
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *parser) unexpected() error {
+	return p.lexer.errorf(p.token.pos, "unexpected %s", p.describe())
+}
+
+func (p *parser) describe() string {
+	switch p.token.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return strconv.Quote(p.token.value)
+	default:
+		return fmt.Sprintf("%q", p.token.value)
+	}
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.token.value}
+	err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.kind == tokenName {
+		op.name = p.token.value
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := p.skip("(")
+	if err != nil {
+		return nil, err
+	}
+	for ok && !p.peek(tokenPunctuator, ")") {
+		v, err := p.variableDefinition()
+		if err != nil {
+			return nil, err
+		}
+		op.variables = append(op.variables, v)
+	}
+	if ok {
+		err = p.expect(")")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// directives on operations are parsed but have no effect
+	_, err = p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	op.selection, err = p.selectionSet()
+	return op, err
+}
+
+func (p *parser) variableDefinition() (*variableDefinition, error) {
+	err := p.expect("$")
+	if err != nil {
+		return nil, err
+	}
+
+	v := &variableDefinition{}
+	v.name, err = p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.expect(":")
+	if err != nil {
+		return nil, err
+	}
+
+	v.typ, err = p.typeReference()
+	if err != nil {
+		return nil, err
+	}
+	v.nonNull = strings.HasSuffix(v.typ, "!")
+
+	ok, err := p.skip("=")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		v.defaultValue, err = p.value(true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.directives()
+	return v, err
+}
+
+// typeReference returns a type written in a variable definition as written, since variables are checked against the types of the arguments they are used in
+func (p *parser) typeReference() (string, error) {
+	var typ string
+	ok, err := p.skip("[")
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		inner, err := p.typeReference()
+		if err != nil {
+			return "", err
+		}
+		err = p.expect("]")
+		if err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		typ, err = p.name()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	ok, err = p.skip("!")
+	if ok {
+		typ += "!"
+	}
+
+	return typ, err
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	err := p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fragment{}
+	f.name, err = p.name()
+	if err != nil {
+		return nil, err
+	}
+	if f.name == "on" {
+		return nil, p.lexer.errorf(p.token.pos, "a fragment cannot be named on")
+	}
+
+	if !p.peek(tokenName, "on") {
+		return nil, p.lexer.errorf(p.token.pos, "expected \"on\", found %s", p.describe())
+	}
+	err = p.advance()
+	if err != nil {
+		return nil, err
+	}
+
+	f.typeCondition, err = p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	f.selection, err = p.selectionSet()
+	return f, err
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	err := p.expect("{")
+	if err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+	for !p.peek(tokenPunctuator, "}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+
+	if len(selections) == 0 {
+		return nil, p.lexer.errorf(p.token.pos, "a selection set cannot be empty")
+	}
+
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	ok, err := p.skip("...")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return p.field()
+	}
+
+	// ... on Type and ... without a type condition are inline fragments, anything else names a fragment
+	if p.token.kind == tokenName && p.token.value != "on" {
+		s := &fragmentSpread{name: p.token.value}
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+		s.directives, err = p.directives()
+		return s, err
+	}
+
+	f := &inlineFragment{}
+	if p.peek(tokenName, "on") {
+		err = p.advance()
+		if err != nil {
+			return nil, err
+		}
+		f.typeCondition, err = p.name()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.directives, err = p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	f.selection, err = p.selectionSet()
+	return f, err
+}
+
+func (p *parser) field() (*field, error) {
+	f := &field{}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := p.skip(":")
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	if ok {
+		f.alias = name
+		f.name, err = p.name()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.arguments, err = p.arguments(false)
+	if err != nil {
+		return nil, err
+	}
+
+	f.directives, err = p.directives()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek(tokenPunctuator, "{") {
+		f.selection, err = p.selectionSet()
+	}
+
+	return f, err
+}
+
+// arguments parses an optional argument list, constant lists cannot refer to variables
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	ok, err := p.skip("(")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var args []*argument
+	seen := make(map[string]bool)
+	for !p.peek(tokenPunctuator, ")") {
+		a, err := p.argument(constant)
+		if err != nil {
+			return nil, err
+		}
+		if seen[a.name] {
+			return nil, fmt.Errorf("there can be only one argument named %s", a.name)
+		}
+		seen[a.name] = true
+		args = append(args, a)
+	}
+
+	if len(args) == 0 {
+		return nil, p.lexer.errorf(p.token.pos, "an argument list cannot be empty")
+	}
+
+	return args, p.advance()
+}
+
+func (p *parser) argument(constant bool) (*argument, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.expect(":")
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := p.value(constant)
+	return &argument{name: name, value: v}, err
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek(tokenPunctuator, "@") {
+		err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+
+		d := &directive{}
+		d.name, err = p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		d.arguments, err = p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+
+	return directives, nil
+}
+
+func (p *parser) value(constant bool) (value, error) {
+	t := p.token
+	switch t.kind {
+	case tokenInt:
+		return intValue{raw: t.value}, p.advance()
+	case tokenFloat:
+		return floatValue{raw: t.value}, p.advance()
+	case tokenString:
+		return stringValue{value: t.value}, p.advance()
+	case tokenName:
+		var v value
+		switch t.value {
+		case "true", "false":
+			v = booleanValue{value: t.value == "true"}
+		case "null":
+			v = nullValue{}
+		default:
+			v = enumValue{name: t.value}
+		}
+		return v, p.advance()
+	}
+
+	switch {
+	case p.peek(tokenPunctuator, "$") && !constant:
+		err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variableValue{name: name}, err
+	case p.peek(tokenPunctuator, "["):
+		err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+		list := listValue{}
+		for !p.peek(tokenPunctuator, "]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list.values = append(list.values, v)
+		}
+		return list, p.advance()
+	case p.peek(tokenPunctuator, "{"):
+		err := p.advance()
+		if err != nil {
+			return nil, err
+		}
+		object := objectValue{}
+		for !p.peek(tokenPunctuator, "}") {
+			a, err := p.argument(constant)
+			if err != nil {
+				return nil, err
+			}
+			object.fields = append(object.fields, a)
+		}
+		return object, p.advance()
+	default:
+		return nil, p.unexpected()
+	}
+}
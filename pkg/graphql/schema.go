@@ -0,0 +1,296 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of a field or argument
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Field values of a scalar type are returned as their resolver returned them.
+type Scalar struct {
+	Name string
+	// coerce converts an argument value into the value resolvers receive
+	coerce func(v any) (any, bool)
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+// The built in scalars. Int arguments are given to resolvers as an int, Float as a float64, and ID as a string.
+var (
+	String  = &Scalar{Name: "String", coerce: coerceString}
+	Int     = &Scalar{Name: "Int", coerce: coerceInt}
+	Float   = &Scalar{Name: "Float", coerce: coerceFloat}
+	Boolean = &Scalar{Name: "Boolean", coerce: coerceBoolean}
+	ID      = &Scalar{Name: "ID", coerce: coerceID}
+)
+
+func coerceString(v any) (any, bool) {
+	s, ok := v.(string)
+	return s, ok
+}
+
+func coerceInt(v any) (any, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		// numbers in json variables are decoded as floats
+		if n != math.Trunc(n) || n > math.MaxInt32 || n < math.MinInt32 {
+			return nil, false
+		}
+		return int(n), true
+	default:
+		return nil, false
+	}
+}
+
+func coerceFloat(v any) (any, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return nil, false
+	}
+}
+
+func coerceBoolean(v any) (any, bool) {
+	b, ok := v.(bool)
+	return b, ok
+}
+
+func coerceID(v any) (any, bool) {
+	switch id := v.(type) {
+	case string:
+		return id, true
+	case int:
+		return strconv.Itoa(id), true
+	case float64:
+		if id != math.Trunc(id) {
+			return nil, false
+		}
+		return strconv.FormatFloat(id, 'f', -1, 64), true
+	default:
+		return nil, false
+	}
+}
+
+// Enum is a leaf type with a fixed set of values, given to resolvers and returned as strings
+type Enum struct {
+	Name   string
+	Values []string
+}
+
+func (e *Enum) String() string {
+	return e.Name
+}
+
+func (e *Enum) has(v string) bool {
+	for _, value := range e.Values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Object is a type with fields. Fields are added after the object is created when types refer to each other.
+type Object struct {
+	Name   string
+	Fields Fields
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+// Fields are an object's fields by name
+type Fields map[string]*Field
+
+// List is a list of values of a type
+type List struct {
+	OfType Type
+}
+
+func (l *List) String() string {
+	return "[" + l.OfType.String() + "]"
+}
+
+// NonNull is a type that is never null. A required argument is NonNull, and a NonNull field whose resolver returns nil makes its parent null instead.
+type NonNull struct {
+	OfType Type
+}
+
+func (n *NonNull) String() string {
+	return n.OfType.String() + "!"
+}
+
+// Field is a field of an object
+type Field struct {
+	Type Type
+	Args map[string]*Argument
+	// Resolve returns the field's value. Without one the value is read from the parent value, a map or a struct field with the field's name as its json name.
+	Resolve ResolveFunc
+}
+
+// Argument is an argument a field accepts
+type Argument struct {
+	Type Type
+	// Default is used when the argument is not given, it is not coerced
+	Default any
+}
+
+// ResolveFunc returns the value of a field
+type ResolveFunc func(p ResolveParams) (any, error)
+
+// ResolveParams are the inputs to a field's resolver
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value of the object the field belongs to, nil for the fields of the query and mutation types
+	Source any
+	Args   Args
+}
+
+// Args are the coerced argument values of a field, arguments that were not given and have no default are missing
+type Args map[string]any
+
+// String returns the string argument, or the empty string when it was not given
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns the int argument, and whether it was given
+func (a Args) Int(name string) (int, bool) {
+	i, ok := a[name].(int)
+	return i, ok
+}
+
+// Bool returns the bool argument, or fallback when it was not given
+func (a Args) Bool(name string, fallback bool) bool {
+	b, ok := a[name].(bool)
+	if !ok {
+		return fallback
+	}
+
+	return b
+}
+
+// Schema holds the root types requests are executed against
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+	// MaxDepth is how deeply selections may be nested, 0 uses DefaultMaxDepth
+	MaxDepth int
+}
+
+// DefaultMaxDepth bounds the nesting of queries so a query cannot ask for an object's children's children without end
+const DefaultMaxDepth = 10
+
+// coerceArgument converts the value of an argument to its type, checking every value of a list.
+// Enum values are written as names in a query but can only be strings in a variable's json, so strings are accepted for enums from variables.
+func coerceArgument(t Type, v any, fromVariable bool) (any, error) {
+	if nonNull, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected a non null %s", nonNull.OfType)
+		}
+		return coerceArgument(nonNull.OfType, v, fromVariable)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		coerced, ok := t.coerce(v)
+		if !ok {
+			return nil, fmt.Errorf("expected %s, found %s", t.Name, describeValue(v))
+		}
+		return coerced, nil
+	case *Enum:
+		s, ok := v.(enumName)
+		if str, isString := v.(string); isString && fromVariable {
+			s, ok = enumName(str), true
+		}
+		if !ok || !t.has(string(s)) {
+			return nil, fmt.Errorf("expected one of %s, found %s", strings.Join(t.Values, ", "), describeValue(v))
+		}
+		return string(s), nil
+	case *List:
+		values, ok := v.([]any)
+		// a single value is accepted where a list is expected
+		if !ok {
+			values = []any{v}
+		}
+		coerced := make([]any, 0, len(values))
+		for _, value := range values {
+			c, err := coerceArgument(t.OfType, value, fromVariable)
+			if err != nil {
+				return nil, err
+			}
+			coerced = append(coerced, c)
+		}
+		return coerced, nil
+	default:
+		return nil, fmt.Errorf("%s cannot be used as an argument", t)
+	}
+}
+
+// enumName is an enum value written in a query, told apart from a string so "UNREAD" is not accepted for an enum
+type enumName string
+
+func describeValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case enumName:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// defaultResolve reads the field from the parent value, a map keyed by the field's name or a struct with a field tagged with the name as its json name
+func defaultResolve(source any, name string) any {
+	if m, ok := source.(map[string]any); ok {
+		return m[name]
+	}
+
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == name || tag == "" && strings.EqualFold(f.Name, name) {
+			return v.Field(i).Interface()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package links
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidURL is returned for a url that is not an absolute http or https url
+var ErrInvalidURL = errors.New("url must be an absolute http or https url")
+
+// defaultPorts are dropped from normalized urls since they are implied by the scheme
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns the canonical form of an absolute http or https url so the same address written differently compares equal.
+// The scheme and host are lowercased, a default port, a trailing slash, and the fragment are removed. Any other url returns ErrInvalidURL.
+func Normalize(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[u.Scheme]; !ok || u.Host == "" || u.Opaque != "" {
+		return "", ErrInvalidURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", ErrInvalidURL
+	}
+
+	port := u.Port()
+	if port != "" && port != defaultPorts[u.Scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// an ipv6 address needs its brackets back once the port is gone
+		host = "[" + host + "]"
+	}
+	u.Host = host
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String(), nil
+}
+
+// Equal reports whether the urls point at the same address, ignoring case. Urls that cannot be normalized are compared as written.
+func Equal(a, b string) bool {
+	return strings.EqualFold(key(a), key(b))
+}
+
+func key(rawURL string) string {
+	normalized, err := Normalize(rawURL)
+	if err != nil {
+		return strings.TrimSpace(rawURL)
+	}
+
+	return normalized
+}
+
+// trackingParams are query parameters added to links for analytics that do not change what the link points at
+var trackingParams = []string{"utm_", "fbclid", "gclid", "mc_cid", "mc_eid"}
+
+// Canonical returns the form of an article link used to recognise the same article linked from different feeds.
+// On top of Normalize, http and https are treated alike, a leading www. is removed from the host, tracking parameters such as utm_source are dropped, and the rest of the query is sorted.
+// A link that cannot be normalized is returned trimmed of surrounding space.
+func Canonical(rawURL string) string {
+	normalized, err := Normalize(rawURL)
+	if err != nil {
+		return strings.TrimSpace(rawURL)
+	}
+
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return normalized
+	}
+
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	u.Host = strings.TrimPrefix(u.Host, "www.")
+
+	query := u.Query()
+	for name := range query {
+		if isTrackingParam(name) {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range trackingParams {
+		if strings.HasPrefix(name, param) {
+			return true
+		}
+	}
+
+	return false
+}
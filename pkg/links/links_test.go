@@ -0,0 +1,75 @@
+package links
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr error
+	}{
+		{name: "already normal", url: "https://example.com/feed.xml", want: "https://example.com/feed.xml"},
+		{name: "surrounding space", url: "  https://example.com/feed.xml\n", want: "https://example.com/feed.xml"},
+		{name: "uppercase scheme and host", url: "HTTPS://Example.COM/Feed.xml", want: "https://example.com/Feed.xml"},
+		{name: "default https port", url: "https://example.com:443/feed.xml", want: "https://example.com/feed.xml"},
+		{name: "default http port", url: "http://example.com:80/feed.xml", want: "http://example.com/feed.xml"},
+		{name: "non default port", url: "http://example.com:8080/feed.xml", want: "http://example.com:8080/feed.xml"},
+		{name: "https on port 80 keeps the port", url: "https://example.com:80/feed.xml", want: "https://example.com:80/feed.xml"},
+		{name: "trailing slash", url: "https://example.com/blog/", want: "https://example.com/blog"},
+		{name: "root", url: "https://example.com/", want: "https://example.com"},
+		{name: "fragment", url: "https://example.com/post#comments", want: "https://example.com/post"},
+		{name: "query is kept", url: "https://example.com/feed?format=rss", want: "https://example.com/feed?format=rss"},
+		{name: "ipv6 with default port", url: "http://[::1]:80/feed", want: "http://[::1]/feed"},
+		{name: "ipv6 with port", url: "http://[::1]:8080/feed", want: "http://[::1]:8080/feed"},
+		{name: "schemeless", url: "example.com/feed.xml", wantErr: ErrInvalidURL},
+		{name: "relative", url: "/feed.xml", wantErr: ErrInvalidURL},
+		{name: "protocol relative", url: "//example.com/feed.xml", wantErr: ErrInvalidURL},
+		{name: "ftp", url: "ftp://example.com/feed.xml", wantErr: ErrInvalidURL},
+		{name: "javascript", url: "javascript:alert(1)", wantErr: ErrInvalidURL},
+		{name: "missing host", url: "https:///feed.xml", wantErr: ErrInvalidURL},
+		{name: "port without host", url: "https://:443/feed.xml", wantErr: ErrInvalidURL},
+		{name: "unparseable", url: "https://exa mple.com/%zz", wantErr: ErrInvalidURL},
+		{name: "empty", url: "", wantErr: ErrInvalidURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.url)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal("https://example.com/posts/1/", "HTTPS://EXAMPLE.com:443/posts/1"))
+	assert.True(t, Equal("https://example.com/Posts/1", "https://example.com/posts/1"))
+	assert.True(t, Equal("not a url", "NOT A URL"))
+	assert.False(t, Equal("https://example.com/posts/1", "https://example.com/posts/2"))
+	assert.False(t, Equal("http://example.com/posts/1", "https://example.com/posts/1"))
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "already canonical", url: "https://example.com/posts/1", want: "https://example.com/posts/1"},
+		{name: "normalized", url: "HTTPS://Example.com:443/posts/1/#comments", want: "https://example.com/posts/1"},
+		{name: "http", url: "http://example.com/posts/1", want: "https://example.com/posts/1"},
+		{name: "www", url: "https://www.example.com/posts/1", want: "https://example.com/posts/1"},
+		{name: "tracking parameters", url: "https://example.com/posts/1?utm_source=rss&UTM_Medium=feed&fbclid=abc", want: "https://example.com/posts/1"},
+		{name: "query is sorted", url: "https://example.com/post?p=1&lang=en&utm_campaign=x", want: "https://example.com/post?lang=en&p=1"},
+		{name: "not a url", url: " not a link ", want: "not a link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Canonical(tt.url))
+		})
+	}
+}
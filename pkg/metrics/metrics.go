@@ -0,0 +1,333 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrAlreadyRegistered is returned when a collector with the same name is already registered
+var ErrAlreadyRegistered = errors.New("metric already registered")
+
+// DefaultBuckets are the histogram upper bounds in seconds used for request latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a metric that can be written in the prometheus text exposition format
+type Collector interface {
+	Name() string
+	write(w io.Writer) error
+}
+
+// AlreadyRegisteredError wraps ErrAlreadyRegistered with the collector that was registered first so callers can share it
+type AlreadyRegisteredError struct {
+	Existing Collector
+}
+
+func (e *AlreadyRegisteredError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrAlreadyRegistered, e.Existing.Name())
+}
+
+func (e *AlreadyRegisteredError) Unwrap() error {
+	return ErrAlreadyRegistered
+}
+
+// Registry holds the collectors served by its handler. Each server can use its own registry so tests do not collide on metric names.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]Collector),
+	}
+}
+
+// Register adds the collector, returning an *AlreadyRegisteredError when its name is taken
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.collectors[c.Name()]; ok {
+		return &AlreadyRegisteredError{Existing: existing}
+	}
+
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+// Share registers c and returns it, or returns the collector already registered under its name when it is the same type.
+// This lets several instances record into one registry without failing on duplicate registration.
+func Share[T Collector](r *Registry, c T) T {
+	err := r.Register(c)
+
+	var already *AlreadyRegisteredError
+	if errors.As(err, &already) {
+		if existing, ok := already.Existing.(T); ok {
+			return existing
+		}
+	}
+
+	return c
+}
+
+// Write writes every registered collector sorted by name
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].Name() < collectors[j].Name()
+	})
+
+	for _, c := range collectors {
+		err := c.write(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the registered metrics in the prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("content-type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// vec holds one value per combination of label values
+type vec[T any] struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*T
+	keys   map[string][]string
+	new    func() *T
+}
+
+func newVec[T any](name, help string, labels []string, new func() *T) vec[T] {
+	return vec[T]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*T),
+		keys:   make(map[string][]string),
+		new:    new,
+	}
+}
+
+// with returns the value for the label values, which are matched to the labels by position. The vec's lock must be held.
+func (v *vec[T]) with(labelValues []string) *T {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	value, ok := v.values[key]
+	if !ok {
+		value = v.new()
+		v.values[key] = value
+		v.keys[key] = append([]string(nil), labelValues...)
+	}
+
+	return value
+}
+
+// sortedKeys returns the value keys in a stable order. The vec's lock must be held.
+func (v *vec[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec[T]) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, kind)
+	return err
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	vec[float64]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		vec: newVec(name, help, labels, func() *float64 { return new(float64) }),
+	}
+}
+
+func (c *CounterVec) Name() string {
+	return c.name
+}
+
+// Inc adds one to the counter for the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the counter for the label values. Counters only go up, so negative values are ignored.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*c.with(labelValues) += v
+}
+
+// Value returns the counter for the label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return *c.with(labelValues)
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.header(w, "counter")
+	if err != nil {
+		return err
+	}
+
+	for _, k := range c.sortedKeys() {
+		_, err = fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.keys[k]), formatValue(*c.values[k]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec counts observations into buckets, partitioned by labels
+type HistogramVec struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// NewHistogramVec creates a histogram with the bucket upper bounds, which must be sorted in increasing order
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		vec: newVec(name, help, labels, func() *histogram {
+			return &histogram{counts: make([]uint64, len(buckets))}
+		}),
+		buckets: buckets,
+	}
+}
+
+func (h *HistogramVec) Name() string {
+	return h.name
+}
+
+// Observe records v for the label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist := h.with(labelValues)
+	for i, upper := range h.buckets {
+		if v <= upper {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+// Count returns the number of observations for the label values
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.with(labelValues).count
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	err := h.header(w, "histogram")
+	if err != nil {
+		return err
+	}
+
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, k := range h.sortedKeys() {
+		hist := h.values[k]
+		values := h.keys[k]
+		bucketValues := append(append([]string(nil), values...), "")
+		for i, upper := range h.buckets {
+			bucketValues[len(values)] = formatValue(upper)
+			_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, bucketValues), hist.counts[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		bucketValues[len(values)] = "+Inf"
+		_, err = fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, formatLabels(bucketLabels, bucketValues), hist.count,
+			h.name, formatLabels(h.labels, values), formatValue(hist.sum),
+			h.name, formatLabels(h.labels, values), hist.count,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func formatLabels(labels, values []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, l, labelEscaper.Replace(values[i]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	first := NewCounterVec("requests_total", "requests")
+
+	err := r.Register(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Register(NewCounterVec("requests_total", "requests"))
+	assert.ErrorIs(t, err, ErrAlreadyRegistered)
+
+	var already *AlreadyRegisteredError
+	if !errors.As(err, &already) {
+		t.Fatal("expected an AlreadyRegisteredError")
+	}
+	assert.Same(t, first, already.Existing)
+
+	assert.NoError(t, NewRegistry().Register(NewCounterVec("requests_total", "requests")))
+}
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	counter := NewCounterVec("requests_total", "Requests served.", "path", "status")
+	histogram := NewHistogramVec("request_duration_seconds", "Request latency.", []float64{0.1, 1}, "path")
+	for _, c := range []Collector{counter, histogram} {
+		err := r.Register(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counter.Inc("/b", "200")
+	counter.Add(2, "/a", "200")
+	counter.Add(-1, "/a", "200")
+	counter.Inc("/a", "500")
+	histogram.Observe(0.05, `/"quoted"`)
+	histogram.Observe(0.5, `/"quoted"`)
+	histogram.Observe(3, `/"quoted"`)
+
+	var b bytes.Buffer
+	err := r.Write(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP request_duration_seconds Request latency.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{path="/\"quoted\"",le="0.1"} 1
+request_duration_seconds_bucket{path="/\"quoted\"",le="1"} 2
+request_duration_seconds_bucket{path="/\"quoted\"",le="+Inf"} 3
+request_duration_seconds_sum{path="/\"quoted\""} 3.55
+request_duration_seconds_count{path="/\"quoted\""} 3
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{path="/a",status="200"} 2
+requests_total{path="/a",status="500"} 1
+requests_total{path="/b",status="200"} 1
+`
+	assert.Equal(t, want, b.String())
+	assert.Equal(t, float64(2), counter.Value("/a", "200"))
+	assert.Equal(t, uint64(3), histogram.Count(`/"quoted"`))
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	counter := NewCounterVec("polls_total", "Polls run.")
+	err := r.Register(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("content-type"))
+	assert.Equal(t, "# HELP polls_total Polls run.\n# TYPE polls_total counter\npolls_total 1\n", w.Body.String())
+}
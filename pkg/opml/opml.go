@@ -0,0 +1,122 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// OPML describes an outline processor markup language document used to share feed subscriptions
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title string `xml:"title"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is a single entry in an OPML document. Outlines without an XMLURL are treated as folders for nested outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// New creates an empty OPML 2.0 document with the given title
+func New(title string) *OPML {
+	return &OPML{
+		Version: "2.0",
+		Head: Head{
+			Title: title,
+		},
+		Body: Body{
+			Outlines: make([]Outline, 0),
+		},
+	}
+}
+
+// Marshal encodes the document as indented xml, including the xml header
+func (o *OPML) Marshal() ([]byte, error) {
+	b, err := xml.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+// Parse decodes an OPML document from the reader
+func Parse(r io.Reader) (*OPML, error) {
+	doc := new(OPML)
+	err := xml.NewDecoder(r).Decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Feeds flattens any nested folders in the document and returns every outline that has an xmlUrl
+func (o *OPML) Feeds() []Outline {
+	return flatten(o.Body.Outlines)
+}
+
+// Subscription is a feed outline along with the folder it was nested in
+type Subscription struct {
+	Outline
+	// Folder is the name of the nearest outline the feed is nested in, empty for a feed at the top level
+	Folder string
+}
+
+// Subscriptions returns every outline that has an xmlUrl along with the folder it was nested in.
+// Folders nested in other folders are not kept, a feed belongs to the folder nearest to it.
+func (o *OPML) Subscriptions() []Subscription {
+	return subscriptions(o.Body.Outlines, "")
+}
+
+func subscriptions(outlines []Outline, folder string) []Subscription {
+	subs := make([]Subscription, 0)
+	for _, o := range outlines {
+		if o.XMLURL != "" {
+			subs = append(subs, Subscription{Outline: o, Folder: folder})
+		}
+
+		if len(o.Outlines) > 0 {
+			subs = append(subs, subscriptions(o.Outlines, o.folderName(folder))...)
+		}
+	}
+	return subs
+}
+
+// folderName is the name of the folder holding the outline's nested outlines, or parent when the outline has no name
+func (o Outline) folderName(parent string) string {
+	for _, name := range []string{o.Text, o.Title} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return parent
+}
+
+func flatten(outlines []Outline) []Outline {
+	feeds := make([]Outline, 0)
+	for _, o := range outlines {
+		if o.XMLURL != "" {
+			feeds = append(feeds, o)
+		}
+
+		feeds = append(feeds, flatten(o.Outlines)...)
+	}
+
+	return feeds
+}
@@ -0,0 +1,79 @@
+package opml
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("error parsing xml", func(t *testing.T) {
+		doc, err := Parse(bytes.NewReader([]byte(`<`)))
+		if err == nil {
+			t.Error("expected err: err is nil")
+		}
+
+		if doc != nil {
+			t.Errorf("Parse() = %v, expected %v", doc, nil)
+		}
+	})
+
+	t.Run("flattens nested outlines", func(t *testing.T) {
+		b, err := os.ReadFile("testing/subscriptions.opml")
+		if err != nil {
+			t.Error(err)
+		}
+
+		doc, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		want := []Outline{
+			{
+				Text:    "blog.kyledev.co",
+				Type:    "rss",
+				XMLURL:  "https://blog.kyledev.co/index.xml",
+				HTMLURL: "https://blog.kyledev.co/",
+			},
+			{
+				Text:    "go blog",
+				Type:    "rss",
+				XMLURL:  "https://go.dev/blog/feed.atom",
+				HTMLURL: "https://go.dev/blog/",
+			},
+			{
+				Text:   "example",
+				Type:   "rss",
+				XMLURL: "https://example.com/feed.xml",
+			},
+		}
+
+		assert.Equal(t, "subscriptions", doc.Head.Title)
+		assert.Equal(t, want, doc.Feeds())
+	})
+
+	t.Run("keeps the nearest folder of each feed", func(t *testing.T) {
+		b, err := os.ReadFile("testing/subscriptions.opml")
+		if err != nil {
+			t.Error(err)
+		}
+
+		doc, err := Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		folders := make(map[string]string)
+		for _, sub := range doc.Subscriptions() {
+			folders[sub.XMLURL] = sub.Folder
+		}
+		assert.Equal(t, map[string]string{
+			"https://blog.kyledev.co/index.xml": "",
+			"https://go.dev/blog/feed.atom":     "tech",
+			"https://example.com/feed.xml":      "nested",
+		}, folders)
+	})
+}
@@ -12,6 +12,9 @@ import (
 type Parser interface {
 	Parse(io.Reader) (*RSSFeed, error)
 	ParseFromURI(ctx context.Context, uri string) (*RSSFeed, error)
+	ConditionalParseFromURI(ctx context.Context, uri, etag, lastModified string) (*RSSFeed, error)
+	DiscoverFeeds(ctx context.Context, uri string) ([]string, error)
+	FullText(ctx context.Context, uri string) (string, error)
 }
 
 // HTTP describes how to make an http request. This interface serves the purpose of providing a way to mock http requests.
@@ -23,6 +26,16 @@ type HTTP interface {
 
 type RSSFeed struct {
 	Channel Channel `xml:"channel"`
+	// ETag and LastModified are the cache validators from the response the feed was fetched from
+	ETag         string `xml:"-"`
+	LastModified string `xml:"-"`
+	// RedirectURL is the final url of the feed when every redirect followed to fetch it was permanent
+	RedirectURL string `xml:"-"`
+}
+
+// empty reports whether the document had nothing a feed is made of
+func (f *RSSFeed) empty() bool {
+	return f.Channel.Title == "" && len(f.Channel.Items) == 0
 }
 
 type Channel struct {
@@ -31,14 +44,28 @@ type Channel struct {
 	Description   string `xml:"description"`
 	Generator     string `xml:"generator"`
 	LastBuildDate string `xml:"lastBuildDate"`
-	Items         []Item `xml:"item"`
+	Language      string `xml:"language"`
+	Copyright     string `xml:"copyright"`
+	// Image is the url of the channel's logo, empty when the feed has none
+	Image string `xml:"image>url"`
+	Items []Item `xml:"item"`
 }
 
 type Item struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	PubDate     string `xml:"pubDate"`
-	GUID        string `xml:"guid"`
-	Description string `xml:"description"`
-	Author      string `xml:"author"`
+	Title          string     `xml:"title"`
+	Link           string     `xml:"link"`
+	PubDate        string     `xml:"pubDate"`
+	GUID           string     `xml:"guid"`
+	Description    string     `xml:"description"`
+	Author         string     `xml:"author"`
+	ContentEncoded string     `xml:"encoded"`
+	Enclosure      *Enclosure `xml:"enclosure"`
+	Categories     []string   `xml:"category"`
+}
+
+// Enclosure is a media file attached to an item, such as a podcast episode's audio. Only an item's first enclosure is kept.
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
 }
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// feedLinkTypes are the link types advertised by pages for feeds the parser can read
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+}
+
+// DiscoverFeeds fetches the html page at uri and returns the absolute urls of the feeds it advertises with <link rel="alternate"> elements.
+// A page without any feed links returns an empty slice.
+func (fr FeedParser) DiscoverFeeds(ctx context.Context, uri string) ([]string, error) {
+	var links []string
+	err := fr.fetchHTML(ctx, uri, func(base *url.URL, contentType string, body io.Reader) error {
+		var err error
+		links, err = discoverFeedLinks(base, body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// fetchHTML fetches the html page at uri and hands read its body, limited to fr.maxBodySize, along with its Content-Type and the url it was served from.
+// The body is only readable until read returns.
+func (fr FeedParser) fetchHTML(ctx context.Context, uri string, read func(base *url.URL, contentType string, body io.Reader) error) error {
+	if fr.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fr.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", fr.userAgent)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	resp, err := fr.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := decompress(resp)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// relative links resolve against the page that was served, which differs from uri after a redirect
+	base := req.URL
+	if resp.Request != nil {
+		base = resp.Request.URL
+	}
+
+	return read(base, resp.Header.Get("Content-Type"), &limitedReader{r: body, n: fr.maxBodySize})
+}
+
+// discoverFeedLinks reads the html document leniently, collecting the feed links in the order they appear
+func discoverFeedLinks(base *url.URL, reader io.Reader) ([]string, error) {
+	decoder := xml.NewDecoder(reader)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charsetReader
+
+	links := make([]string, 0)
+	seen := make(map[string]bool)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, ErrFeedTooLarge) {
+				return nil, err
+			}
+
+			// real world html is rarely well formed, so keep whatever links were found before the decoder gave up
+			return links, nil
+		}
+
+		e, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(e.Name.Local) {
+		case "base":
+			if href := attr(e, "href"); href != "" {
+				if u, err := base.Parse(href); err == nil {
+					base = u
+				}
+			}
+		case "link":
+			if !hasToken(attr(e, "rel"), "alternate") || !feedLinkTypes[strings.ToLower(attr(e, "type"))] {
+				continue
+			}
+
+			href := attr(e, "href")
+			if href == "" {
+				continue
+			}
+
+			u, err := base.Parse(href)
+			if err != nil || seen[u.String()] {
+				continue
+			}
+
+			seen[u.String()] = true
+			links = append(links, u.String())
+		}
+	}
+}
+
+// attr returns the trimmed value of the element's attribute, matching the name without regard to case
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return strings.TrimSpace(a.Value)
+		}
+	}
+
+	return ""
+}
+
+// hasToken reports whether the space separated list, such as a rel attribute, contains the token
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotModified      = errors.New("feed not modified")
+	ErrTooManyRedirects = errors.New("too many redirects")
+	ErrRedirectScheme   = errors.New("redirect to unsupported scheme")
+	ErrFeedTooLarge     = errors.New("feed too large")
+	// ErrEmptyFeed is returned for a document with neither a title nor any items, such as an empty body or one with only an xml declaration
+	ErrEmptyFeed = errors.New("feed is empty")
+	// ErrNoContent is returned for a page without any readable content to extract
+	ErrNoContent = errors.New("page has no readable content")
+)
+
+// StatusError is returned when a feed is fetched with an unsuccessful status code
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
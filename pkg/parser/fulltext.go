@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/kdwils/feedreader/pkg/sanitize"
+)
+
+// FullText fetches the html page at uri and returns the sanitized html of its main content, such as the body of the article the page shows.
+// ErrNoContent is returned for a page without any content to extract.
+func (fr FeedParser) FullText(ctx context.Context, uri string) (string, error) {
+	var content string
+	err := fr.fetchHTML(ctx, uri, func(base *url.URL, contentType string, body io.Reader) error {
+		// pages are assumed to be utf-8 unless the response says otherwise
+		if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" && !strings.EqualFold(params["charset"], "utf-8") {
+			body, err = charsetReader(params["charset"], body)
+			if err != nil {
+				return err
+			}
+		}
+
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+
+		content = sanitize.Readable(string(b), base)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if content == "" {
+		return "", ErrNoContent
+	}
+
+	return content, nil
+}
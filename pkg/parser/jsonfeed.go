@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// jsonFeed is a JSON Feed document as described by https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Icon        string         `json:"icon"`
+	Favicon     string         `json:"favicon"`
+	Language    string         `json:"language"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	Tags          []string             `json:"tags"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+	// Author is from version 1.0 of the spec, which 1.1 replaces with Authors
+	Author  *jsonFeedAuthor  `json:"author"`
+	Authors []jsonFeedAuthor `json:"authors"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+}
+
+// isJSONContentType reports whether the Content-Type header is a JSON Feed or generic JSON media type
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/feed+json" || mediaType == "application/json"
+}
+
+// looksLikeJSON reports whether the first non whitespace byte is the opening brace of a JSON object
+func looksLikeJSON(r *bufio.Reader) bool {
+	for n := 1; n <= r.Size(); n++ {
+		b, _ := r.Peek(n)
+		if len(b) < n {
+			return false
+		}
+
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// parseJSONFeed maps a JSON Feed document onto the rss structures so the rest of the reader does not need to know the feed's format
+func parseJSONFeed(reader io.Reader) (*RSSFeed, error) {
+	var doc jsonFeed
+	err := json.NewDecoder(reader).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &RSSFeed{
+		Channel: Channel{
+			Title:       strings.TrimSpace(doc.Title),
+			Link:        strings.TrimSpace(doc.HomePageURL),
+			Description: strings.TrimSpace(doc.Description),
+			Image:       strings.TrimSpace(doc.Icon),
+			Language:    strings.TrimSpace(doc.Language),
+		},
+	}
+
+	if feed.Channel.Image == "" {
+		feed.Channel.Image = strings.TrimSpace(doc.Favicon)
+	}
+
+	for _, i := range doc.Items {
+		if feed.Channel.Items == nil {
+			feed.Channel.Items = make([]Item, 0)
+		}
+
+		item := Item{
+			Title:       strings.TrimSpace(i.Title),
+			Link:        strings.TrimSpace(i.URL),
+			GUID:        strings.TrimSpace(i.ID),
+			Description: strings.TrimSpace(i.Summary),
+			PubDate:     jsonFeedDate(i.DatePublished),
+			Categories:  i.Tags,
+		}
+
+		item.ContentEncoded = strings.TrimSpace(i.ContentHTML)
+		if item.ContentEncoded == "" {
+			item.ContentEncoded = strings.TrimSpace(i.ContentText)
+		}
+
+		switch {
+		case len(i.Authors) > 0:
+			item.Author = strings.TrimSpace(i.Authors[0].Name)
+		case i.Author != nil:
+			item.Author = strings.TrimSpace(i.Author.Name)
+		}
+
+		if len(i.Attachments) > 0 && i.Attachments[0].URL != "" {
+			item.Enclosure = &Enclosure{
+				URL:    i.Attachments[0].URL,
+				Type:   i.Attachments[0].MimeType,
+				Length: i.Attachments[0].SizeInBytes,
+			}
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	if feed.empty() {
+		return nil, ErrEmptyFeed
+	}
+
+	return feed, nil
+}
+
+// jsonFeedDate reformats an RFC 3339 date in the RFC 1123 style used by rss pubDate. Dates that do not parse are kept as is.
+func jsonFeedDate(date string) string {
+	date = strings.TrimSpace(date)
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+
+	return t.Format(time.RFC1123Z)
+}
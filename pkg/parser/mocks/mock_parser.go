@@ -36,6 +36,51 @@ func (m *MockParser) EXPECT() *MockParserMockRecorder {
 	return m.recorder
 }
 
+// ConditionalParseFromURI mocks base method.
+func (m *MockParser) ConditionalParseFromURI(arg0 context.Context, arg1, arg2, arg3 string) (*parser.RSSFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConditionalParseFromURI", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*parser.RSSFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConditionalParseFromURI indicates an expected call of ConditionalParseFromURI.
+func (mr *MockParserMockRecorder) ConditionalParseFromURI(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalParseFromURI", reflect.TypeOf((*MockParser)(nil).ConditionalParseFromURI), arg0, arg1, arg2, arg3)
+}
+
+// DiscoverFeeds mocks base method.
+func (m *MockParser) DiscoverFeeds(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverFeeds", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverFeeds indicates an expected call of DiscoverFeeds.
+func (mr *MockParserMockRecorder) DiscoverFeeds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverFeeds", reflect.TypeOf((*MockParser)(nil).DiscoverFeeds), arg0, arg1)
+}
+
+// FullText mocks base method.
+func (m *MockParser) FullText(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FullText", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FullText indicates an expected call of FullText.
+func (mr *MockParserMockRecorder) FullText(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FullText", reflect.TypeOf((*MockParser)(nil).FullText), arg0, arg1)
+}
+
 // Parse mocks base method.
 func (m *MockParser) Parse(arg0 io.Reader) (*parser.RSSFeed, error) {
 	m.ctrl.T.Helper()
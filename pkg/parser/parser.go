@@ -1,35 +1,132 @@
 package parser
 
 import (
+	"bufio"
 	"context"
 	"encoding/xml"
 	"errors"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultUserAgent = "feedreader/1.0"
+	// DefaultMaxBodySize is the largest decompressed feed body read by default
+	DefaultMaxBodySize int64 = 5 << 20
 )
 
 type FeedParser struct {
-	http HTTP
+	http         HTTP
+	userAgent    string
+	timeout      time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxBodySize  int64
 }
 
-func New(http HTTP) Parser {
-	return FeedParser{
-		http: http,
+// Option configures optional FeedParser settings
+type Option func(*FeedParser)
+
+// WithUserAgent sets the User-Agent header sent when fetching feeds
+func WithUserAgent(userAgent string) Option {
+	return func(fp *FeedParser) {
+		if userAgent != "" {
+			fp.userAgent = userAgent
+		}
+	}
+}
+
+// WithTimeout sets the deadline for fetching and parsing a single feed. A zero timeout means no deadline.
+func WithTimeout(timeout time.Duration) Option {
+	return func(fp *FeedParser) {
+		fp.timeout = timeout
+	}
+}
+
+// WithRetry retries fetches that fail with a server error or connection error up to maxAttempts times in total.
+// The delay between attempts starts at backoff and doubles after each attempt, with jitter added.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(fp *FeedParser) {
+		if maxAttempts > 0 {
+			fp.maxAttempts = maxAttempts
+		}
+		fp.retryBackoff = backoff
 	}
 }
 
+// WithMaxBodySize caps how many bytes of a decompressed feed body are read before failing with ErrFeedTooLarge
+func WithMaxBodySize(maxBodySize int64) Option {
+	return func(fp *FeedParser) {
+		if maxBodySize > 0 {
+			fp.maxBodySize = maxBodySize
+		}
+	}
+}
+
+func New(http HTTP, opts ...Option) Parser {
+	fp := FeedParser{
+		http:        http,
+		userAgent:   DefaultUserAgent,
+		maxAttempts: 1,
+		maxBodySize: DefaultMaxBodySize,
+	}
+
+	for _, opt := range opts {
+		opt(&fp)
+	}
+
+	return fp
+}
+
 type tokenBuffer struct {
 	feed        *RSSFeed
 	buffer      string
 	data        bool
 	openItemTag bool
+	// openImageTag is set inside the channel's image element, whose title and link describe the image rather than the channel
+	openImageTag bool
+	// link holds the attributes of the link element being read, and the ranks are those of the links chosen so far for the channel and the current item
+	link            link
+	channelLinkRank int
+	itemLinkRank    int
+}
+
+// link is a link element's attributes. Atom style links hold the url in href while rss links hold it as text.
+type link struct {
+	href     string
+	rel      string
+	linkType string
+}
+
+// rank orders links by how well they point at the web page of a channel or item, 0 meaning not at all.
+// Only alternate links point at the page, so self and hub links are skipped, and an html link is preferred over another format. A link without a rel is an alternate link.
+func (l link) rank() int {
+	rel := strings.ToLower(l.rel)
+	if rel != "" && rel != "alternate" {
+		return 0
+	}
+
+	switch strings.ToLower(l.linkType) {
+	case "", "text/html", "application/xhtml+xml":
+		return 2
+	default:
+		return 1
+	}
 }
 
 func (tb *tokenBuffer) reset() {
 	tb.buffer = ""
 }
 
+// trim removes the whitespace surrounding an element's data, such as indentation around a CDATA block
+func (tb *tokenBuffer) trim() {
+	tb.buffer = strings.TrimSpace(tb.buffer)
+}
+
 func (tb *tokenBuffer) ok() bool {
 	return tb.buffer != ""
 }
@@ -42,8 +139,15 @@ func (tb *tokenBuffer) itemsLen() int {
 	return len(tb.feed.Channel.Items) - 1
 }
 
+// Parse reads an rss or atom document, or a JSON Feed document when the content starts with a JSON object
 func (fr FeedParser) Parse(reader io.Reader) (*RSSFeed, error) {
-	decoder := xml.NewDecoder(reader)
+	br := bufio.NewReader(reader)
+	if looksLikeJSON(br) {
+		return parseJSONFeed(br)
+	}
+
+	decoder := xml.NewDecoder(br)
+	decoder.CharsetReader = charsetReader
 	tb := &tokenBuffer{
 		feed: new(RSSFeed),
 	}
@@ -67,39 +171,188 @@ func (fr FeedParser) Parse(reader io.Reader) (*RSSFeed, error) {
 		}
 	}
 
+	if tb.feed.empty() {
+		return nil, ErrEmptyFeed
+	}
+
 	return tb.feed, nil
 }
 
+// atomNamespace is the namespace of atom elements, which tells them apart from elements of the same name in other namespaces such as itunes:summary
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// isItem reports whether the element is an rss item or an atom entry, which are read the same way
+func isItem(name string) bool {
+	return name == "item" || name == "entry"
+}
+
 func (tb *tokenBuffer) parseStartElement(e xml.StartElement) {
-	if e.Name.Local == "item" {
+	if isItem(e.Name.Local) {
 		tb.openItemTag = true
 		if tb.feed.Channel.Items == nil {
 			tb.feed.Channel.Items = make([]Item, 0)
 		}
 
 		tb.feed.Channel.Items = append(tb.feed.Channel.Items, Item{})
+		tb.itemLinkRank = 0
+	}
+
+	if e.Name.Local == "link" {
+		tb.link = link{}
+		for _, attr := range e.Attr {
+			switch attr.Name.Local {
+			case "href":
+				tb.link.href = strings.TrimSpace(attr.Value)
+			case "rel":
+				tb.link.rel = strings.TrimSpace(attr.Value)
+			case "type":
+				tb.link.linkType = strings.TrimSpace(attr.Value)
+			}
+		}
+	}
+
+	if e.Name.Local == "image" && !tb.openItemTag {
+		tb.parseImageStartElement(e)
+	}
+
+	if e.Name.Local == "enclosure" && tb.openItemTag && tb.feed.Channel.Items[tb.itemsLen()].Enclosure == nil {
+		tb.feed.Channel.Items[tb.itemsLen()].Enclosure = parseEnclosure(e.Attr)
 	}
 	tb.reset()
 }
 
+// parseImageStartElement handles the rss image element, which holds the logo url in a nested url element, and the itunes:image element, which holds it in an href attribute
+func (tb *tokenBuffer) parseImageStartElement(e xml.StartElement) {
+	for _, attr := range e.Attr {
+		if attr.Name.Local == "href" {
+			if tb.feed.Channel.Image == "" {
+				tb.feed.Channel.Image = strings.TrimSpace(attr.Value)
+			}
+			return
+		}
+	}
+
+	tb.openImageTag = true
+}
+
+// parseEnclosure reads an enclosure from its attributes. An enclosure without a url is ignored.
+func parseEnclosure(attrs []xml.Attr) *Enclosure {
+	var enclosure Enclosure
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "url":
+			enclosure.URL = strings.TrimSpace(attr.Value)
+		case "type":
+			enclosure.Type = strings.TrimSpace(attr.Value)
+		case "length":
+			// feeds commonly leave length empty or set it to 0 when the size is unknown
+			enclosure.Length, _ = strconv.ParseInt(strings.TrimSpace(attr.Value), 10, 64)
+		}
+	}
+
+	if enclosure.URL == "" {
+		return nil
+	}
+
+	return &enclosure
+}
+
 func (tb *tokenBuffer) parseEndElement(e xml.EndElement) {
 	// a closing element means we need to reset the buffer after its read because there is no more data to be parsed for that tag
 	defer tb.reset()
 
+	if e.Name.Local == "image" {
+		tb.openImageTag = false
+	}
+
+	// an item usually closes after whitespace alone, so it is closed before elements without text are skipped
+	if isItem(e.Name.Local) {
+		tb.openItemTag = false
+		return
+	}
+
+	tb.trim()
+	// links with an href have no text, so they are handled before elements without text are skipped
+	if e.Name.Local == "link" && !tb.openImageTag {
+		tb.parseLinkEndElement()
+		return
+	}
+
 	if !tb.ok() {
 		return
 	}
 
+	if tb.openImageTag {
+		if e.Name.Local == "url" {
+			tb.feed.Channel.Image = tb.buffer
+		}
+		return
+	}
+
 	switch e.Name.Local {
 	case "generator":
 		tb.feed.Channel.Generator = tb.buffer
 	case "lastBuildDate":
 		tb.feed.Channel.LastBuildDate = tb.buffer
+	case "updated":
+		if e.Name.Space != atomNamespace {
+			return
+		}
+
+		// atom feeds have no lastBuildDate, and an entry's updated date stands in for its published date when it has none
+		if !tb.openItemTag {
+			tb.feed.Channel.LastBuildDate = tb.buffer
+			return
+		}
+
+		item := &tb.feed.Channel.Items[tb.itemsLen()]
+		if item.PubDate == "" {
+			item.PubDate = tb.buffer
+		}
+	case "published":
+		if !tb.openItemTag || e.Name.Space != atomNamespace {
+			return
+		}
+
+		tb.feed.Channel.Items[tb.itemsLen()].PubDate = tb.buffer
+	case "language":
+		if !tb.openItemTag {
+			tb.feed.Channel.Language = tb.buffer
+		}
+	case "copyright":
+		if !tb.openItemTag {
+			tb.feed.Channel.Copyright = tb.buffer
+		}
 	case "pubDate":
+		if !tb.openItemTag {
+			return
+		}
+
 		tb.feed.Channel.Items[tb.itemsLen()].PubDate = tb.buffer
 	case "guid":
+		if !tb.openItemTag {
+			return
+		}
+
+		tb.feed.Channel.Items[tb.itemsLen()].GUID = tb.buffer
+	case "id":
+		if !tb.openItemTag || e.Name.Space != atomNamespace {
+			return
+		}
+
 		tb.feed.Channel.Items[tb.itemsLen()].GUID = tb.buffer
 	case "author":
+		if !tb.openItemTag {
+			return
+		}
+
+		tb.feed.Channel.Items[tb.itemsLen()].Author = tb.buffer
+	case "creator":
+		// dc:creator is only used as the author when the item does not provide an explicit author element
+		if !tb.openItemTag || tb.feed.Channel.Items[tb.itemsLen()].Author != "" {
+			return
+		}
+
 		tb.feed.Channel.Items[tb.itemsLen()].Author = tb.buffer
 	case "title":
 		if tb.openItemTag {
@@ -108,6 +361,12 @@ func (tb *tokenBuffer) parseEndElement(e xml.EndElement) {
 		}
 
 		tb.feed.Channel.Title = tb.buffer
+	case "summary", "subtitle":
+		// itunes has summary and subtitle elements of its own, which are left to the description
+		if e.Name.Space != atomNamespace {
+			return
+		}
+		fallthrough
 	case "description":
 		if tb.openItemTag {
 			tb.feed.Channel.Items[tb.itemsLen()].Description = tb.buffer
@@ -115,18 +374,69 @@ func (tb *tokenBuffer) parseEndElement(e xml.EndElement) {
 		}
 
 		tb.feed.Channel.Description = tb.buffer
-	case "link":
-		u, err := url.Parse(tb.buffer)
-		if err != nil {
+	case "encoded":
+		if !tb.openItemTag {
 			return
 		}
-		if tb.openItemTag {
+
+		tb.feed.Channel.Items[tb.itemsLen()].ContentEncoded = tb.buffer
+	case "content":
+		// xhtml content is nested elements rather than text, so only text and html content is read
+		if !tb.openItemTag || e.Name.Space != atomNamespace {
+			return
+		}
+
+		tb.feed.Channel.Items[tb.itemsLen()].ContentEncoded = tb.buffer
+	case "category":
+		if !tb.openItemTag {
+			return
+		}
+
+		item := &tb.feed.Channel.Items[tb.itemsLen()]
+		item.Categories = append(item.Categories, tb.buffer)
+	case "logo":
+		// atom feeds may have both a logo and a smaller icon, the logo is preferred
+		if !tb.openItemTag {
+			tb.feed.Channel.Image = tb.buffer
+		}
+	case "icon":
+		if !tb.openItemTag && tb.feed.Channel.Image == "" {
+			tb.feed.Channel.Image = tb.buffer
+		}
+	}
+}
+
+// parseLinkEndElement sets the channel or item link when the link just read ranks higher than any link before it. Of equally ranked links the first is kept.
+func (tb *tokenBuffer) parseLinkEndElement() {
+	l := tb.link
+	tb.link = link{}
+
+	value := l.href
+	if value == "" {
+		value = tb.buffer
+	}
+
+	rank := l.rank()
+	if value == "" || rank == 0 {
+		return
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return
+	}
+
+	if tb.openItemTag {
+		if rank > tb.itemLinkRank {
 			tb.feed.Channel.Items[tb.itemsLen()].Link = u.String()
-			break
+			tb.itemLinkRank = rank
 		}
+		return
+	}
+
+	if rank > tb.channelLinkRank {
 		tb.feed.Channel.Link = u.String()
-	case "item":
-		tb.openItemTag = false
+		tb.channelLinkRank = rank
 	}
 }
 
@@ -135,20 +445,99 @@ func (tb *tokenBuffer) parseCharElement(e xml.CharData) {
 }
 
 func (fr FeedParser) ParseFromURI(ctx context.Context, uri string) (*RSSFeed, error) {
+	return fr.ConditionalParseFromURI(ctx, uri, "", "")
+}
+
+// ConditionalParseFromURI fetches the feed with If-None-Match and If-Modified-Since set from the validators of a previous fetch.
+// ErrNotModified is returned when the server reports the feed has not changed.
+func (fr FeedParser) ConditionalParseFromURI(ctx context.Context, uri, etag, lastModified string) (*RSSFeed, error) {
+	if fr.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fr.timeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		feed, err := fr.fetch(ctx, uri, etag, lastModified)
+
+		var retryable retryableError
+		if !errors.As(err, &retryable) {
+			return feed, err
+		}
+
+		if attempt >= fr.maxAttempts {
+			return nil, retryable.err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, retryable.err
+		case <-time.After(backoff(fr.retryBackoff, attempt)):
+		}
+	}
+}
+
+// fetch makes a single attempt at fetching and parsing the feed. Errors worth retrying are wrapped in a retryableError.
+func (fr FeedParser) fetch(ctx context.Context, uri, etag, lastModified string) (*RSSFeed, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	req.Header.Set("User-Agent", fr.userAgent)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
 	resp, err := fr.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrTooManyRedirects) || errors.Is(err, ErrRedirectScheme) {
+			return nil, err
+		}
+
+		return nil, retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := &StatusError{StatusCode: resp.StatusCode}
+		if resp.StatusCode >= 500 {
+			return nil, retryableError{err: err}
+		}
+
+		return nil, err
+	}
+
+	body, err := decompress(resp)
 	if err != nil {
 		return nil, err
 	}
+	defer body.Close()
 
-	parsed, err := fr.Parse(resp.Body)
+	limited := &limitedReader{r: body, n: fr.maxBodySize}
+
+	var parsed *RSSFeed
+	if isJSONContentType(resp.Header.Get("Content-Type")) {
+		parsed, err = parseJSONFeed(limited)
+	} else {
+		parsed, err = fr.Parse(limited)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	parsed.ETag = resp.Header.Get("ETag")
+	parsed.LastModified = resp.Header.Get("Last-Modified")
+	parsed.RedirectURL = permanentRedirectURL(resp)
 	return parsed, nil
 }
@@ -2,10 +2,19 @@ package parser
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/xml"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,6 +22,7 @@ import (
 func TestNewFeedParser(t *testing.T) {
 	type args struct {
 		http HTTP
+		opts []Option
 	}
 	tests := []struct {
 		args args
@@ -25,13 +35,31 @@ func TestNewFeedParser(t *testing.T) {
 				http: http.DefaultClient,
 			},
 			want: FeedParser{
+				http:        http.DefaultClient,
+				userAgent:   DefaultUserAgent,
+				maxAttempts: 1,
+				maxBodySize: DefaultMaxBodySize,
+			},
+		},
+		{
+			name: "new parser with options",
+			args: args{
 				http: http.DefaultClient,
+				opts: []Option{WithUserAgent("test/1.0"), WithTimeout(time.Second), WithRetry(3, time.Millisecond), WithMaxBodySize(1024)},
+			},
+			want: FeedParser{
+				http:         http.DefaultClient,
+				userAgent:    "test/1.0",
+				timeout:      time.Second,
+				maxAttempts:  3,
+				retryBackoff: time.Millisecond,
+				maxBodySize:  1024,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := New(tt.args.http); !reflect.DeepEqual(got, tt.want) {
+			if got := New(tt.args.http, tt.args.opts...); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("New() = %v, want %v", got, tt.want)
 			}
 		})
@@ -95,4 +123,910 @@ func TestFeedReader_Parse(t *testing.T) {
 			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
 		}
 	})
+
+	t.Run("author and dc:creator", func(t *testing.T) {
+		b, err := os.ReadFile("testing/authors.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "authors",
+				Link:        "https://example.com/",
+				Description: "feed with multiple author forms",
+				Items: []Item{
+					{
+						Title:  "author only",
+						Author: "Item Author",
+						Link:   "https://example.com/posts/author/",
+					},
+					{
+						Title:  "creator only",
+						Author: "Item Creator",
+						Link:   "https://example.com/posts/creator/",
+					},
+					{
+						Title:  "both",
+						Author: "Item Author",
+						Link:   "https://example.com/posts/both/",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("cdata", func(t *testing.T) {
+		b, err := os.ReadFile("testing/cdata.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "cdata & friends",
+				Link:        "https://example.com/",
+				Description: "channel <em>description</em>",
+				Items: []Item{
+					{
+						Title:       "An <i>item</i> title",
+						Link:        "https://example.com/posts/cdata/",
+						Description: "<p>first paragraph</p>\n<p>second &amp; <a href=\"https://example.com\">last</a> paragraph</p>",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("iso-8859-1", func(t *testing.T) {
+		b, err := os.ReadFile("testing/latin1.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "café",
+				Link:        "https://example.com/",
+				Description: "naïve résumé façade",
+				Items: []Item{
+					{
+						Title: "À bientôt, señor",
+						Link:  "https://example.com/posts/latin1/",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("content:encoded", func(t *testing.T) {
+		b, err := os.ReadFile("testing/content.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "content",
+				Link:        "https://example.com/",
+				Description: "feed with full article bodies",
+				Items: []Item{
+					{
+						Title:          "full body",
+						Link:           "https://example.com/posts/full/",
+						Description:    "a short summary",
+						ContentEncoded: "<p>the full article body</p>",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("enclosure", func(t *testing.T) {
+		b, err := os.ReadFile("testing/enclosure.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "podcast",
+				Link:        "https://example.com/",
+				Description: "feed with audio episodes",
+				Items: []Item{
+					{
+						Title: "episode 2",
+						Link:  "https://example.com/episodes/2/",
+						Enclosure: &Enclosure{
+							URL:    "https://example.com/episodes/2.mp3",
+							Type:   "audio/mpeg",
+							Length: 12345678,
+						},
+					},
+					{
+						Title: "episode 1",
+						Link:  "https://example.com/episodes/1/",
+						Enclosure: &Enclosure{
+							URL:  "https://example.com/episodes/1.mp3",
+							Type: "audio/mpeg",
+						},
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("categories", func(t *testing.T) {
+		b, err := os.ReadFile("testing/categories.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "categories",
+				Link:        "https://example.com/",
+				Description: "feed with tagged items",
+				Items: []Item{
+					{
+						Title:      "tagged",
+						Link:       "https://example.com/posts/tagged/",
+						Categories: []string{"golang", "homelab"},
+					},
+					{
+						Title: "untagged",
+						Link:  "https://example.com/posts/untagged/",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("channel image", func(t *testing.T) {
+		b, err := os.ReadFile("testing/image.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "image",
+				Link:        "https://example.com/",
+				Description: "feed with a channel image",
+				Image:       "https://example.com/logo.png",
+				Items: []Item{
+					{
+						Title: "post",
+						Link:  "https://example.com/posts/post/",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("multiple links", func(t *testing.T) {
+		b, err := os.ReadFile("testing/links.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "links",
+				Link:        "https://example.com/",
+				Description: "feed with several links",
+				Items: []Item{
+					{
+						Title: "text link",
+						Link:  "https://example.com/posts/text/",
+					},
+					{
+						Title: "alternate link",
+						Link:  "https://example.com/posts/alternate/",
+					},
+					{
+						Title: "other format",
+						Link:  "https://example.com/posts/other/index.json",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		b, err := os.ReadFile("testing/atom.xml")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:         "atom",
+				Link:          "https://example.com/",
+				Description:   "an atom feed",
+				LastBuildDate: "2023-04-25T00:00:00Z",
+				Items: []Item{
+					{
+						Title:          "first",
+						Link:           "https://example.com/posts/first/",
+						GUID:           "tag:example.com,2023:first",
+						PubDate:        "2023-04-24T00:00:00Z",
+						Description:    "the first entry",
+						ContentEncoded: "<p>the first entry</p>",
+					},
+					{
+						Title:       "second",
+						Link:        "https://example.com/posts/second/",
+						GUID:        "tag:example.com,2023:second",
+						PubDate:     "2023-04-23T00:00:00Z",
+						Description: "the second entry",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("language and copyright", func(t *testing.T) {
+		b, err := os.ReadFile("testing/metadata.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Error(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "metadata",
+				Link:        "https://example.com/",
+				Description: "feed with language and copyright",
+				Language:    "en-us",
+				Copyright:   "© 2023 example",
+				Items: []Item{
+					{
+						Title: "translated",
+						Link:  "https://example.com/posts/translated/",
+					},
+				},
+			},
+		}
+
+		if !assert.Equal(t, wantFeed, feed) {
+			t.Errorf("TestFeedReader_Parse() = %+v, want %+v", feed, wantFeed)
+		}
+	})
+
+	t.Run("channel pubDate and guid", func(t *testing.T) {
+		b, err := os.ReadFile("testing/channel.rss")
+		if err != nil {
+			t.Error(err)
+		}
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantFeed := &RSSFeed{
+			Channel: Channel{
+				Title:       "channel",
+				Link:        "https://example.com/",
+				Description: "feed with a channel pubDate and guid before its items",
+				Items: []Item{
+					{
+						Title:   "first",
+						Link:    "https://example.com/posts/first/",
+						GUID:    "https://example.com/posts/first/",
+						PubDate: "Tue, 25 Apr 2023 00:00:00 +0000",
+					},
+				},
+			},
+		}
+
+		assert.Equal(t, wantFeed, feed, "the channel's pubDate and guid are not read into an item")
+	})
+
+	t.Run("atom logo and icon", func(t *testing.T) {
+		doc := `<feed xmlns="http://www.w3.org/2005/Atom"><title>atom</title><icon>https://example.com/favicon.ico</icon><logo>https://example.com/logo.png</logo></feed>`
+
+		parser := New(http.DefaultClient)
+		feed, err := parser.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Error(err)
+		}
+
+		assert.Equal(t, "https://example.com/logo.png", feed.Channel.Image)
+	})
+}
+
+func TestFeedParser_ConditionalParseFromURI(t *testing.T) {
+	b, err := os.ReadFile("testing/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` || r.Header.Get("If-Modified-Since") == "Tue, 25 Apr 2023 00:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Tue, 25 Apr 2023 00:00:00 GMT")
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	parser := New(http.DefaultClient)
+
+	t.Run("records cache validators", func(t *testing.T) {
+		feed, err := parser.ParseFromURI(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, `"v1"`, feed.ETag)
+		assert.Equal(t, "Tue, 25 Apr 2023 00:00:00 GMT", feed.LastModified)
+		assert.Len(t, feed.Channel.Items, 2)
+	})
+
+	t.Run("etag not modified", func(t *testing.T) {
+		feed, err := parser.ConditionalParseFromURI(context.Background(), srv.URL, `"v1"`, "")
+		assert.ErrorIs(t, err, ErrNotModified)
+		assert.Nil(t, feed)
+	})
+
+	t.Run("last modified not modified", func(t *testing.T) {
+		feed, err := parser.ConditionalParseFromURI(context.Background(), srv.URL, "", "Tue, 25 Apr 2023 00:00:00 GMT")
+		assert.ErrorIs(t, err, ErrNotModified)
+		assert.Nil(t, feed)
+	})
+}
+
+func TestFeedParser_ParseFromURITimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DefaultUserAgent, r.Header.Get("User-Agent"))
+
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	parser := New(http.DefaultClient, WithTimeout(50*time.Millisecond))
+	feed, err := parser.ParseFromURI(context.Background(), srv.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, feed)
+}
+
+func TestFeedParser_ParseFromURIEncoding(t *testing.T) {
+	b, err := os.ReadFile("testing/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		writer   func(io.Writer) io.WriteCloser
+	}{
+		{
+			name: "identity",
+		},
+		{
+			name:     "gzip",
+			encoding: "gzip",
+			writer: func(w io.Writer) io.WriteCloser {
+				return gzip.NewWriter(w)
+			},
+		},
+		{
+			name:     "zlib deflate",
+			encoding: "deflate",
+			writer: func(w io.Writer) io.WriteCloser {
+				return zlib.NewWriter(w)
+			},
+		},
+		{
+			name:     "raw deflate",
+			encoding: "deflate",
+			writer: func(w io.Writer) io.WriteCloser {
+				fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+				return fw
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "gzip, deflate", r.Header.Get("Accept-Encoding"))
+
+				if tt.writer == nil {
+					w.Write(b)
+					return
+				}
+
+				w.Header().Set("Content-Encoding", tt.encoding)
+				cw := tt.writer(w)
+				cw.Write(b)
+				cw.Close()
+			}))
+			defer srv.Close()
+
+			feed, err := New(http.DefaultClient).ParseFromURI(context.Background(), srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "blog.kyledev.co", feed.Channel.Title)
+			assert.Len(t, feed.Channel.Items, 2)
+		})
+	}
+}
+
+func TestFeedParser_ParseFromURIRedirects(t *testing.T) {
+	b, err := os.ReadFile("testing/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(b)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved-again", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-again", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/feed.xml", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/ftp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/feed.xml", http.StatusMovedPermanently)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	parser := New(&http.Client{CheckRedirect: CheckRedirect})
+
+	t.Run("no redirect", func(t *testing.T) {
+		feed, err := parser.ParseFromURI(context.Background(), srv.URL+"/feed.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "", feed.RedirectURL)
+	})
+
+	t.Run("permanent redirects", func(t *testing.T) {
+		feed, err := parser.ParseFromURI(context.Background(), srv.URL+"/moved")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, srv.URL+"/feed.xml", feed.RedirectURL)
+	})
+
+	t.Run("temporary redirect in chain", func(t *testing.T) {
+		feed, err := parser.ParseFromURI(context.Background(), srv.URL+"/temporary")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "", feed.RedirectURL)
+	})
+
+	t.Run("too many redirects", func(t *testing.T) {
+		_, err := parser.ParseFromURI(context.Background(), srv.URL+"/loop")
+		assert.ErrorIs(t, err, ErrTooManyRedirects)
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := parser.ParseFromURI(context.Background(), srv.URL+"/ftp")
+		assert.ErrorIs(t, err, ErrRedirectScheme)
+	})
+}
+
+func TestFeedParser_ParseFromURIRetry(t *testing.T) {
+	b, err := os.ReadFile("testing/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		handler      func(attempt int, w http.ResponseWriter)
+		wantErr      error
+		wantAttempts int
+	}{
+		{
+			name: "succeeds on third attempt",
+			handler: func(attempt int, w http.ResponseWriter) {
+				if attempt < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write(b)
+			},
+			wantAttempts: 3,
+		},
+		{
+			name: "gives up after max attempts",
+			handler: func(attempt int, w http.ResponseWriter) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantErr:      &StatusError{StatusCode: http.StatusBadGateway},
+			wantAttempts: 3,
+		},
+		{
+			name: "client error is not retried",
+			handler: func(attempt int, w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantErr:      &StatusError{StatusCode: http.StatusNotFound},
+			wantAttempts: 1,
+		},
+		{
+			name: "malformed xml is not retried",
+			handler: func(attempt int, w http.ResponseWriter) {
+				w.Write([]byte(`<`))
+			},
+			wantErr:      &xml.SyntaxError{Msg: "unexpected EOF", Line: 1},
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				tt.handler(attempts, w)
+			}))
+			defer srv.Close()
+
+			parser := New(http.DefaultClient, WithRetry(3, time.Millisecond))
+			feed, err := parser.ParseFromURI(context.Background(), srv.URL)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.wantErr == nil {
+				assert.Len(t, feed.Channel.Items, 2)
+			}
+		})
+	}
+}
+
+func TestFeedParser_ParseFromURIMaxBodySize(t *testing.T) {
+	b, err := os.ReadFile("testing/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	t.Run("exact size", func(t *testing.T) {
+		feed, err := New(http.DefaultClient, WithMaxBodySize(int64(len(b)))).ParseFromURI(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Len(t, feed.Channel.Items, 2)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		feed, err := New(http.DefaultClient, WithMaxBodySize(int64(len(b)-1))).ParseFromURI(context.Background(), srv.URL)
+		assert.ErrorIs(t, err, ErrFeedTooLarge)
+		assert.Nil(t, feed)
+	})
+}
+
+func TestFeedParser_ParseFromURIEmptyFeed(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "empty body", body: ""},
+		{name: "whitespace", body: " \n\t\n"},
+		{name: "xml declaration", body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n"},
+		{name: "empty channel", body: `<rss version="2.0"><channel></channel></rss>`},
+		{name: "empty json feed", contentType: "application/feed+json", body: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			feed, err := New(http.DefaultClient).ParseFromURI(context.Background(), srv.URL)
+			assert.ErrorIs(t, err, ErrEmptyFeed)
+			assert.Nil(t, feed)
+		})
+	}
+}
+
+func TestFeedParser_ParseJSONFeed(t *testing.T) {
+	rss, err := os.ReadFile("testing/feed.rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonFeed, err := os.ReadFile("testing/feed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := New(http.DefaultClient).Parse(bytes.NewReader(rss))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("sniffed from content", func(t *testing.T) {
+		feed, err := New(http.DefaultClient).Parse(bytes.NewReader(jsonFeed))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, want.Channel.Title, feed.Channel.Title)
+		assert.Equal(t, want.Channel.Link, feed.Channel.Link)
+		assert.Equal(t, want.Channel.Items, feed.Channel.Items)
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+			w.Write(jsonFeed)
+		}))
+		defer srv.Close()
+
+		feed, err := New(http.DefaultClient).ParseFromURI(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, want.Channel.Items, feed.Channel.Items)
+	})
+
+	t.Run("xml fallback", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write(rss)
+		}))
+		defer srv.Close()
+
+		feed, err := New(http.DefaultClient).ParseFromURI(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, want.Channel.Items, feed.Channel.Items)
+	})
+}
+
+func TestFeedParser_DiscoverFeeds(t *testing.T) {
+	page, err := os.ReadFile("testing/discover.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/blog/", http.StatusFound)
+	})
+	mux.HandleFunc("/blog/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/empty/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>no feeds</title></head><body></body></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("relative and absolute links", func(t *testing.T) {
+		links, err := New(http.DefaultClient).DiscoverFeeds(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{srv.URL + "/index.xml", "https://blog.kyledev.co/atom.xml", srv.URL + "/blog/index.xml"}, links)
+	})
+
+	t.Run("no feeds", func(t *testing.T) {
+		links, err := New(http.DefaultClient).DiscoverFeeds(context.Background(), srv.URL+"/empty/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, []string{}, links)
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		links, err := New(http.DefaultClient).DiscoverFeeds(context.Background(), srv.URL+"/missing")
+		var statusErr *StatusError
+		assert.ErrorAs(t, err, &statusErr)
+		assert.Nil(t, links)
+	})
+}
+
+func TestFeedParser_FullText(t *testing.T) {
+	page, err := os.ReadFile("testing/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/posts/feed-reader/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	mux.HandleFunc("/latin1/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<article><p>Un caf\xe9 cr\xe8me, avec une phrase assez longue pour compter.</p></article>"))
+	})
+	mux.HandleFunc("/empty/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><div>nothing to read</div></body></html>`))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("article page", func(t *testing.T) {
+		content, err := New(http.DefaultClient).FullText(context.Background(), srv.URL+"/posts/feed-reader/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Contains(t, content, "<h1>Writing a feed reader</h1>")
+		assert.Contains(t, content, "<p>Most feeds only publish a summary")
+		assert.Contains(t, content, `<a href="`+srv.URL+`/posts/sqlite/">storage layer</a>`)
+		assert.Contains(t, content, `<img src="`+srv.URL+`/posts/feed-reader/images/diagram.png" alt="architecture">`)
+		assert.NotContains(t, content, "Recent posts")
+		assert.NotContains(t, content, "Great post")
+		assert.NotContains(t, content, "Copyright")
+		assert.NotContains(t, content, "trackRead")
+		assert.NotContains(t, content, "onerror")
+	})
+
+	t.Run("charset", func(t *testing.T) {
+		content, err := New(http.DefaultClient).FullText(context.Background(), srv.URL+"/latin1/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "<p>Un café crème, avec une phrase assez longue pour compter.</p>", content)
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		_, err := New(http.DefaultClient).FullText(context.Background(), srv.URL+"/empty/")
+		assert.ErrorIs(t, err, ErrNoContent)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := New(http.DefaultClient, WithMaxBodySize(64)).FullText(context.Background(), srv.URL+"/posts/feed-reader/")
+		assert.ErrorIs(t, err, ErrFeedTooLarge)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer slow.Close()
+
+		_, err := New(http.DefaultClient, WithTimeout(10*time.Millisecond)).FullText(context.Background(), slow.URL)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		_, err := New(http.DefaultClient).FullText(context.Background(), srv.URL+"/missing")
+		var statusErr *StatusError
+		assert.ErrorAs(t, err, &statusErr)
+	})
 }
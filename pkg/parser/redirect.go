@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MaxRedirects is the longest redirect chain followed when fetching a feed
+const MaxRedirects = 5
+
+// CheckRedirect caps the redirect chain and only allows redirects to http(s) urls. It is intended to be set as the CheckRedirect of the http.Client given to New.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= MaxRedirects {
+		return ErrTooManyRedirects
+	}
+
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("%w: %s", ErrRedirectScheme, req.URL.Scheme)
+	}
+
+	return nil
+}
+
+// permanentRedirectURL returns the url the response was served from if every redirect in the chain was permanent
+func permanentRedirectURL(resp *http.Response) string {
+	final := resp.Request
+	if final == nil || final.Response == nil {
+		return ""
+	}
+
+	for req := final; req != nil && req.Response != nil; req = req.Response.Request {
+		switch req.Response.StatusCode {
+		case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		default:
+			return ""
+		}
+	}
+
+	return final.URL.String()
+}
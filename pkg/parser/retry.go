@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"math/rand"
+	"time"
+)
+
+// retryableError marks an error from a fetch attempt that may succeed if attempted again
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
+// backoff is the delay before the next attempt. It doubles with each attempt and adds up to one base delay of jitter so feeds failing together do not retry together.
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base << (attempt - 1)
+	return delay + time.Duration(rand.Int63n(int64(base)))
+}
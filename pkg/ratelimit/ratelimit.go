@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket per key. Each bucket holds up to burst tokens and refills at rate tokens per second.
+// At most size keys are tracked and the least recently seen key is evicted to make room, which simply gives that key a full bucket if it returns.
+type Limiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	size    int
+	now     func() time.Time
+	buckets map[string]*list.Element
+	recent  *list.List
+}
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// New creates a limiter allowing rate requests per second per key with bursts of up to burst requests, tracking at most size keys
+func New(rate float64, burst, size int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		size:    size,
+		now:     time.Now,
+		buckets: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// Allow takes a token from the key's bucket. When the bucket is empty it returns false along with how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.bucket(key, now)
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.rate <= 0 {
+		return false, time.Duration(1<<63 - 1)
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// Len returns the number of keys being tracked
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// bucket returns the key's bucket, marking it as the most recently seen. The lock must be held.
+func (l *Limiter) bucket(key string, now time.Time) *bucket {
+	if e, ok := l.buckets[key]; ok {
+		l.recent.MoveToFront(e)
+		return e.Value.(*bucket)
+	}
+
+	if l.recent.Len() >= l.size {
+		oldest := l.recent.Back()
+		l.recent.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*bucket).key)
+	}
+
+	b := &bucket{key: key, tokens: l.burst, last: now}
+	l.buckets[key] = l.recent.PushFront(b)
+	return b
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2023, 4, 25, 0, 0, 0, 0, time.UTC)
+	l := New(2, 3, 10)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("1.2.3.4")
+		assert.True(t, ok, "request %d should be within the burst", i)
+	}
+
+	ok, wait := l.Allow("1.2.3.4")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _ = l.Allow("5.6.7.8")
+	assert.True(t, ok, "other keys have their own bucket")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("1.2.3.4")
+	assert.True(t, ok, "a token refills after 1/rate seconds")
+
+	ok, _ = l.Allow("1.2.3.4")
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ = l.Allow("1.2.3.4")
+		assert.True(t, ok, "the bucket refills up to the burst")
+	}
+	ok, _ = l.Allow("1.2.3.4")
+	assert.False(t, ok)
+}
+
+func TestLimiter_Eviction(t *testing.T) {
+	now := time.Date(2023, 4, 25, 0, 0, 0, 0, time.UTC)
+	l := New(1, 1, 2)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	l.Allow("b")
+	l.Allow("a")
+	l.Allow("c")
+
+	assert.Equal(t, 2, l.Len())
+
+	ok, _ := l.Allow("a")
+	assert.False(t, ok, "a was seen recently so its empty bucket is kept")
+
+	ok, _ = l.Allow("b")
+	assert.True(t, ok, "b was the least recently seen so it was evicted and starts with a full bucket")
+	assert.Equal(t, 2, l.Len())
+}
@@ -0,0 +1,305 @@
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// minParagraphLength is the fewest characters of text a paragraph needs to count towards the content it is in
+const minParagraphLength = 25
+
+// unlikelyElements hold page furniture rather than content, so they are dropped before the content is looked for
+var unlikelyElements = map[string]bool{
+	"aside":  true,
+	"button": true,
+	"footer": true,
+	"form":   true,
+	"header": true,
+	"menu":   true,
+	"nav":    true,
+	"select": true,
+	"svg":    true,
+}
+
+// htmlVoidElements never have a closing tag, unlike voidElements this includes elements that are not allowed
+var htmlVoidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// elementScores favour the elements content is usually wrapped in
+var elementScores = map[string]float64{
+	"article":    10,
+	"div":        5,
+	"main":       10,
+	"section":    3,
+	"blockquote": 3,
+	"pre":        3,
+	"td":         3,
+	"form":       -3,
+	"li":         -3,
+	"ol":         -3,
+	"ul":         -3,
+	"h1":         -5,
+	"h2":         -5,
+	"h3":         -5,
+	"th":         -5,
+}
+
+var (
+	unlikelyHints = regexp.MustCompile(`(?i)ad-|advert|banner|breadcrumb|comment|cookie|footer|menu|modal|nav|popup|promo|related|share|sidebar|social|sponsor|subscribe`)
+	likelyHints   = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text`)
+)
+
+type node struct {
+	// text is set for text nodes, which have no tag name
+	text     string
+	tag      tag
+	parent   *node
+	children []*node
+}
+
+// Readable returns the sanitized HTML of the main content of the html page s, such as the body of an article without the site's navigation, comments, and sidebars.
+// The element with the most paragraph text, weighed against how much of it is links, is taken as the content. Relative links resolve against base when it is not nil.
+// An empty string is returned when the page has no paragraphs to take content from.
+func Readable(s string, base *url.URL) string {
+	root := parseTree(s)
+
+	scores := make(map[*node]float64)
+	root.each(func(n *node) {
+		if n.tag.name != "p" && n.tag.name != "pre" {
+			return
+		}
+
+		text := n.textContent()
+		if len([]rune(text)) < minParagraphLength || n.parent == nil {
+			return
+		}
+
+		// commas and length suggest prose, with length counting for at most 3 points
+		score := 1 + float64(strings.Count(text, ","))
+		if length := len(text) / 100; length < 3 {
+			score += float64(length)
+		} else {
+			score += 3
+		}
+
+		// the paragraph counts fully towards its parent, and half as much towards its grandparent
+		for i, ancestor := range []*node{n.parent, n.parent.parent} {
+			if ancestor == nil {
+				break
+			}
+			if _, ok := scores[ancestor]; !ok {
+				scores[ancestor] = ancestor.initialScore()
+			}
+			scores[ancestor] += score / float64(i+1)
+		}
+	})
+
+	// candidates are visited in document order so the first of any tied elements wins
+	var best *node
+	var bestScore float64
+	root.each(func(n *node) {
+		score, ok := scores[n]
+		if !ok {
+			return
+		}
+
+		score *= 1 - n.linkDensity()
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	})
+	if best == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, c := range best.children {
+		c.render(&b, base)
+	}
+
+	return strings.TrimSpace(HTML(b.String()))
+}
+
+// parseTree builds the element tree of s, leaving out unlikely elements. Closing tags without an open element are ignored, and a paragraph or list item is closed by the start of the next.
+func parseTree(s string) *node {
+	root := &node{}
+	current := root
+	// skipping is the depth of nesting inside an unlikely element
+	skipping := 0
+
+	walk(s, func(text string) {
+		if skipping > 0 {
+			return
+		}
+		current.children = append(current.children, &node{text: text, parent: current})
+	}, func(t tag) {
+		if t.closing {
+			if skipping > 0 {
+				if !htmlVoidElements[t.name] && t.name == current.tag.name {
+					skipping--
+					if skipping == 0 {
+						current = current.parent
+						current.children = current.children[:len(current.children)-1]
+					}
+				}
+				return
+			}
+
+			for n := current; n != root; n = n.parent {
+				if n.tag.name == t.name {
+					current = n.parent
+					break
+				}
+			}
+			return
+		}
+
+		if skipping > 0 {
+			if !htmlVoidElements[t.name] && t.name == current.tag.name {
+				skipping++
+			}
+			return
+		}
+
+		if (t.name == "p" || t.name == "li") && current.tag.name == t.name {
+			current = current.parent
+		}
+
+		n := &node{tag: t, parent: current}
+		current.children = append(current.children, n)
+		if htmlVoidElements[t.name] {
+			return
+		}
+
+		current = n
+		if n.unlikely() {
+			skipping = 1
+		}
+	})
+
+	return root
+}
+
+// unlikely reports whether the element is page furniture, either by its name or by a class or id hinting it is not content
+func (n *node) unlikely() bool {
+	if unlikelyElements[n.tag.name] {
+		return true
+	}
+
+	switch n.tag.name {
+	case "html", "body", "article", "main":
+		return false
+	}
+
+	hints := n.hints()
+	return unlikelyHints.MatchString(hints) && !likelyHints.MatchString(hints)
+}
+
+// hints returns the element's class and id, which pages often name after what the element holds
+func (n *node) hints() string {
+	var hints []string
+	for _, a := range n.tag.attributes {
+		if a.name == "class" || a.name == "id" {
+			hints = append(hints, a.value)
+		}
+	}
+
+	return strings.Join(hints, " ")
+}
+
+func (n *node) initialScore() float64 {
+	score := elementScores[n.tag.name]
+
+	hints := n.hints()
+	if likelyHints.MatchString(hints) {
+		score += 25
+	}
+	if unlikelyHints.MatchString(hints) {
+		score -= 25
+	}
+
+	return score
+}
+
+// each calls fn for the node and every node beneath it
+func (n *node) each(fn func(*node)) {
+	fn(n)
+	for _, c := range n.children {
+		c.each(fn)
+	}
+}
+
+func (n *node) textContent() string {
+	var b strings.Builder
+	n.each(func(c *node) {
+		if c.tag.name == "" {
+			b.WriteString(c.text)
+		} else if blockElements[c.tag.name] {
+			b.WriteString(" ")
+		}
+	})
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// linkDensity is the share of the element's text that is inside links
+func (n *node) linkDensity() float64 {
+	text := len(n.textContent())
+	if text == 0 {
+		return 0
+	}
+
+	var links int
+	n.each(func(c *node) {
+		if c.tag.name == "a" {
+			links += len(c.textContent())
+		}
+	})
+
+	return float64(links) / float64(text)
+}
+
+// render writes the node back out as html, resolving the links in href and src attributes against base
+func (n *node) render(b *strings.Builder, base *url.URL) {
+	if n.tag.name == "" {
+		b.WriteString(html.EscapeString(n.text))
+		return
+	}
+
+	b.WriteString("<" + n.tag.name)
+	for _, a := range n.tag.attributes {
+		value := a.value
+		if urlAttributes[a.name] && base != nil {
+			if u, err := base.Parse(strings.TrimSpace(value)); err == nil {
+				value = u.String()
+			}
+		}
+		b.WriteString(" " + a.name + `="` + html.EscapeString(value) + `"`)
+	}
+	b.WriteString(">")
+
+	if htmlVoidElements[n.tag.name] {
+		return
+	}
+
+	for _, c := range n.children {
+		c.render(b, base)
+	}
+	b.WriteString("</" + n.tag.name + ">")
+}
@@ -0,0 +1,68 @@
+package sanitize
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadable(t *testing.T) {
+	base, err := url.Parse("https://example.com/posts/first/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		html string
+		base *url.URL
+		want string
+	}{
+		{
+			name: "content beside navigation and comments",
+			html: `<body><nav><p>Home, posts, about, and everything else on the site</p></nav>` +
+				`<div class="content"><p>The first paragraph of the post, which is long enough to count.</p><p>The second paragraph, with a comma, also long enough.</p></div>` +
+				`<div class="comments"><p>A comment on the post that is long enough to count, too.</p></div></body>`,
+			want: `<p>The first paragraph of the post, which is long enough to count.</p><p>The second paragraph, with a comma, also long enough.</p>`,
+		},
+		{
+			name: "more paragraphs win",
+			html: `<div><p>A single paragraph that is long enough to count for something.</p></div>` +
+				`<div><p>One of two paragraphs that are long enough to count, together.</p><p>Two of two paragraphs that are long enough to count, together.</p></div>`,
+			want: `<p>One of two paragraphs that are long enough to count, together.</p><p>Two of two paragraphs that are long enough to count, together.</p>`,
+		},
+		{
+			name: "link heavy elements lose",
+			html: `<div><p><a href="/a">A paragraph that is only a link, and a long one</a></p><p><a href="/b">Another paragraph that is only a link, also long</a></p></div>` +
+				`<div><p>A paragraph of prose that mentions nothing else, long enough.</p></div>`,
+			want: `<p>A paragraph of prose that mentions nothing else, long enough.</p>`,
+		},
+		{
+			name: "unclosed paragraphs",
+			html: `<div><p>The first paragraph is never closed, but it is long enough<p>Neither is the second, which is also long enough`,
+			want: `<p>The first paragraph is never closed, but it is long enough</p><p>Neither is the second, which is also long enough</p>`,
+		},
+		{
+			name: "relative links",
+			html: `<article><p>A paragraph with a <a href="../second/">relative link</a> and an image, long enough.</p><img src="/images/a.png"></article>`,
+			base: base,
+			want: `<p>A paragraph with a <a href="https://example.com/posts/second/">relative link</a> and an image, long enough.</p><img src="https://example.com/images/a.png">`,
+		},
+		{
+			name: "sanitized",
+			html: `<article><p onclick="alert(1)">A paragraph that has an event handler, long enough.</p><script>alert(1)</script><a href="javascript:alert(1)">x</a></article>`,
+			want: `<p>A paragraph that has an event handler, long enough.</p><a>x</a>`,
+		},
+		{
+			name: "no paragraphs",
+			html: `<html><body><div>short</div><p>too short</p></body></html>`,
+			want: ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Readable(tt.html, tt.base))
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package sanitize
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// allowedAttributes is the allowlist of elements kept by HTML along with the attributes each may keep
+var allowedAttributes = map[string][]string{
+	"a":          {"href", "title"},
+	"b":          nil,
+	"blockquote": nil,
+	"br":         nil,
+	"code":       nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title"},
+	"li":         nil,
+	"ol":         nil,
+	"p":          nil,
+	"pre":        nil,
+	"strong":     nil,
+	"ul":         nil,
+}
+
+// urlAttributes hold links that are only kept when they use a safe scheme
+var urlAttributes = map[string]bool{
+	"href": true,
+	"src":  true,
+}
+
+// safeSchemes are the url schemes links may use, a relative link has no scheme
+var safeSchemes = map[string]bool{
+	"":       true,
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// voidElements never have a closing tag
+var voidElements = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+// rawElements are dropped along with everything inside them
+var rawElements = map[string]bool{
+	"iframe":   true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+}
+
+// blockElements separate words when tags are stripped
+var blockElements = map[string]bool{
+	"blockquote": true,
+	"br":         true,
+	"div":        true,
+	"figcaption": true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"hr":         true,
+	"li":         true,
+	"p":          true,
+	"pre":        true,
+	"td":         true,
+	"tr":         true,
+}
+
+type attribute struct {
+	name  string
+	value string
+}
+
+type tag struct {
+	name       string
+	closing    bool
+	attributes []attribute
+}
+
+// HTML returns s with every element and attribute outside a small allowlist of formatting elements removed.
+// Scripts, styles, and embedded frames are dropped along with their contents, links are only kept with an http, https, or mailto scheme, and unclosed elements are closed.
+func HTML(s string) string {
+	var b strings.Builder
+	var open []string
+
+	walk(s, func(text string) {
+		b.WriteString(html.EscapeString(text))
+	}, func(t tag) {
+		allowed, ok := allowedAttributes[t.name]
+		if !ok {
+			return
+		}
+
+		if t.closing {
+			// only close an element that is open, closing any left open inside it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != t.name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+			return
+		}
+
+		b.WriteString("<" + t.name)
+		for _, a := range t.attributes {
+			if !contains(allowed, a.name) {
+				continue
+			}
+			if urlAttributes[a.name] && !safeURL(a.value) {
+				continue
+			}
+			b.WriteString(" " + a.name + `="` + html.EscapeString(a.value) + `"`)
+		}
+		b.WriteString(">")
+
+		if !voidElements[t.name] {
+			open = append(open, t.name)
+		}
+	})
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return b.String()
+}
+
+// Text returns the text of s with every tag removed, entities decoded, and runs of whitespace collapsed to a single space
+func Text(s string) string {
+	var b strings.Builder
+
+	walk(s, func(text string) {
+		b.WriteString(text)
+	}, func(t tag) {
+		if blockElements[t.name] {
+			b.WriteString(" ")
+		}
+	})
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Excerpt returns the text of s cut to at most length characters at a word boundary, followed by an ellipsis when anything was cut.
+// A single word longer than length is cut mid-word.
+func Excerpt(s string, length int) string {
+	if length <= 0 {
+		return ""
+	}
+
+	text := []rune(Text(s))
+	if len(text) <= length {
+		return string(text)
+	}
+
+	cut := text[:length]
+	// a cut that lands exactly on a space keeps the whole word before it
+	if !unicode.IsSpace(text[length]) {
+		for i := len(cut) - 1; i > 0; i-- {
+			if unicode.IsSpace(cut[i]) {
+				cut = cut[:i]
+				break
+			}
+		}
+	}
+
+	return strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+// walk splits s into unescaped text and tags. Comments, doctypes, and raw elements along with their contents are skipped, as is an unterminated tag at the end of s.
+func walk(s string, text func(string), element func(tag)) {
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			text(html.UnescapeString(s))
+			return
+		}
+		if i > 0 {
+			text(html.UnescapeString(s[:i]))
+			s = s[i:]
+		}
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s[4:], "-->")
+			continue
+		case strings.HasPrefix(s, "<!"), strings.HasPrefix(s, "<?"):
+			s = skipPast(s[2:], ">")
+			continue
+		}
+
+		t, rest, ok := parseTag(s)
+		if !ok {
+			// a lone < is text rather than the start of a tag
+			text("<")
+			s = s[1:]
+			continue
+		}
+		s = rest
+
+		if rawElements[t.name] && !t.closing {
+			s = skipRaw(s, t.name)
+			continue
+		}
+
+		element(t)
+	}
+}
+
+// parseTag parses the tag at the start of s, returning the rest of s after it. ok is false when s does not start with a tag name.
+// A tag left unterminated at the end of s is returned without a name so it is dropped.
+func parseTag(s string) (t tag, rest string, ok bool) {
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		t.closing = true
+		i++
+	}
+
+	// tag names start with a letter, so text such as 1 <2 is left alone
+	if i >= len(s) || !isLetter(s[i]) {
+		return t, s, false
+	}
+
+	start := i
+	for i < len(s) && (isLetter(s[i]) || s[i] >= '0' && s[i] <= '9') {
+		i++
+	}
+	t.name = strings.ToLower(s[start:i])
+
+	for {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		if i >= len(s) {
+			return tag{}, "", true
+		}
+		if s[i] == '>' {
+			return t, s[i+1:], true
+		}
+
+		start = i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		a := attribute{name: strings.ToLower(s[start:i])}
+
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					return tag{}, "", true
+				}
+				a.value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start = i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				a.value = s[start:i]
+			}
+			a.value = html.UnescapeString(a.value)
+		}
+
+		t.attributes = append(t.attributes, a)
+	}
+}
+
+// skipRaw skips past the closing tag of the raw element name, which may be written in any case
+func skipRaw(s, name string) string {
+	// only ascii letters are lowered so offsets into lower match s
+	lower := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+	i := strings.Index(lower, "</"+name)
+	if i < 0 {
+		return ""
+	}
+
+	return skipPast(s[i:], ">")
+}
+
+// skipPast returns s after the first occurrence of sep, or nothing when sep is missing
+func skipPast(s, sep string) string {
+	i := strings.Index(s, sep)
+	if i < 0 {
+		return ""
+	}
+
+	return s[i+len(sep):]
+}
+
+// safeURL reports whether the link uses a safe scheme. Browsers ignore whitespace and control characters in a scheme, so they are removed before it is checked.
+func safeURL(link string) bool {
+	link = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, link)
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	return safeSchemes[strings.ToLower(u.Scheme)]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,177 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "allowed formatting",
+			html: `<p>Some <strong>bold</strong> and <em>emphasis</em><br/></p>`,
+			want: `<p>Some <strong>bold</strong> and <em>emphasis</em><br></p>`,
+		},
+		{
+			name: "script tags",
+			html: `<p>before</p><script type="text/javascript">alert("xss")</script><p>after</p>`,
+			want: `<p>before</p><p>after</p>`,
+		},
+		{
+			name: "script in any case",
+			html: `hi<SCRIPT>alert(1)</ScRiPt >there`,
+			want: `hithere`,
+		},
+		{
+			name: "unterminated script",
+			html: `safe<script>alert(1)`,
+			want: `safe`,
+		},
+		{
+			name: "event handlers and styles",
+			html: `<img src="https://example.com/a.png" onerror="alert(1)" style="position:fixed" alt="a">`,
+			want: `<img src="https://example.com/a.png" alt="a">`,
+		},
+		{
+			name: "javascript links",
+			html: `<a href="javascript:alert(1)">one</a><a href=" jav&#x09;ascript:alert(2)">two</a><a href='/relative'>three</a>`,
+			want: `<a>one</a><a>two</a><a href="/relative">three</a>`,
+		},
+		{
+			name: "unknown elements keep their text",
+			html: `<div class="post"><span>text</span></div>`,
+			want: `text`,
+		},
+		{
+			name: "unclosed elements",
+			html: `<p><b>bold <i>both`,
+			want: `<p><b>bold <i>both</i></b></p>`,
+		},
+		{
+			name: "stray closing tags",
+			html: `</b>text</p><b>x</i></b>`,
+			want: `text<b>x</b>`,
+		},
+		{
+			name: "malformed tags",
+			html: `1 <2 & 3 > 0 <p title="unterminated`,
+			want: `1 &lt;2 &amp; 3 &gt; 0 `,
+		},
+		{
+			name: "comments",
+			html: `a<!-- <script>alert(1)</script> -->b<!DOCTYPE html>c`,
+			want: `abc`,
+		},
+		{
+			name: "entities",
+			html: `&lt;script&gt; &amp;amp; &quot;`,
+			want: `&lt;script&gt; &amp;amp; &#34;`,
+		},
+		{
+			name: "attribute quotes are escaped",
+			html: `<a href="https://example.com/?q=&quot;&gt;" title='"onmouseover="x'>link</a>`,
+			want: `<a href="https://example.com/?q=&#34;&gt;" title="&#34;onmouseover=&#34;x">link</a>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTML(tt.html))
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "tags removed",
+			html: `<p>Some <strong>bold</strong>  text</p><p>next&nbsp;paragraph &amp; more</p>`,
+			want: "Some bold text next paragraph & more",
+		},
+		{
+			name: "script contents removed",
+			html: `before<script>alert("xss")</script>after`,
+			want: "beforeafter",
+		},
+		{
+			name: "line breaks separate words",
+			html: "one<br>two<li>three</li>\n\tfour",
+			want: "one two three four",
+		},
+		{
+			name: "malformed",
+			html: `a < b <i>c`,
+			want: "a < b c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Text(tt.html))
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		length int
+		want   string
+	}{
+		{
+			name:   "short description",
+			html:   "<p>Short and sweet.</p>",
+			length: 200,
+			want:   "Short and sweet.",
+		},
+		{
+			name:   "html heavy",
+			html:   `<div class="post"><p>The <a href="https://example.com">quick</a> brown <em>fox</em> jumps</p><script>var over = "the lazy dog";</script><p>over the lazy dog</p></div>`,
+			length: 24,
+			want:   "The quick brown fox…",
+		},
+		{
+			name:   "cut on a space",
+			html:   "one two three",
+			length: 7,
+			want:   "one two…",
+		},
+		{
+			name:   "trailing punctuation dropped",
+			html:   "first sentence, second sentence",
+			length: 20,
+			want:   "first sentence…",
+		},
+		{
+			name:   "multibyte characters",
+			html:   "héllo wörld ünïcode",
+			length: 13,
+			want:   "héllo wörld…",
+		},
+		{
+			name:   "single long word",
+			html:   "日本語のテキストです",
+			length: 4,
+			want:   "日本語の…",
+		},
+		{
+			name:   "disabled",
+			html:   "anything",
+			length: 0,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Excerpt(tt.html, tt.length))
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package websocket
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// echoServer echoes every message back and records why its read loop ended
+func echoServer(t *testing.T, readErr chan<- error) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(1 << 10)
+
+		for {
+			messageType, b, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+
+			err = conn.WriteMessage(messageType, b)
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func dial(t *testing.T, url string) *Conn {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := Dial(ctx, strings.Replace(url, "http://", "ws://", 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+// frame encodes a single frame by hand so tests can send what WriteMessage never would
+func frame(fin bool, op int, payload []byte, masked bool) []byte {
+	b := []byte{byte(op)}
+	if fin {
+		b[0] |= 0x80
+	}
+
+	var maskBit byte
+	if masked {
+		maskBit = 0x80
+	}
+
+	switch {
+	case len(payload) <= 125:
+		b = append(b, maskBit|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		b = append(b, maskBit|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(payload)))
+	default:
+		b = append(b, maskBit|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(len(payload)))
+	}
+
+	if !masked {
+		return append(b, payload...)
+	}
+
+	mask := [4]byte{1, 2, 3, 4}
+	b = append(b, mask[:]...)
+	for i, p := range payload {
+		b = append(b, p^mask[i%4])
+	}
+
+	return b
+}
+
+func TestConn_Echo(t *testing.T) {
+	readErr := make(chan error, 1)
+	conn := dial(t, echoServer(t, readErr).URL)
+
+	tests := []struct {
+		name        string
+		messageType int
+		data        string
+	}{
+		{name: "text", messageType: TextMessage, data: "hello"},
+		{name: "empty", messageType: TextMessage, data: ""},
+		{name: "extended length", messageType: BinaryMessage, data: strings.Repeat("a", 1000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := conn.WriteMessage(tt.messageType, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			messageType, b, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.messageType, messageType)
+			assert.Equal(t, tt.data, string(b))
+		})
+	}
+
+	t.Run("ping", func(t *testing.T) {
+		pong := make(chan struct{}, 1)
+		conn.SetPongHandler(func() { pong <- struct{}{} })
+
+		err := conn.WriteMessage(PingMessage, []byte("ping"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.WriteMessage(TextMessage, []byte("after ping"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "after ping", string(b))
+		assert.Len(t, pong, 1, "the pong arrives before the echo")
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		err := conn.WriteMessage(TextMessage, []byte(strings.Repeat("a", 2<<10)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ErrorIs(t, <-readErr, ErrMessageTooLarge)
+	})
+}
+
+func TestConn_Close(t *testing.T) {
+	readErr := make(chan error, 1)
+	conn := dial(t, echoServer(t, readErr).URL)
+
+	err := conn.WriteMessage(CloseMessage, []byte{0x03, 0xe8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.ErrorIs(t, <-readErr, ErrClosed)
+	_, _, err = conn.ReadMessage()
+	assert.ErrorIs(t, err, ErrClosed, "the server echoes the close")
+}
+
+func TestUpgrade_BadHandshake(t *testing.T) {
+	srv := echoServer(t, make(chan error, 1))
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "13", resp.Header.Get("Sec-WebSocket-Version"))
+}
+
+func TestConn_Frames(t *testing.T) {
+	tests := []struct {
+		name    string
+		frames  [][]byte
+		want    string
+		wantErr error
+	}{
+		{
+			name: "fragmented",
+			frames: [][]byte{
+				frame(false, TextMessage, []byte("hel"), true),
+				frame(false, continuationFrame, []byte("lo "), true),
+				frame(true, continuationFrame, []byte("world"), true),
+			},
+			want: "hello world",
+		},
+		{
+			name: "ping between fragments",
+			frames: [][]byte{
+				frame(false, TextMessage, []byte("hel"), true),
+				frame(true, PingMessage, nil, true),
+				frame(true, continuationFrame, []byte("lo"), true),
+			},
+			want: "hello",
+		},
+		{
+			name:    "continuation without a message",
+			frames:  [][]byte{frame(true, continuationFrame, []byte("lo"), true)},
+			wantErr: ErrProtocol,
+		},
+		{
+			name: "new message before the last fragment",
+			frames: [][]byte{
+				frame(false, TextMessage, []byte("hel"), true),
+				frame(true, TextMessage, []byte("lo"), true),
+			},
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "oversized frame",
+			frames:  [][]byte{frame(true, BinaryMessage, make([]byte, 70000), true)},
+			wantErr: ErrMessageTooLarge,
+		},
+		{
+			name: "fragments larger than the limit",
+			frames: [][]byte{
+				frame(false, TextMessage, make([]byte, 600), true),
+				frame(true, continuationFrame, make([]byte, 600), true),
+			},
+			wantErr: ErrMessageTooLarge,
+		},
+		{
+			name:    "oversized control frame",
+			frames:  [][]byte{frame(true, PingMessage, make([]byte, 126), true)},
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "fragmented control frame",
+			frames:  [][]byte{frame(false, PingMessage, nil, true)},
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "unmasked client frame",
+			frames:  [][]byte{frame(true, TextMessage, []byte("hello"), false)},
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "reserved bits",
+			frames:  [][]byte{append([]byte{0x80 | 0x40 | TextMessage, 0x80}, 1, 2, 3, 4)},
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "unknown opcode",
+			frames:  [][]byte{frame(true, 3, nil, true)},
+			wantErr: ErrProtocol,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readErr := make(chan error, 1)
+			conn := dial(t, echoServer(t, readErr).URL)
+			defer conn.Close()
+
+			for _, f := range tt.frames {
+				_, err := conn.conn.Write(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, <-readErr, tt.wantErr)
+				return
+			}
+
+			messageType, b, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, TextMessage, messageType)
+			assert.Equal(t, tt.want, string(b))
+		})
+	}
+}
+
+func TestConn_CloseHandshake(t *testing.T) {
+	t.Run("server echoes the status code", func(t *testing.T) {
+		readErr := make(chan error, 1)
+		conn := dial(t, echoServer(t, readErr).URL)
+
+		payload := append(binary.BigEndian.AppendUint16(nil, 1001), "going away"...)
+		err := conn.WriteMessage(CloseMessage, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ErrorIs(t, <-readErr, ErrClosed)
+
+		fin, op, b, err := conn.readFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, fin)
+		assert.Equal(t, CloseMessage, op)
+		assert.Equal(t, uint16(1001), binary.BigEndian.Uint16(b), "only the status code is echoed")
+		assert.Len(t, b, 2)
+	})
+
+	t.Run("client close", func(t *testing.T) {
+		readErr := make(chan error, 1)
+		conn := dial(t, echoServer(t, readErr).URL)
+
+		err := conn.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ErrorIs(t, <-readErr, ErrClosed)
+	})
+
+	t.Run("server close", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := Upgrade(w, r)
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}))
+		t.Cleanup(srv.Close)
+		conn := dial(t, srv.URL)
+
+		_, _, err := conn.ReadMessage()
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+}
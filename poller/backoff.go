@@ -0,0 +1,119 @@
+package poller
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// clock is the source of time for the poller so tests can control it
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// randomDuration returns a random duration in [0, max)
+func randomDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// failures tracks consecutive refresh failures per feed in memory. A failing feed is skipped until its backoff has passed.
+// The backoff is base doubled for each consecutive failure, capped at max, so with base set to the poll interval a feed that failed once waits two intervals.
+type failures struct {
+	mu    sync.Mutex
+	base  time.Duration
+	max   time.Duration
+	feeds map[string]*failureState
+}
+
+type failureState struct {
+	count   int
+	retryAt time.Time
+}
+
+func newFailures(base, max time.Duration) *failures {
+	return &failures{
+		base:  base,
+		max:   max,
+		feeds: make(map[string]*failureState),
+	}
+}
+
+// ready reports whether the feed is not waiting out a backoff
+func (f *failures) ready(id string, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state, ok := f.feeds[id]
+	return !ok || !now.Before(state.retryAt)
+}
+
+// failed records a failure and returns how long the feed will be skipped for
+func (f *failures) failed(id string, now time.Time) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state, ok := f.feeds[id]
+	if !ok {
+		state = new(failureState)
+		f.feeds[id] = state
+	}
+
+	state.count++
+	delay := f.delay(state.count)
+	state.retryAt = now.Add(delay)
+	return delay
+}
+
+// succeeded resets the feed's failure count
+func (f *failures) succeeded(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.feeds, id)
+}
+
+// count returns the number of consecutive failures recorded for the feed
+func (f *failures) count(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state, ok := f.feeds[id]
+	if !ok {
+		return 0
+	}
+
+	return state.count
+}
+
+func (f *failures) delay(count int) time.Duration {
+	if f.base <= 0 {
+		return 0
+	}
+
+	delay := f.base
+	for i := 0; i < count && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+
+	if f.max > 0 && delay > f.max {
+		return f.max
+	}
+
+	return delay
+}
@@ -2,47 +2,317 @@ package poller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/kdwils/feedreader/pkg/metrics"
 	"github.com/kdwils/feedreader/service"
+	"github.com/kdwils/feedreader/storage"
 	"go.uber.org/zap"
 )
 
+// DefaultConcurrency is how many feeds are refreshed at once when no concurrency is configured
+const DefaultConcurrency = 4
+
+// ErrRunning is returned by RunOnce while another refresh of every feed is in progress
+var ErrRunning = errors.New("a poll is already running")
+
 // Poller checks rss feeds for new articles on a given interval
 type Poller struct {
-	service service.Service
-	ticker  *time.Ticker
-	logger  *zap.Logger
+	service     service.Service
+	ticker      *time.Ticker
+	logger      *zap.Logger
+	concurrency int
+	jitter      time.Duration
+	runOnStart  bool
+	startDelay  time.Duration
+	clock       clock
+	failures    *failures
+	metrics     pollerMetrics
+	// running is held for the length of a refresh of every feed so a triggered poll and a tick never overlap
+	running *sync.Mutex
+}
+
+type pollerMetrics struct {
+	feedsRefreshed *metrics.CounterVec
+	articlesAdded  *metrics.CounterVec
+	errors         *metrics.CounterVec
+}
+
+func newPollerMetrics() pollerMetrics {
+	return pollerMetrics{
+		feedsRefreshed: metrics.NewCounterVec("feedreader_poller_feeds_refreshed_total", "Feeds the poller refreshed successfully."),
+		articlesAdded:  metrics.NewCounterVec("feedreader_poller_articles_added_total", "Articles stored by the poller."),
+		errors:         metrics.NewCounterVec("feedreader_poller_errors_total", "Feed refreshes and polls that failed."),
+	}
+}
+
+// Option configures optional Poller settings
+type Option func(*Poller)
+
+// WithConcurrency sets the maximum number of feeds refreshed at the same time
+func WithConcurrency(concurrency int) Option {
+	return func(p *Poller) {
+		if concurrency > 0 {
+			p.concurrency = concurrency
+		}
+	}
 }
 
-func New(ticker *time.Ticker, service service.Service, logger *zap.Logger) Poller {
-	return Poller{
-		ticker:  ticker,
-		service: service,
-		logger:  logger,
+// WithJitter delays each poll by a random duration up to jitter so feeds sharing an interval are not all fetched at the same moment
+func WithJitter(jitter time.Duration) Option {
+	return func(p *Poller) {
+		p.jitter = jitter
 	}
 }
 
+// WithRunOnStart refreshes every feed as soon as Poll is called rather than waiting for the first tick
+func WithRunOnStart(runOnStart bool) Option {
+	return func(p *Poller) {
+		p.runOnStart = runOnStart
+	}
+}
+
+// WithStartDelay waits delay before the refresh run on start, such as to let the rest of the application start first
+func WithStartDelay(delay time.Duration) Option {
+	return func(p *Poller) {
+		p.startDelay = delay
+	}
+}
+
+// WithFailureBackoff skips a feed after it fails to refresh, starting at twice base and doubling with each consecutive failure up to max.
+// A successful refresh resets the backoff.
+func WithFailureBackoff(base, max time.Duration) Option {
+	return func(p *Poller) {
+		p.failures = newFailures(base, max)
+	}
+}
+
+// WithMetrics records the poller's counters on reg
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(p *Poller) {
+		p.metrics = pollerMetrics{
+			feedsRefreshed: metrics.Share(reg, p.metrics.feedsRefreshed),
+			articlesAdded:  metrics.Share(reg, p.metrics.articlesAdded),
+			errors:         metrics.Share(reg, p.metrics.errors),
+		}
+	}
+}
+
+func New(ticker *time.Ticker, service service.Service, logger *zap.Logger, opts ...Option) Poller {
+	p := Poller{
+		ticker:      ticker,
+		service:     service,
+		logger:      logger,
+		concurrency: DefaultConcurrency,
+		clock:       realClock{},
+		failures:    newFailures(0, 0),
+		metrics:     newPollerMetrics(),
+		running:     &sync.Mutex{},
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return p
+}
+
+// result is the outcome of refreshing a single feed
+type result struct {
+	// index is the feed's position in the list of feeds refreshed so results can be reported in that order
+	index int
+	feed  *storage.Feed
+	added int
+	err   error
+}
+
+// Summary is the outcome of refreshing every feed
+type Summary struct {
+	Feeds []FeedSummary `json:"feeds"`
+	// Skipped is the number of feeds left out because they are backing off from earlier failures
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+	Added   int `json:"added"`
+}
+
+// FeedSummary is the outcome of refreshing a single feed
+type FeedSummary struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Added int    `json:"added"`
+	Error string `json:"error,omitempty"`
+}
+
+// Poll refreshes every feed on each tick until ctx is canceled. With WithRunOnStart, the feeds are also refreshed once after the start delay, before the first tick.
 func (p Poller) Poll(ctx context.Context) error {
+	if p.runOnStart {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.clock.After(p.startDelay):
+		}
+
+		err := p.poll(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-p.ticker.C:
-			feedList, err := p.service.ListFeeds(ctx, nil)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-p.clock.After(randomDuration(p.jitter)):
+			}
+
+			err := p.poll(ctx)
 			if err != nil {
 				return err
 			}
+		}
+	}
+}
+
+// poll refreshes every feed, skipping the run when one triggered through RunOnce is already in progress
+func (p Poller) poll(ctx context.Context) error {
+	_, err := p.RunOnce(ctx)
+	if errors.Is(err, ErrRunning) {
+		p.logger.Info("skipping poll, a poll is already running")
+		return nil
+	}
+
+	return err
+}
+
+// RunOnce refreshes every feed that is not backing off from earlier failures, running up to p.concurrency refreshes at once, and summarizes how each feed went.
+// This is the work done on each tick. ErrRunning is returned without refreshing anything when a refresh of every feed is already in progress.
+func (p Poller) RunOnce(ctx context.Context) (Summary, error) {
+	if !p.running.TryLock() {
+		return Summary{}, ErrRunning
+	}
+	defer p.running.Unlock()
+
+	return p.refreshAll(ctx)
+}
+
+func (p Poller) refreshAll(ctx context.Context) (Summary, error) {
+	now := p.clock.Now()
+	stored, err := p.service.ListAllFeeds(ctx)
+	if err != nil {
+		p.metrics.errors.Inc()
+		return Summary{}, err
+	}
+
+	feeds := make([]*storage.Feed, 0, len(stored))
+	for _, f := range stored {
+		if p.failures.ready(f.ID, now) {
+			feeds = append(feeds, f)
+		}
+	}
+
+	jobs := make(chan int)
+	results := make(chan result, len(feeds))
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency && i < len(feeds); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := p.refresh(ctx, feeds[i])
+				r.index = i
+				results <- r
+			}
+		}()
+	}
 
-			for _, f := range feedList.Feeds {
-				new, err := p.service.RefreshFeed(ctx, f)
-				if err != nil {
-					p.logger.Error("failed to refreshed feed articles", zap.Error(err), zap.Any("feed", f.Title))
-					continue
-				}
+send:
+	for i := range feeds {
+		// select picks randomly when a worker is also ready, so check for cancellation first
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break send
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	ordered := make([]result, 0, len(feeds))
+	for r := range results {
+		ordered = append(ordered, r)
+	}
+	// feeds not refreshed before the context was canceled have no result
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].index < ordered[j].index })
+
+	summary := Summary{
+		Feeds:   make([]FeedSummary, 0, len(ordered)),
+		Skipped: len(stored) - len(feeds),
+	}
+
+	for _, r := range ordered {
+		fs := FeedSummary{ID: r.feed.ID, Title: r.feed.Title, Added: r.added}
+		if r.err != nil {
+			fs.Error = r.err.Error()
+		}
+		summary.Feeds = append(summary.Feeds, fs)
+
+		summary.Added += r.added
+		p.metrics.articlesAdded.Add(float64(r.added))
+		if r.err != nil {
+			p.metrics.errors.Inc()
+		} else {
+			p.metrics.feedsRefreshed.Inc()
+		}
 
-				p.logger.Info("successfully refreshed feed", zap.String("feed", f.Title), zap.Int("articles added", len(new)))
+		// a refresh that stored some articles reached the feed, so only a refresh that stored nothing counts towards the backoff
+		if r.err != nil && r.added == 0 {
+			summary.Failed++
+			if backoff := p.failures.failed(r.feed.ID, now); backoff > 0 {
+				p.logger.Warn("backing off feed", zap.String("feed", r.feed.Title), zap.Int("failures", p.failures.count(r.feed.ID)), zap.Duration("backoff", backoff))
 			}
+			continue
 		}
+
+		p.failures.succeeded(r.feed.ID)
 	}
+
+	p.logger.Info("finished refreshing feeds", zap.Int("feeds", len(feeds)), zap.Int("skipped", summary.Skipped), zap.Int("failed", summary.Failed), zap.Int("articles added", summary.Added))
+	return summary, nil
+}
+
+// refresh refreshes a single feed. A panic is recovered and reported as the feed's error so it does not take down the other workers.
+func (p Poller) refresh(ctx context.Context, f *storage.Feed) (r result) {
+	r.feed = f
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.err = fmt.Errorf("panic refreshing feed: %v", rec)
+			p.logger.Error("failed to refresh feed articles", zap.Error(r.err), zap.Any("feed", f.Title))
+		}
+	}()
+
+	new, err := p.service.RefreshFeed(ctx, f)
+	r.added = len(new)
+	r.err = err
+	if err != nil {
+		// a refresh can partially fail, in which case the articles that were stored are still counted
+		p.logger.Error("failed to refresh feed articles", zap.Error(err), zap.Any("feed", f.Title), zap.Int("articles added", r.added))
+		return r
+	}
+
+	p.logger.Info("successfully refreshed feed", zap.String("feed", f.Title), zap.Int("articles added", r.added))
+	return r
 }
@@ -0,0 +1,305 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kdwils/feedreader/pkg/metrics"
+	"github.com/kdwils/feedreader/pkg/parser"
+	parserMocks "github.com/kdwils/feedreader/pkg/parser/mocks"
+	"github.com/kdwils/feedreader/service"
+	serviceMocks "github.com/kdwils/feedreader/service/mocks"
+	"github.com/kdwils/feedreader/storage"
+	storageMocks "github.com/kdwils/feedreader/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPoller_RefreshAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	ctx := context.Background()
+
+	feeds := make([]*storage.Feed, 0)
+	for i := 1; i <= 6; i++ {
+		feeds = append(feeds, &storage.Feed{ID: fmt.Sprintf("%d", i), RSSLink: fmt.Sprintf("https://example.com/%d.xml", i)})
+	}
+	store.EXPECT().ListFeeds(ctx, storage.DefaultOptions()).Return(storage.FeedList{Feeds: feeds}, nil)
+
+	var running, maxRunning, refreshed int32
+	p.EXPECT().ConditionalParseFromURI(ctx, gomock.Any(), "", "").Times(len(feeds)).DoAndReturn(func(ctx context.Context, uri, etag, lastModified string) (*parser.RSSFeed, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&refreshed, 1)
+		if uri == "https://example.com/2.xml" {
+			panic("malformed feed")
+		}
+
+		return &parser.RSSFeed{}, nil
+	})
+	store.EXPECT().ListArticlesByFeed(ctx, gomock.Any()).Times(len(feeds)-1).Return([]*storage.Article{}, nil)
+	store.EXPECT().RecordFeedFetch(ctx, gomock.Any(), "").Times(len(feeds) - 1).Return(nil)
+
+	poller := New(time.NewTicker(time.Hour), service.New(store, p), zap.NewNop(), WithConcurrency(2), WithMetrics(metrics.NewRegistry()))
+	summary, err := poller.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Summary{Failed: 1}
+	for _, f := range feeds {
+		fs := FeedSummary{ID: f.ID}
+		if f.ID == "2" {
+			fs.Error = "panic refreshing feed: malformed feed"
+		}
+		want.Feeds = append(want.Feeds, fs)
+	}
+	assert.Equal(t, want, summary)
+
+	assert.Equal(t, int32(len(feeds)), refreshed)
+	assert.LessOrEqual(t, maxRunning, int32(2))
+	assert.Equal(t, float64(len(feeds)-1), poller.metrics.feedsRefreshed.Value())
+	assert.Equal(t, float64(1), poller.metrics.errors.Value())
+}
+
+func TestPoller_RefreshAllCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	feeds := []*storage.Feed{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	store.EXPECT().ListFeeds(ctx, storage.DefaultOptions()).Return(storage.FeedList{Feeds: feeds}, nil)
+
+	// the first refresh cancels the poll, so no further feeds should be handed to the worker
+	p.EXPECT().ConditionalParseFromURI(ctx, gomock.Any(), "", "").Times(1).DoAndReturn(func(ctx context.Context, uri, etag, lastModified string) (*parser.RSSFeed, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+
+	poller := New(time.NewTicker(time.Hour), service.New(store, p), zap.NewNop(), WithConcurrency(1))
+	summary, err := poller.RunOnce(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, summary.Feeds, 1) {
+		assert.Equal(t, "1", summary.Feeds[0].ID)
+		assert.NotEmpty(t, summary.Feeds[0].Error)
+	}
+}
+
+func TestPoller_RunOnceSummary(t *testing.T) {
+	svc := serviceMocks.NewMockService(gomock.NewController(t))
+	ctx := context.Background()
+
+	feeds := []*storage.Feed{{ID: "1", Title: "ok"}, {ID: "2", Title: "partial"}, {ID: "3", Title: "down"}}
+	svc.EXPECT().ListAllFeeds(ctx).Return(feeds, nil)
+	svc.EXPECT().RefreshFeed(ctx, feeds[0]).Return([]*storage.Article{{ID: "a"}, {ID: "b"}}, nil)
+	svc.EXPECT().RefreshFeed(ctx, feeds[1]).Return([]*storage.Article{{ID: "c"}}, errors.New("failed to store article"))
+	svc.EXPECT().RefreshFeed(ctx, feeds[2]).Return(nil, service.ErrFeedUnreachable)
+
+	poller := New(time.NewTicker(time.Hour), svc, zap.NewNop(), WithConcurrency(3))
+	summary, err := poller.RunOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{
+		Feeds: []FeedSummary{
+			{ID: "1", Title: "ok", Added: 2},
+			{ID: "2", Title: "partial", Added: 1, Error: "failed to store article"},
+			{ID: "3", Title: "down", Error: service.ErrFeedUnreachable.Error()},
+		},
+		Failed: 1,
+		Added:  3,
+	}, summary)
+}
+
+func TestPoller_RunOnceRunning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	poller := New(time.NewTicker(time.Hour), service.New(storageMocks.NewMockStorage(ctrl), parserMocks.NewMockParser(ctrl)), zap.NewNop())
+
+	poller.running.Lock()
+	_, err := poller.RunOnce(context.Background())
+	assert.True(t, errors.Is(err, ErrRunning))
+
+	poller.running.Unlock()
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestFailures(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := newFailures(time.Minute, 10*time.Minute)
+
+	assert.True(t, f.ready("1", now))
+
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		delays = append(delays, f.failed("1", now))
+	}
+	assert.Equal(t, []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}, delays)
+	assert.Equal(t, 5, f.count("1"))
+
+	assert.False(t, f.ready("1", now.Add(9*time.Minute)))
+	assert.True(t, f.ready("1", now.Add(10*time.Minute)))
+	assert.True(t, f.ready("2", now))
+
+	f.succeeded("1")
+	assert.Equal(t, 0, f.count("1"))
+	assert.True(t, f.ready("1", now))
+	assert.Equal(t, 2*time.Minute, f.failed("1", now))
+}
+
+func TestPoller_RefreshAllBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	feed := &storage.Feed{ID: "1", RSSLink: "https://example.com/feed.xml"}
+	store.EXPECT().ListFeeds(ctx, gomock.Any()).AnyTimes().Return(storage.FeedList{Feeds: []*storage.Feed{feed}}, nil)
+
+	poller := New(time.NewTicker(time.Hour), service.New(store, p), zap.NewNop(), WithFailureBackoff(time.Hour, 24*time.Hour))
+	poller.clock = clock
+	runOnce := func() error {
+		_, err := poller.RunOnce(ctx)
+		return err
+	}
+
+	unavailable := &parser.StatusError{StatusCode: 503}
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Times(2).Return(nil, unavailable)
+	store.EXPECT().RecordFeedFetch(ctx, feed.ID, gomock.Not("")).Times(2).Return(nil)
+
+	// the first failure skips the next interval
+	assert.NoError(t, runOnce())
+	assert.Equal(t, 1, poller.failures.count(feed.ID))
+
+	clock.now = clock.now.Add(time.Hour)
+	assert.NoError(t, runOnce())
+	assert.Equal(t, 1, poller.failures.count(feed.ID))
+
+	// the second failure doubles the backoff to four intervals
+	clock.now = clock.now.Add(time.Hour)
+	assert.NoError(t, runOnce())
+	assert.Equal(t, 2, poller.failures.count(feed.ID))
+
+	clock.now = clock.now.Add(3 * time.Hour)
+	assert.NoError(t, runOnce())
+
+	// a success resets the backoff so the feed is refreshed on the following interval
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Times(2).Return(&parser.RSSFeed{}, nil)
+	store.EXPECT().ListArticlesByFeed(ctx, feed.ID).Times(2).Return([]*storage.Article{}, nil)
+	store.EXPECT().RecordFeedFetch(ctx, feed.ID, "").Times(2).Return(nil)
+
+	clock.now = clock.now.Add(time.Hour)
+	assert.NoError(t, runOnce())
+	assert.Equal(t, 0, poller.failures.count(feed.ID))
+
+	clock.now = clock.now.Add(time.Hour)
+	assert.NoError(t, runOnce())
+}
+
+func TestPoller_PollJitter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	poller := New(ticker, service.New(store, p), zap.NewNop(), WithJitter(time.Minute))
+	poller.clock = clock
+
+	store.EXPECT().ListFeeds(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, opts *storage.Options) (storage.FeedList, error) {
+		cancel()
+		return storage.FeedList{}, nil
+	})
+
+	err := poller.Poll(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Less(t, clock.now.Sub(start), time.Minute)
+}
+
+func TestPoller_PollRunOnStart(t *testing.T) {
+	svc := serviceMocks.NewMockService(gomock.NewController(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+
+	// the first tick is an hour away, so only the run on start can refresh the feeds
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	poller := New(ticker, svc, zap.NewNop(), WithRunOnStart(true), WithStartDelay(30*time.Second))
+	poller.clock = clock
+
+	var ranAt time.Time
+	svc.EXPECT().ListAllFeeds(ctx).DoAndReturn(func(ctx context.Context) ([]*storage.Feed, error) {
+		ranAt = clock.Now()
+		cancel()
+		return nil, nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- poller.Poll(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		t.Fatal("feeds were not refreshed before the first tick")
+	}
+	assert.Equal(t, start.Add(30*time.Second), ranAt)
+}
+
+func TestPoller_PollCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	poller := New(ticker, service.New(storageMocks.NewMockStorage(ctrl), parserMocks.NewMockParser(ctrl)), zap.NewNop())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- poller.Poll(ctx)
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		t.Fatal("poll did not return after its context was canceled")
+	}
+}
@@ -0,0 +1,51 @@
+package pruner
+
+import (
+	"context"
+	"time"
+
+	"github.com/kdwils/feedreader/service"
+	"go.uber.org/zap"
+)
+
+// Pruner removes read articles outside the service's retention on a given interval so the database does not grow without bound
+type Pruner struct {
+	service service.Service
+	ticker  *time.Ticker
+	logger  *zap.Logger
+}
+
+func New(ticker *time.Ticker, service service.Service, logger *zap.Logger) Pruner {
+	return Pruner{
+		service: service,
+		ticker:  ticker,
+		logger:  logger,
+	}
+}
+
+// Prune removes the articles outside the retention on each tick until ctx is canceled.
+// A failed prune is logged and tried again on the next tick.
+func (p Pruner) Prune(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.ticker.C:
+			_, err := p.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				p.logger.Error("failed to prune articles", zap.Error(err))
+			}
+		}
+	}
+}
+
+// RunOnce removes the articles outside the retention now and returns how many were removed. This is the work done on each tick.
+func (p Pruner) RunOnce(ctx context.Context) (int, error) {
+	deleted, err := p.service.PruneArticles(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	p.logger.Info("finished pruning articles", zap.Int("articles deleted", deleted))
+	return deleted, nil
+}
@@ -0,0 +1,69 @@
+package pruner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	parserMocks "github.com/kdwils/feedreader/pkg/parser/mocks"
+	"github.com/kdwils/feedreader/service"
+	"github.com/kdwils/feedreader/storage"
+	storageMocks "github.com/kdwils/feedreader/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPruner_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	retention := storage.Retention{MaxAge: 30 * 24 * time.Hour, MaxPerFeed: 100}
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	pruner := New(ticker, service.New(store, parserMocks.NewMockParser(ctrl), service.WithRetention(retention)), zap.NewNop())
+
+	// a failed prune does not stop the next tick from pruning
+	gomock.InOrder(
+		store.EXPECT().PruneArticles(ctx, retention).Return(0, errors.New("database is locked")),
+		store.EXPECT().PruneArticles(ctx, retention).DoAndReturn(func(ctx context.Context, retention storage.Retention) (int, error) {
+			cancel()
+			return 3, nil
+		}),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- pruner.Prune(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(5 * time.Second):
+		t.Fatal("prune did not return after its context was canceled")
+	}
+}
+
+func TestPruner_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	ctx := context.Background()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	pruner := New(ticker, service.New(store, parserMocks.NewMockParser(ctrl), service.WithRetention(storage.Retention{MaxPerFeed: 10})), zap.NewNop())
+
+	store.EXPECT().PruneArticles(ctx, storage.Retention{MaxPerFeed: 10}).Return(4, nil)
+	deleted, err := pruner.RunOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, deleted)
+
+	store.EXPECT().PruneArticles(ctx, gomock.Any()).Return(0, errors.New("database is locked"))
+	_, err = pruner.RunOnce(ctx)
+	assert.Error(t, err)
+}
@@ -13,6 +13,8 @@ const (
 	_ ctxKey = iota
 	ctxLoggerKey
 	ctxOptionsKey
+	ctxRequestIDKey
+	ctxUnreadCountsKey
 )
 
 func LoggerToContext(ctx context.Context, logger *zap.Logger) context.Context {
@@ -40,3 +42,13 @@ func OptionsFromContext(ctx context.Context, with ...zap.Field) *storage.Options
 func OptionsToContext(ctx context.Context, opts *storage.Options) context.Context {
 	return context.WithValue(ctx, ctxOptionsKey, opts)
 }
+
+func RequestIDToContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxRequestIDKey, id)
+}
+
+// RequestIDFromContext returns the id of the request, or an empty string when there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestIDKey).(string)
+	return id
+}
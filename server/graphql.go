@@ -0,0 +1,390 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kdwils/feedreader/pkg/graphql"
+	"github.com/kdwils/feedreader/pkg/sanitize"
+	"github.com/kdwils/feedreader/service"
+	"github.com/kdwils/feedreader/storage"
+	"go.uber.org/zap"
+)
+
+// maxGraphQLBody bounds the size of a posted graphql request
+const maxGraphQLBody = 1 << 20
+
+// GraphQL runs a graphql query against the feeds and articles. Queries are posted as json or sent in the query parameter of a GET, mutations are only accepted when posted.
+func (s Server) GraphQL() http.HandlerFunc {
+	schema := s.graphQLSchema()
+	// a GET can be made by following a link, so it may only read
+	readOnly := &graphql.Schema{Query: schema.Query, MaxDepth: schema.MaxDepth}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		var request graphql.Request
+		exec := schema
+		if r.Method == http.MethodGet {
+			exec = readOnly
+			q := r.URL.Query()
+			request.Query = q.Get("query")
+			request.OperationName = q.Get("operationName")
+			if variables := q.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+					http.Error(w, "invalid variables", http.StatusBadRequest)
+					return
+				}
+			}
+		} else {
+			b, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLBody))
+			if err != nil {
+				l.Error("failed to read request body", zap.Error(err))
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+			if err := json.Unmarshal(b, &request); err != nil {
+				http.Error(w, "invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if strings.TrimSpace(request.Query) == "" {
+			http.Error(w, "query is required", http.StatusBadRequest)
+			return
+		}
+
+		response := exec.Execute(withUnreadCounts(r.Context()), request)
+		writeResponse(w, http.StatusOK, response)
+	}
+}
+
+// graphQLSchema builds the schema the graphql endpoint serves:
+//
+//	type Query {
+//	  feeds(first: Int, after: String): FeedConnection!
+//	  feed(id: ID!): Feed
+//	  articles(status: ArticleStatus = ALL, tag: String, search: String, first: Int, after: String, sort: ArticleSort, order: Order): ArticleConnection!
+//	  article(id: ID!): Article
+//	}
+//	type Mutation {
+//	  markRead(id: ID!, read: Boolean = true): Article
+//	  favorite(id: ID!, favorited: Boolean = true): Article
+//	}
+//
+// Lists are paged with the same cursors as the rest of the api: first is the page size and after is the endCursor of the previous page.
+func (s Server) graphQLSchema() *graphql.Schema {
+	sortBy := &graphql.Enum{Name: "ArticleSort", Values: []string{"PUBLISHED", "ADDED", "TITLE"}}
+	order := &graphql.Enum{Name: "Order", Values: []string{"ASC", "DESC"}}
+	status := &graphql.Enum{Name: "ArticleStatus", Values: []string{"ALL", "READ", "UNREAD", "FAVORITED", "SAVED"}}
+	pageArgs := func() map[string]*graphql.Argument {
+		return map[string]*graphql.Argument{
+			"first": {Type: graphql.Int},
+			"after": {Type: graphql.String},
+			"sort":  {Type: sortBy},
+			"order": {Type: order},
+		}
+	}
+
+	nonNullString := &graphql.NonNull{OfType: graphql.String}
+	nonNullBoolean := &graphql.NonNull{OfType: graphql.Boolean}
+	pageInfo := &graphql.Object{Name: "PageInfo", Fields: graphql.Fields{
+		"endCursor":       {Type: graphql.String, Resolve: cursorField(func(c storage.Cursor) any { return c.Next })},
+		"hasNextPage":     {Type: nonNullBoolean, Resolve: cursorField(func(c storage.Cursor) any { return c.HasNext })},
+		"startCursor":     {Type: graphql.String, Resolve: cursorField(func(c storage.Cursor) any { return c.Prev })},
+		"hasPreviousPage": {Type: nonNullBoolean, Resolve: cursorField(func(c storage.Cursor) any { return c.HasPrev })},
+	}}
+
+	enclosure := &graphql.Object{Name: "Enclosure", Fields: graphql.Fields{
+		"url":    {Type: nonNullString},
+		"type":   {Type: graphql.String},
+		"length": {Type: graphql.Float},
+	}}
+
+	feed := &graphql.Object{Name: "Feed", Fields: graphql.Fields{
+		"id":                  {Type: &graphql.NonNull{OfType: graphql.ID}},
+		"title":               {Type: nonNullString},
+		"rssLink":             {Type: nonNullString},
+		"siteLink":            {Type: graphql.String},
+		"description":         {Type: graphql.String},
+		"image":               {Type: graphql.String},
+		"language":            {Type: graphql.String},
+		"folderId":            {Type: graphql.ID},
+		"lastError":           {Type: graphql.String},
+		"consecutiveFailures": {Type: &graphql.NonNull{OfType: graphql.Int}},
+		"unreadCount": {
+			Type: &graphql.NonNull{OfType: graphql.Int},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				counts, err := unreadCountsFromContext(p.Context, s.service)
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to count unread articles", err)
+				}
+				return counts[p.Source.(*storage.Feed).ID], nil
+			},
+		},
+	}}
+
+	article := &graphql.Object{Name: "Article", Fields: graphql.Fields{
+		"id":          {Type: &graphql.NonNull{OfType: graphql.ID}},
+		"feedId":      {Type: &graphql.NonNull{OfType: graphql.ID}, Resolve: articleField(func(a *storage.Article) any { return a.FeedID })},
+		"title":       {Type: nonNullString},
+		"author":      {Type: graphql.String},
+		"link":        {Type: graphql.String},
+		"guid":        {Type: graphql.String},
+		"description": {Type: graphql.String},
+		"content":     {Type: graphql.String},
+		"excerpt": {Type: graphql.String, Resolve: articleField(func(a *storage.Article) any {
+			return sanitize.Excerpt(a.Description, s.excerptLength)
+		})},
+		"enclosure": {Type: enclosure},
+		"tags": {Type: &graphql.NonNull{OfType: &graphql.List{OfType: nonNullString}}, Resolve: articleField(func(a *storage.Article) any {
+			if a.Tags == nil {
+				return []string{}
+			}
+			return a.Tags
+		})},
+		// published is the unix time the article was published, publishedOn the date as the api formats it
+		"published":   {Type: graphql.Float},
+		"publishedOn": {Type: graphql.String},
+		"readDate":    {Type: graphql.String},
+		"read":        {Type: nonNullBoolean},
+		"favorited":   {Type: nonNullBoolean},
+		"saved":       {Type: nonNullBoolean},
+		"feed": {Type: feed, Resolve: func(p graphql.ResolveParams) (any, error) {
+			f, err := s.service.GetFeed(p.Context, p.Source.(*storage.Article).FeedID)
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, s.graphQLError(p.Context, "failed to get feed", err)
+			}
+			return f, nil
+		}},
+	}}
+
+	feedConnection := &graphql.Object{Name: "FeedConnection", Fields: graphql.Fields{
+		"nodes": {Type: &graphql.NonNull{OfType: &graphql.List{OfType: &graphql.NonNull{OfType: feed}}}, Resolve: func(p graphql.ResolveParams) (any, error) {
+			feeds := p.Source.(storage.FeedList).Feeds
+			if feeds == nil {
+				return []*storage.Feed{}, nil
+			}
+			return feeds, nil
+		}},
+		"pageInfo": {Type: &graphql.NonNull{OfType: pageInfo}, Resolve: func(p graphql.ResolveParams) (any, error) { return p.Source.(storage.FeedList).Cursor, nil }},
+	}}
+	articleConnection := &graphql.Object{Name: "ArticleConnection", Fields: graphql.Fields{
+		"nodes": {Type: &graphql.NonNull{OfType: &graphql.List{OfType: &graphql.NonNull{OfType: article}}}, Resolve: func(p graphql.ResolveParams) (any, error) {
+			articles := p.Source.(storage.ArticleList).Articles
+			if articles == nil {
+				return []*storage.Article{}, nil
+			}
+			return articles, nil
+		}},
+		"pageInfo": {Type: &graphql.NonNull{OfType: pageInfo}, Resolve: func(p graphql.ResolveParams) (any, error) { return p.Source.(storage.ArticleList).Cursor, nil }},
+	}}
+
+	feed.Fields["articles"] = &graphql.Field{
+		Type: &graphql.NonNull{OfType: articleConnection},
+		Args: pageArgs(),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			opts, err := s.graphQLOptions(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			articles, err := s.service.ListFeedArticles(p.Context, p.Source.(*storage.Feed).ID, opts)
+			if err != nil {
+				return nil, s.graphQLError(p.Context, "failed to list articles", err)
+			}
+			return articles, nil
+		},
+	}
+
+	articlesArgs := pageArgs()
+	articlesArgs["status"] = &graphql.Argument{Type: status, Default: "ALL"}
+	articlesArgs["tag"] = &graphql.Argument{Type: graphql.String}
+	articlesArgs["search"] = &graphql.Argument{Type: graphql.String}
+
+	query := &graphql.Object{Name: "Query", Fields: graphql.Fields{
+		"feeds": {
+			Type: &graphql.NonNull{OfType: feedConnection},
+			Args: map[string]*graphql.Argument{
+				"first": {Type: graphql.Int},
+				"after": {Type: graphql.String},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				opts, err := s.graphQLOptions(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				feeds, err := s.service.ListFeeds(p.Context, opts)
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to list feeds", err)
+				}
+				return feeds, nil
+			},
+		},
+		"feed": {
+			Type: feed,
+			Args: map[string]*graphql.Argument{"id": {Type: &graphql.NonNull{OfType: graphql.ID}}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				f, err := s.service.GetFeed(p.Context, p.Args.String("id"))
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to get feed", err)
+				}
+				return f, nil
+			},
+		},
+		"articles": {
+			Type: &graphql.NonNull{OfType: articleConnection},
+			Args: articlesArgs,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				opts, err := s.graphQLOptions(p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				var articles storage.ArticleList
+				switch {
+				case p.Args.String("search") != "":
+					articles, err = s.service.SearchArticles(p.Context, p.Args.String("search"), opts)
+				case p.Args.String("tag") != "":
+					articles, err = s.service.ListTaggedArticles(p.Context, p.Args.String("tag"), opts)
+				default:
+					articles, err = s.service.ListArticlesByStatus(p.Context, storage.ParseStatus(p.Args.String("status")), opts)
+				}
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to list articles", err)
+				}
+				return articles, nil
+			},
+		},
+		"article": {
+			Type: article,
+			Args: map[string]*graphql.Argument{"id": {Type: &graphql.NonNull{OfType: graphql.ID}}},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a, err := s.service.GetArticle(p.Context, p.Args.String("id"))
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to get article", err)
+				}
+				return a, nil
+			},
+		},
+	}}
+
+	mutation := &graphql.Object{Name: "Mutation", Fields: graphql.Fields{
+		"markRead": {
+			Type: article,
+			Args: map[string]*graphql.Argument{
+				"id":   {Type: &graphql.NonNull{OfType: graphql.ID}},
+				"read": {Type: graphql.Boolean, Default: true},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a, err := s.service.MarkArticleRead(p.Context, p.Args.String("id"), service.MarkArticleReadRequest{Read: p.Args.Bool("read", true)})
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, errors.New("article not found")
+				}
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to mark article read", err)
+				}
+				return a, nil
+			},
+		},
+		"favorite": {
+			Type: article,
+			Args: map[string]*graphql.Argument{
+				"id":        {Type: &graphql.NonNull{OfType: graphql.ID}},
+				"favorited": {Type: graphql.Boolean, Default: true},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a, err := s.service.SetArticleFavorited(p.Context, p.Args.String("id"), service.SetArticleFavoritedRequest{Favorited: p.Args.Bool("favorited", true)})
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, errors.New("article not found")
+				}
+				if err != nil {
+					return nil, s.graphQLError(p.Context, "failed to favorite article", err)
+				}
+				return a, nil
+			},
+		},
+	}}
+
+	return &graphql.Schema{Query: query, Mutation: mutation}
+}
+
+// graphQLOptions maps the paging arguments of a field onto the options the rest of the api reads from the query string, so the page size limits and cursors are the same
+func (s Server) graphQLOptions(args graphql.Args) (*storage.Options, error) {
+	values := url.Values{}
+	if first, ok := args.Int("first"); ok {
+		values.Set("limit", strconv.Itoa(first))
+	}
+	values.Set("cursor", args.String("after"))
+	values.Set("sort", strings.ToLower(args.String("sort")))
+	switch args.String("order") {
+	case "ASC":
+		values.Set("order", string(storage.Ascending))
+	case "DESC":
+		values.Set("order", string(storage.Descending))
+	}
+
+	opts, err := storage.ParseOptions(values, s.pageSize, s.cursors)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return opts, nil
+}
+
+// graphQLError logs why a resolver failed and returns the message clients see in its place, the same way handlers hide internal errors behind their http error
+func (s Server) graphQLError(ctx context.Context, message string, err error) error {
+	LoggerFromContext(ctx).Error(message, zap.Error(err))
+	return errors.New(message)
+}
+
+func cursorField(f func(c storage.Cursor) any) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (any, error) {
+		return f(p.Source.(storage.Cursor)), nil
+	}
+}
+
+func articleField(f func(a *storage.Article) any) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) (any, error) {
+		return f(p.Source.(*storage.Article)), nil
+	}
+}
+
+// unreadCounts counts the unread articles of every feed once per request, rather than once for every feed a query asks for
+type unreadCounts struct {
+	once   sync.Once
+	counts map[string]int
+	err    error
+}
+
+func withUnreadCounts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxUnreadCountsKey, &unreadCounts{})
+}
+
+func unreadCountsFromContext(ctx context.Context, svc service.Service) (map[string]int, error) {
+	u, ok := ctx.Value(ctxUnreadCountsKey).(*unreadCounts)
+	if !ok {
+		counts, _, err := svc.UnreadCounts(ctx)
+		return counts, err
+	}
+
+	u.once.Do(func() {
+		u.counts, _, u.err = svc.UnreadCounts(ctx)
+	})
+	return u.counts, u.err
+}
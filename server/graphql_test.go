@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kdwils/feedreader/pkg/graphql"
+	"github.com/kdwils/feedreader/service"
+	"github.com/kdwils/feedreader/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func postGraphQL(t *testing.T, s Server, request graphql.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	b, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(b)))
+	return w
+}
+
+func TestServer_GraphQL(t *testing.T) {
+	t.Run("feed with its articles", func(t *testing.T) {
+		s, svc := newMockServiceServer(t)
+		svc.EXPECT().GetFeed(gomock.Any(), "feed-1").Return(&storage.Feed{ID: "feed-1", Title: "example"}, nil)
+		svc.EXPECT().UnreadCounts(gomock.Any()).Return(map[string]int{"feed-1": 4}, 4, nil)
+		svc.EXPECT().ListFeedArticles(gomock.Any(), "feed-1", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, opts *storage.Options) (storage.ArticleList, error) {
+			assert.Equal(t, 2, opts.Limit, "first is the page size")
+			assert.Equal(t, storage.SortTitle, opts.SortBy)
+			assert.Equal(t, storage.Ascending, opts.Order)
+			return storage.ArticleList{
+				Cursor:   storage.Cursor{Next: "next-page", HasNext: true},
+				Articles: []*storage.Article{{ID: "a", FeedID: "feed-1", Title: "first", Tags: []string{"go"}}, {ID: "b", FeedID: "feed-1", Title: "second"}},
+			}, nil
+		})
+
+		w := postGraphQL(t, s, graphql.Request{
+			Query: `query ($id: ID!) {
+				feed(id: $id) {
+					title
+					unreadCount
+					articles(first: 2, sort: TITLE, order: ASC) {
+						nodes { id title tags }
+						pageInfo { endCursor hasNextPage }
+					}
+				}
+			}`,
+			Variables: map[string]any{"id": "feed-1"},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":{"feed":{
+			"title":"example",
+			"unreadCount":4,
+			"articles":{
+				"nodes":[{"id":"a","title":"first","tags":["go"]},{"id":"b","title":"second","tags":[]}],
+				"pageInfo":{"endCursor":"next-page","hasNextPage":true}
+			}
+		}}}`, w.Body.String())
+	})
+
+	t.Run("unread counts are read once per request", func(t *testing.T) {
+		s, svc := newMockServiceServer(t)
+		svc.EXPECT().ListFeeds(gomock.Any(), gomock.Any()).Return(storage.FeedList{Feeds: []*storage.Feed{{ID: "1"}, {ID: "2"}}}, nil)
+		svc.EXPECT().UnreadCounts(gomock.Any()).Return(map[string]int{"1": 3}, 3, nil).Times(1)
+
+		w := postGraphQL(t, s, graphql.Request{Query: `{ feeds { nodes { id unreadCount } } }`})
+		assert.JSONEq(t, `{"data":{"feeds":{"nodes":[{"id":"1","unreadCount":3},{"id":"2","unreadCount":0}]}}}`, w.Body.String())
+	})
+
+	t.Run("articles by status", func(t *testing.T) {
+		s, svc := newMockServiceServer(t)
+		svc.EXPECT().ListArticlesByStatus(gomock.Any(), storage.StatusFavorited, gomock.Any()).Return(storage.ArticleList{}, nil)
+
+		w := postGraphQL(t, s, graphql.Request{Query: `{ articles(status: FAVORITED) { nodes { id } pageInfo { hasNextPage } } }`})
+		assert.JSONEq(t, `{"data":{"articles":{"nodes":[],"pageInfo":{"hasNextPage":false}}}}`, w.Body.String())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		s, _ := newMockServiceServer(t)
+
+		w := postGraphQL(t, s, graphql.Request{Query: `{ articles(after: "forged") { nodes { id } } }`})
+		assert.JSONEq(t, `{"errors":[{"message":"invalid cursor","path":["articles"]}]}`, w.Body.String())
+	})
+
+	t.Run("internal errors are not returned", func(t *testing.T) {
+		s, svc := newMockServiceServer(t)
+		svc.EXPECT().GetFeed(gomock.Any(), "1").Return(nil, errors.New("disk I/O error"))
+
+		w := postGraphQL(t, s, graphql.Request{Query: `{ feed(id: 1) { id } }`})
+		assert.JSONEq(t, `{"data":{"feed":null},"errors":[{"message":"failed to get feed","path":["feed"]}]}`, w.Body.String())
+	})
+
+	t.Run("mark read and favorite", func(t *testing.T) {
+		s, svc := newMockServiceServer(t)
+		svc.EXPECT().MarkArticleRead(gomock.Any(), "a", service.MarkArticleReadRequest{Read: false}).Return(&storage.Article{ID: "a"}, nil)
+		svc.EXPECT().SetArticleFavorited(gomock.Any(), "b", service.SetArticleFavoritedRequest{Favorited: true}).Return(&storage.Article{ID: "b", Favorited: true}, nil)
+		svc.EXPECT().MarkArticleRead(gomock.Any(), "missing", service.MarkArticleReadRequest{Read: true}).Return(nil, storage.ErrNotFound)
+
+		w := postGraphQL(t, s, graphql.Request{Query: `mutation {
+			unread: markRead(id: "a", read: false) { id read }
+			favorite(id: "b") { id favorited }
+			missing: markRead(id: "missing") { id }
+		}`})
+		assert.JSONEq(t, `{
+			"data":{"unread":{"id":"a","read":false},"favorite":{"id":"b","favorited":true},"missing":null},
+			"errors":[{"message":"article not found","path":["missing"]}]
+		}`, w.Body.String())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		s, svc := newMockServiceServer(t)
+		svc.EXPECT().GetArticle(gomock.Any(), "a").Return(&storage.Article{ID: "a", Title: "first"}, nil)
+
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{"query": {`{ article(id: "a") { title } }`}}.Encode(), nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":{"article":{"title":"first"}}}`, w.Body.String())
+	})
+
+	t.Run("mutations are not run from a get", func(t *testing.T) {
+		s, _ := newMockServiceServer(t)
+
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{"query": {`mutation { markRead(id: "a") { id } }`}}.Encode(), nil))
+		assert.JSONEq(t, `{"errors":[{"message":"mutation operations are not supported"}]}`, w.Body.String())
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		s, _ := newMockServiceServer(t)
+
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString("{")))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = postGraphQL(t, s, graphql.Request{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
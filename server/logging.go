@@ -1,13 +1,43 @@
 package server
 
 import (
+	"fmt"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-func NewLogger() (*zap.Logger, error) {
+// NewLogger builds a logger writing at level and above with the json or console encoding. An empty level or encoding logs json at info.
+func NewLogger(level, encoding string) (*zap.Logger, error) {
+	cfg, err := newLoggerConfig(level, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Build(zap.AddCaller(), zap.AddCallerSkip(1))
+}
+
+func newLoggerConfig(level, encoding string) (zap.Config, error) {
 	cfg := zap.NewProductionConfig()
 	cfg.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
 	cfg.EncoderConfig.StacktraceKey = ""
-	return cfg.Build(zap.AddCaller(), zap.AddCallerSkip(1))
+
+	if level != "" {
+		l, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Level = zap.NewAtomicLevelAt(l)
+	}
+
+	switch encoding {
+	case "", "json":
+	case "console":
+		cfg.Encoding = encoding
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	default:
+		return cfg, fmt.Errorf("unknown log encoding %q", encoding)
+	}
+
+	return cfg, nil
 }
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewLoggerConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		level        string
+		encoding     string
+		wantLevel    zapcore.Level
+		wantEncoding string
+		wantErr      bool
+	}{
+		{
+			name:         "defaults",
+			wantLevel:    zapcore.InfoLevel,
+			wantEncoding: "json",
+		},
+		{
+			name:         "debug console",
+			level:        "debug",
+			encoding:     "console",
+			wantLevel:    zapcore.DebugLevel,
+			wantEncoding: "console",
+		},
+		{
+			name:         "upper case level",
+			level:        "WARN",
+			encoding:     "json",
+			wantLevel:    zapcore.WarnLevel,
+			wantEncoding: "json",
+		},
+		{
+			name:    "unknown level",
+			level:   "verbose",
+			wantErr: true,
+		},
+		{
+			name:     "unknown encoding",
+			encoding: "xml",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newLoggerConfig(tt.level, tt.encoding)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.wantLevel, cfg.Level.Level())
+			assert.Equal(t, tt.wantEncoding, cfg.Encoding)
+
+			logger, err := NewLogger(tt.level, tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, logger.Core().Enabled(tt.wantLevel))
+			assert.False(t, logger.Core().Enabled(tt.wantLevel-1))
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/kdwils/feedreader/pkg/metrics"
+)
+
+type httpMetrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+// newHTTPMetrics registers the request metrics, sharing them with any server that already registered them on the same registry
+func newHTTPMetrics(reg *metrics.Registry) httpMetrics {
+	return httpMetrics{
+		requests: metrics.Share(reg, metrics.NewCounterVec("feedreader_http_requests_total", "HTTP requests served by route, method, and status.", "route", "method", "status")),
+		duration: metrics.Share(reg, metrics.NewHistogramVec("feedreader_http_request_duration_seconds", "HTTP request latency in seconds by route and method.", metrics.DefaultBuckets, "route", "method")),
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes through to the underlying writer so event streams still flush
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// MetricsMiddleware records the count and latency of requests. Requests are labelled with the route template rather than the path so ids do not create a series each.
+func (s Server) MetricsMiddleware() mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := "unknown"
+			if current := mux.CurrentRoute(r); current != nil {
+				if tmpl, err := current.GetPathTemplate(); err == nil {
+					route = tmpl
+				}
+			}
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			h.ServeHTTP(rec, r)
+
+			s.metrics.requests.Inc(route, r.Method, strconv.Itoa(rec.status))
+			s.metrics.duration.Observe(time.Since(start).Seconds(), route, r.Method)
+		})
+	}
+}
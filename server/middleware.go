@@ -1,25 +1,264 @@
 package server
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/kdwils/feedreader/storage"
 	"go.uber.org/zap"
 )
 
+// RequestIDHeader carries the id that ties together the logs of a single request
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of an id accepted from a client
+const maxRequestIDLength = 128
+
+// LogMiddleware adds a logger to the request context tagged with the path and request id. The id is taken from the X-Request-ID header when the client sent a usable one, otherwise one is generated, and it is echoed back in the response.
 func (s Server) LogMiddleware() mux.MiddlewareFunc {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			r = r.WithContext(LoggerToContext(r.Context(), s.logger.With(zap.String("path", r.URL.Path))))
-			h.ServeHTTP(w, r)
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			logger := s.logger.With(zap.String("path", r.URL.Path), zap.String("requestID", id), zap.String("clientIP", s.clientIP(r)))
+			ctx := RequestIDToContext(r.Context(), id)
+			ctx = LoggerToContext(ctx, logger)
+			r = r.WithContext(ctx)
+
+			if !logger.Core().Enabled(zap.DebugLevel) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.Any("headers", s.loggedHeaders(r.Header)),
+			}
+			if s.logBodies {
+				fields = append(fields, s.loggedBody(r)...)
+			}
+
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			h.ServeHTTP(rec, r)
+
+			fields = append(fields, zap.Int("status", rec.status), zap.Duration("duration", time.Since(start)))
+			logger.Debug("request", fields...)
+		})
+	}
+}
+
+// loggedHeaders joins the values of each header, replacing those of redacted headers
+func (s Server) loggedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if s.redactedHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = "[redacted]"
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	return headers
+}
+
+// loggedBody reads up to the logged body size of the request body and puts it back so the handler still reads all of it.
+// A body over the size is not logged, only noted as too large.
+func (s Server) loggedBody(r *http.Request) []zap.Field {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(r.Body, int64(s.maxLoggedBody)+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), r.Body), r.Body}
+	if err != nil {
+		return nil
+	}
+
+	if len(b) > s.maxLoggedBody {
+		return []zap.Field{zap.Bool("bodyTooLarge", true)}
+	}
+
+	return []zap.Field{zap.ByteString("body", b)}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// validRequestID only accepts short ids of printable ascii so a client cannot inject into the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AuthMiddleware rejects requests that do not present a configured api key, either as a bearer token in the Authorization header or in the X-API-Key header
+func (s Server) AuthMiddleware() mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(s.apiKeys) == 0 || s.bypass[r.URL.Path] || s.validAPIKey(requestAPIKey(r)) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			LoggerFromContext(r.Context()).Info("unauthorized request")
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
 		})
 	}
 }
 
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// validAPIKey compares the key against every configured key in constant time so the response time does not reveal how much of a key matched
+func (s Server) validAPIKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	valid := 0
+	for _, k := range s.apiKeys {
+		valid |= subtle.ConstantTimeCompare([]byte(key), k)
+	}
+
+	return valid == 1
+}
+
+// RateLimitMiddleware responds with 429 and a Retry-After header once a client has used up its requests. Without a limiter every request is let through.
+func (s Server) RateLimitMiddleware() mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.limiter == nil {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			ok, wait := s.limiter.Allow(s.clientIP(r))
+			if ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+
+			LoggerFromContext(r.Context()).Info("rate limited request", zap.Int("retryAfter", retryAfter))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+		})
+	}
+}
+
+// clientIP returns the address of the client. When the request comes from a trusted proxy the address is taken from X-Forwarded-For, or X-Real-IP when it is not set, otherwise it is the connection's address.
+// X-Forwarded-For is read from the end since each proxy appends the address it received the request from, and the first address not belonging to a trusted proxy is the client.
+// Anything before that address was sent by the client and could be forged.
+func (s Server) clientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || !s.trustedProxy(addr) {
+		return peer
+	}
+
+	forwardedFor := r.Header.Values("X-Forwarded-For")
+	if len(forwardedFor) == 0 {
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return realIP.Unmap().String()
+		}
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+	client := addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		// the address of the last proxy to hand the request on is the best that can be told of a malformed header
+		if err != nil {
+			break
+		}
+
+		client = hop
+		if !s.trustedProxy(hop) {
+			break
+		}
+	}
+
+	return client.Unmap().String()
+}
+
+// trustedProxy reports whether the forwarded headers of requests from addr are believed
+func (s Server) trustedProxy(addr netip.Addr) bool {
+	if s.trustForwardedFor {
+		return true
+	}
+
+	addr = addr.Unmap()
+	for _, p := range s.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s Server) OptionsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		opts := storage.ParseOptions(r.URL.Query())
+		opts, err := storage.ParseOptions(r.URL.Query(), s.pageSize, s.cursors)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+
 		r = r.WithContext(OptionsToContext(r.Context(), opts))
 		next(w, r)
 	})
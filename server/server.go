@@ -2,31 +2,280 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/kdwils/feedreader/events"
+	"github.com/kdwils/feedreader/pkg/metrics"
+	"github.com/kdwils/feedreader/pkg/ratelimit"
+	"github.com/kdwils/feedreader/pkg/sanitize"
+	"github.com/kdwils/feedreader/pkg/websocket"
+	"github.com/kdwils/feedreader/poller"
+	"github.com/kdwils/feedreader/pruner"
 	"github.com/kdwils/feedreader/service"
+	"github.com/kdwils/feedreader/storage"
 	"go.uber.org/zap"
 )
 
+// DefaultHeartbeat is how often an idle event stream is sent a comment, and a websocket a ping, so proxies do not close the connection
+const DefaultHeartbeat = 15 * time.Second
+
+// DefaultExcerptLength is the most characters of a listed article's excerpt
+const DefaultExcerptLength = 200
+
+// maxBatchFeeds is the most feeds a single batch request can create
+const maxBatchFeeds = 100
+
+// DefaultRedactedHeaders are the request headers whose values are left out of debug request logs
+var DefaultRedactedHeaders = []string{"Authorization", "X-API-Key", "Cookie"}
+
 type Server struct {
-	logger  *zap.Logger
-	service service.Service
+	logger    *zap.Logger
+	service   service.Service
+	heartbeat time.Duration
+	pageSize  storage.PageSize
+	cursors   *storage.CursorCodec
+	apiKeys   [][]byte
+	bypass    map[string]bool
+	registry  *metrics.Registry
+	metrics   httpMetrics
+	cors      []handlers.CORSOption
+	// origins are the origins browsers may call the api from, websocket upgrades from any other origin are refused
+	origins []string
+	limiter *ratelimit.Limiter
+	poller  *poller.Poller
+	pruner  *pruner.Pruner
+	// excerptLength is the most characters of a listed article's excerpt
+	excerptLength int
+	// logBodies logs request bodies of up to maxLoggedBody bytes in the debug request logs
+	logBodies     bool
+	maxLoggedBody int
+	// redactedHeaders are the canonical names of the headers whose values are left out of debug request logs
+	redactedHeaders map[string]bool
+	// trustForwardedFor trusts the forwarded headers of every peer, as if every address were a trusted proxy
+	trustForwardedFor bool
+	// trustedProxies are the peers whose X-Forwarded-For and X-Real-IP headers are believed
+	trustedProxies []netip.Prefix
+}
+
+// Option configures optional Server settings
+type Option func(*Server)
+
+// WithPageSize sets the page size used when a list request does not ask for one and the largest page size it can ask for
+func WithPageSize(defaultLimit, maxLimit int) Option {
+	return func(s *Server) {
+		s.pageSize = storage.PageSize{
+			Default: defaultLimit,
+			Max:     maxLimit,
+		}
+	}
+}
+
+// WithCursorKey signs pagination cursors with the key. Without a key cursors are signed with a random key and stop working when the server restarts.
+func WithCursorKey(key string) Option {
+	return func(s *Server) {
+		s.cursors = storage.NewCursorCodec([]byte(key))
+	}
+}
+
+// WithExcerptLength sets the most characters of the excerpt added to listed articles, 0 leaves excerpts out
+func WithExcerptLength(length int) Option {
+	return func(s *Server) {
+		s.excerptLength = length
+	}
+}
+
+// WithRequestLogging logs the body of requests up to maxBodySize bytes when the logger is at debug level, and leaves the values of the redacted headers out of the logged headers.
+// Without it bodies are not logged and the DefaultRedactedHeaders are redacted.
+func WithRequestLogging(logBodies bool, maxBodySize int, redactedHeaders []string) Option {
+	return func(s *Server) {
+		s.logBodies = logBodies
+		s.maxLoggedBody = maxBodySize
+		s.redactedHeaders = redactHeaders(redactedHeaders)
+	}
+}
+
+func redactHeaders(headers []string) map[string]bool {
+	redacted := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		redacted[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return redacted
+}
+
+// WithAPIKeys requires every request to present one of the keys, except requests for the bypass paths.
+// Without any keys the api is left open.
+func WithAPIKeys(keys []string, bypass ...string) Option {
+	return func(s *Server) {
+		s.apiKeys = make([][]byte, 0, len(keys))
+		for _, key := range keys {
+			if key != "" {
+				s.apiKeys = append(s.apiKeys, []byte(key))
+			}
+		}
+
+		s.bypass = make(map[string]bool, len(bypass))
+		for _, path := range bypass {
+			s.bypass[path] = true
+		}
+	}
+}
+
+// WithCORS allows browsers on the origins to call the api with the methods and headers. Without any origins only same origin requests are allowed, and an origin of * allows every origin.
+func WithCORS(origins, methods, headers []string) Option {
+	return func(s *Server) {
+		s.cors = corsOptions(origins, methods, headers)
+		s.origins = origins
+	}
+}
+
+func corsOptions(origins, methods, headers []string) []handlers.CORSOption {
+	opts := []handlers.CORSOption{
+		handlers.ExposedHeaders([]string{RequestIDHeader}),
+	}
+
+	if len(methods) > 0 {
+		opts = append(opts, handlers.AllowedMethods(methods))
+	}
+	if len(headers) > 0 {
+		opts = append(opts, handlers.AllowedHeaders(headers))
+	}
+
+	// the cors handler treats an empty origin list as allowing every origin, so an explicit validator is needed to refuse them all
+	if len(origins) == 0 {
+		return append(opts, handlers.AllowedOriginValidator(func(string) bool { return false }))
+	}
+
+	return append(opts, handlers.AllowedOrigins(origins))
+}
+
+// WithRateLimit limits each client to rate requests per second with bursts of up to burst requests, remembering at most maxClients clients.
+// Clients are told apart by their address, see WithTrustedProxies. Setting trustForwardedFor believes the X-Forwarded-For header of every peer, which is only safe when the server cannot be reached except through a proxy.
+func WithRateLimit(rate float64, burst, maxClients int, trustForwardedFor bool) Option {
+	return func(s *Server) {
+		s.limiter = ratelimit.New(rate, burst, maxClients)
+		s.trustForwardedFor = trustForwardedFor
+	}
+}
+
+// WithTrustedProxies believes the X-Forwarded-For and X-Real-IP headers of requests from peers within the prefixes, so clients behind a reverse proxy are told apart by their own address rather than the proxy's.
+// Requests from any other peer are identified by their connection's address, so a client cannot pick its address by sending the headers itself.
+func WithTrustedProxies(proxies ...netip.Prefix) Option {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// WithPoller lets a poll of every feed be triggered through the api, sharing p so a triggered poll never overlaps one of its ticks
+func WithPoller(p *poller.Poller) Option {
+	return func(s *Server) {
+		s.poller = p
+	}
+}
+
+// WithPruner lets pruning articles outside the retention be triggered through the api
+func WithPruner(p *pruner.Pruner) Option {
+	return func(s *Server) {
+		s.pruner = p
+	}
 }
 
-func New(service service.Service, logger *zap.Logger) Server {
-	return Server{
-		service: service,
-		logger:  logger,
+// WithRegistry registers the request metrics on reg and serves every metric registered on it
+func WithRegistry(reg *metrics.Registry) Option {
+	return func(s *Server) {
+		s.registry = reg
+	}
+}
+
+func New(service service.Service, logger *zap.Logger, opts ...Option) Server {
+	s := Server{
+		service:         service,
+		logger:          logger,
+		heartbeat:       DefaultHeartbeat,
+		pageSize:        storage.DefaultPageSize(),
+		cursors:         storage.NewCursorCodec(nil),
+		registry:        metrics.NewRegistry(),
+		excerptLength:   DefaultExcerptLength,
+		cors:            corsOptions(nil, nil, nil),
+		redactedHeaders: redactHeaders(DefaultRedactedHeaders),
+	}
+
+	for _, opt := range opts {
+		opt(&s)
 	}
+	s.metrics = newHTTPMetrics(s.registry)
+
+	return s
+}
+
+type UnreadCountsResponse struct {
+	Feeds map[string]int `json:"feeds"`
+	Total int            `json:"total"`
+}
+
+type MarkAllReadResponse struct {
+	Marked int `json:"marked"`
+}
+
+type RefreshFeedResponse struct {
+	Added    int                `json:"added"`
+	Articles []*storage.Article `json:"articles"`
+}
+
+// CreateFeedsResult is the outcome of creating one feed of a batch, with the status the link would have been given by POST /api/feeds
+type CreateFeedsResult struct {
+	Link   string        `json:"link"`
+	Status int           `json:"status"`
+	Feed   *storage.Feed `json:"feed,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type CreateFeedsResponse struct {
+	Results []CreateFeedsResult `json:"results"`
+}
+
+type FullTextResponse struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+type BackfillFeedResponse struct {
+	Updated int `json:"updated"`
+}
+
+// NewArticlesResponse is how many articles arrived since a cursor was issued
+type NewArticlesResponse struct {
+	Count int `json:"count"`
+}
+
+// PruneResponse is how many articles a prune removed
+type PruneResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// ReadNextResponse is the article marked read and the unread article to read next, which is null once every article is read
+type ReadNextResponse struct {
+	Article *storage.Article `json:"article"`
+	Next    *storage.Article `json:"next"`
+}
+
+type ImportOPMLResponse struct {
+	Feeds    []*storage.Feed `json:"feeds"`
+	Failures []string        `json:"failures"`
 }
 
 func writeResponse(w http.ResponseWriter, status int, body interface{}) error {
@@ -40,26 +289,239 @@ func writeResponse(w http.ResponseWriter, status int, body interface{}) error {
 	return nil
 }
 
-func (s Server) Serve(port int) {
+// writeConditionalResponse writes the body like writeResponse, tagged with an ETag of the body and the lastModified time when it is not zero.
+// A client whose If-None-Match holds the ETag, or whose If-Modified-Since is not before lastModified, already has the body and is sent 304 Not Modified without it.
+// If-Modified-Since is only checked when there is no If-None-Match, since a change that leaves lastModified alone still changes the ETag.
+func writeConditionalResponse(w http.ResponseWriter, r *http.Request, body interface{}, lastModified time.Time) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+	return nil
+}
+
+// notModified reports whether the request's conditional headers match the etag or lastModified time of the response
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			// a weak tag matches the same body, which is all this etag promises
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+
+	// http dates have no fraction of a second
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// lastAdded is when the most recently added of the articles was stored, or the zero time when there are none
+func lastAdded(articles []*storage.Article) time.Time {
+	var last int64
+	for _, a := range articles {
+		if a.Timestamp > last {
+			last = a.Timestamp
+		}
+	}
+	if last == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(last, 0)
+}
+
+// addExcerpts sets each article's excerpt from its description
+func (s Server) addExcerpts(articles []*storage.Article) {
+	for _, a := range articles {
+		a.Excerpt = sanitize.Excerpt(a.Description, s.excerptLength)
+	}
+}
+
+// plainText replaces the article descriptions with their text when the request has plain=true, for clients that do not render html
+func plainText(r *http.Request, articles ...*storage.Article) {
+	plain, _ := strconv.ParseBool(r.URL.Query().Get("plain"))
+	if !plain {
+		return
+	}
+
+	for _, a := range articles {
+		a.Description = sanitize.Text(a.Description)
+	}
+}
+
+// parseTimeParam parses an RFC3339 time or unix timestamp from a query parameter. An empty value is the zero time.
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return time.Unix(unix, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// articleFilter reads the status, feeds, from, and to query parameters, along with tag, that narrow the articles listed or counted.
+// The error names the invalid parameter for the client.
+func articleFilter(query url.Values) (storage.ArticleFilter, error) {
+	filter := storage.ArticleFilter{
+		Status: storage.ParseStatus(query.Get("status")),
+		Tag:    query.Get("tag"),
+	}
+	// filter=favorited predates the status parameter
+	if query.Get("status") == "" && query.Get("filter") == "favorited" {
+		filter.Status = storage.StatusFavorited
+	}
+
+	if query.Has("feeds") {
+		filter.FeedIDs = strings.Split(query.Get("feeds"), ",")
+	}
+
+	var err error
+	filter.From, err = parseTimeParam(query.Get("from"))
+	if err != nil {
+		return filter, errors.New("invalid from date")
+	}
+	filter.To, err = parseTimeParam(query.Get("to"))
+	if err != nil {
+		return filter, errors.New("invalid to date")
+	}
+
+	return filter, nil
+}
+
+// localizedPublishedLayout includes the time and offset, since outside UTC the day alone can be wrong
+const localizedPublishedLayout = "Mon, 02 Jan 2006 15:04:05 -0700"
+
+// localizePublished formats the articles' published dates in the IANA timezone named by the request's tz parameter, e.g. America/Chicago.
+// An unknown timezone falls back to UTC rather than failing the request.
+func localizePublished(r *http.Request, articles ...*storage.Article) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return
+	}
+
+	loc, err := time.LoadLocation(tz)
+	// Local would format dates in whatever timezone the server runs in
+	if err != nil || loc == time.Local {
+		LoggerFromContext(r.Context()).Info("unknown timezone, using UTC", zap.String("tz", tz))
+		loc = time.UTC
+	}
+
+	for _, a := range articles {
+		a.Published = time.Unix(a.PublishedUnix, 0).In(loc).Format(localizedPublishedLayout)
+	}
+}
+
+// Router registers every route with its middleware
+func (s Server) Router() *mux.Router {
 	rtr := mux.NewRouter()
 	rtr.Use(s.LogMiddleware())
+	rtr.Use(s.MetricsMiddleware())
+
+	// probes are registered ahead of the api so they match without an api key
+	rtr.HandleFunc("/healthz", s.Healthz()).Methods(http.MethodGet)
+	rtr.HandleFunc("/readyz", s.Readyz()).Methods(http.MethodGet)
+
+	api := rtr.NewRoute().Subrouter()
+	api.Use(s.RateLimitMiddleware())
+	api.Use(s.AuthMiddleware())
+
+	api.Handle("/metrics", s.registry.Handler()).Methods(http.MethodGet)
 
-	rtr.HandleFunc("/api/feeds", s.CreateFeed()).Methods(http.MethodPost)
-	rtr.HandleFunc("/api/feeds", s.OptionsMiddleware(s.ListFeeds())).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds", s.CreateFeed()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds", s.OptionsMiddleware(s.ListFeeds())).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds/batch", s.CreateFeeds()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds/import", s.ImportOPML()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds/export", s.ExportOPML()).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds/discover", s.DiscoverFeeds()).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds/unread-counts", s.UnreadCounts()).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds/health", s.FeedHealth()).Methods(http.MethodGet)
+	api.HandleFunc("/api/stats", s.Stats()).Methods(http.MethodGet)
+	api.HandleFunc("/api/poll", s.Poll()).Methods(http.MethodPost)
+	api.HandleFunc("/api/prune", s.Prune()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds/{id}", s.GetFeed()).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds/{id}", s.UpdateFeedTitle()).Methods(http.MethodPatch)
+	api.HandleFunc("/api/feeds/{id}", s.DeleteFeed()).Methods(http.MethodDelete)
+	api.HandleFunc("/api/feeds/{id}/articles", s.OptionsMiddleware(s.ListFeedArticles())).Methods(http.MethodGet)
+	api.HandleFunc("/api/feeds/{id}/read", s.MarkAllRead()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds/{id}/refresh", s.RefreshFeed()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds/{id}/backfill", s.BackfillFeed()).Methods(http.MethodPost)
+	api.HandleFunc("/api/feeds/{id}/folder", s.AssignFeedToFolder()).Methods(http.MethodPut)
 
-	rtr.HandleFunc("/api/articles", s.CreateArticle()).Methods(http.MethodPost)
-	rtr.HandleFunc("/api/articles", s.OptionsMiddleware(s.ListArticles())).Methods(http.MethodGet)
-	rtr.HandleFunc("/api/articles/read", s.OptionsMiddleware(s.ListReadArticles())).Methods(http.MethodPost)
-	rtr.HandleFunc("/api/articles/unread", s.OptionsMiddleware(s.ListUnreadArticles())).Methods(http.MethodPost)
-	rtr.HandleFunc("/api/articles/favorited", s.OptionsMiddleware(s.ListFavoritedArticles())).Methods(http.MethodPost)
+	api.HandleFunc("/graphql", s.GraphQL()).Methods(http.MethodGet, http.MethodPost)
+
+	api.HandleFunc("/api/folders", s.CreateFolder()).Methods(http.MethodPost)
+	api.HandleFunc("/api/folders", s.ListFolders()).Methods(http.MethodGet)
+	api.HandleFunc("/api/folders/{id}", s.GetFolder()).Methods(http.MethodGet)
+	api.HandleFunc("/api/folders/{id}", s.RenameFolder()).Methods(http.MethodPatch)
+	api.HandleFunc("/api/folders/{id}", s.DeleteFolder()).Methods(http.MethodDelete)
+	api.HandleFunc("/api/folders/{id}/feeds", s.OptionsMiddleware(s.ListFolderFeeds())).Methods(http.MethodGet)
+
+	api.HandleFunc("/api/articles", s.CreateArticle()).Methods(http.MethodPost)
+	api.HandleFunc("/api/articles", s.OptionsMiddleware(s.ListArticles())).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/read", s.OptionsMiddleware(s.ListReadArticles())).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/read", s.MarkAllRead()).Methods(http.MethodPost)
+	api.HandleFunc("/api/articles/unread", s.OptionsMiddleware(s.ListUnreadArticles())).Methods(http.MethodGet, http.MethodPost)
+	api.HandleFunc("/api/articles/favorited", s.OptionsMiddleware(s.ListFavoritedArticles())).Methods(http.MethodGet, http.MethodPost)
+	api.HandleFunc("/api/articles/search", s.OptionsMiddleware(s.SearchArticles())).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/stream", s.StreamArticles()).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/new-count", s.OptionsMiddleware(s.CountNewArticles())).Methods(http.MethodGet)
+	api.HandleFunc("/api/ws", s.Websocket()).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/{id}", s.GetArticle()).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/{id}", s.DeleteArticle()).Methods(http.MethodDelete)
+	api.HandleFunc("/api/articles/{id}/fulltext", s.FullText()).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/{id}/feeds", s.ArticleFeeds()).Methods(http.MethodGet)
+	api.HandleFunc("/api/articles/{id}/read", s.MarkArticleRead()).Methods(http.MethodPatch)
+	api.HandleFunc("/api/articles/{id}/read-next", s.OptionsMiddleware(s.ReadNext())).Methods(http.MethodPost)
+	api.HandleFunc("/api/articles/{id}/favorite", s.SetArticleFavorited()).Methods(http.MethodPatch)
+	api.HandleFunc("/api/articles/{id}/saved", s.SetArticleSaved()).Methods(http.MethodPatch)
+
+	return rtr
+}
+
+// Handler returns the router wrapped in the cors policy
+func (s Server) Handler() http.Handler {
+	return handlers.CORS(s.cors...)(s.Router())
+}
+
+// Serve serves the api on port until ctx is canceled, then shuts the server down
+func (s Server) Serve(ctx context.Context, port int) {
+	// request contexts are canceled on shutdown so long lived event streams end instead of holding the shutdown open
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
 
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: handlers.CORS()(rtr),
+		Addr:        fmt.Sprintf(":%d", port),
+		Handler:     s.Handler(),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
-
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	srv.RegisterOnShutdown(cancelBase)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -68,19 +530,42 @@ func (s Server) Serve(port int) {
 	}()
 
 	s.logger.Info("serving", zap.Int("port", port))
-	<-done
+	<-ctx.Done()
 
 	s.logger.Info("stopping server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer func() {
-		cancel()
-	}()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := srv.Shutdown(shutdownCtx); err != nil {
 		s.logger.Fatal("server shutdown failed", zap.Error(err))
 	}
 }
 
+// Healthz reports the server is alive
+func (s Server) Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/plain")
+		w.Write([]byte("ok"))
+	}
+}
+
+// Readyz reports whether the server can serve requests, which needs the storage backend to be reachable
+func (s Server) Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		err := s.service.Ping(r.Context())
+		if err != nil {
+			l.Error("storage is unreachable", zap.Error(err))
+			http.Error(w, "storage is unreachable", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("content-type", "text/plain")
+		w.Write([]byte("ok"))
+	}
+}
+
 func (s Server) CreateFeed() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		l := LoggerFromContext(r.Context())
@@ -101,9 +586,17 @@ func (s Server) CreateFeed() http.HandlerFunc {
 		}
 
 		feed, err := s.service.CreateFeed(r.Context(), request)
+		if errors.Is(err, storage.ErrDuplicateFeed) {
+			// the existing feed is returned so the client can find the subscription it already has
+			writeResponse(w, http.StatusConflict, feed)
+			return
+		}
 		if err != nil {
-			l.Error("failed to create feed", zap.Error(err), zap.Any("request", request))
-			http.Error(w, "failed to create feed", http.StatusBadRequest)
+			status, msg := createFeedError(err)
+			if status >= http.StatusInternalServerError {
+				l.Error("failed to create feed", zap.Error(err), zap.Any("request", request))
+			}
+			http.Error(w, msg, status)
 			return
 		}
 
@@ -111,11 +604,72 @@ func (s Server) CreateFeed() http.HandlerFunc {
 	}
 }
 
+// createFeedError maps an error from creating a feed to the status and message returned to the client
+func createFeedError(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidURL):
+		return http.StatusBadRequest, "feed link must be an absolute http or https url"
+	case errors.Is(err, service.ErrFeedUnreachable):
+		return http.StatusBadGateway, "feed could not be fetched"
+	case errors.Is(err, service.ErrEmptyFeed):
+		return http.StatusUnprocessableEntity, "feed link did not return a feed"
+	case errors.Is(err, storage.ErrDuplicateFeed):
+		return http.StatusConflict, "feed already exists"
+	default:
+		return http.StatusInternalServerError, "failed to create feed"
+	}
+}
+
+// CreateFeeds creates a feed for every url in a json array, reporting each url's outcome rather than failing the batch on one bad url
+func (s Server) CreateFeeds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		var urls []string
+		err := json.NewDecoder(r.Body).Decode(&urls)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err))
+			http.Error(w, "request body must be a json array of feed urls", http.StatusBadRequest)
+			return
+		}
+		if len(urls) == 0 {
+			http.Error(w, "no feed urls to create", http.StatusBadRequest)
+			return
+		}
+		if len(urls) > maxBatchFeeds {
+			http.Error(w, fmt.Sprintf("at most %d feeds can be created at once", maxBatchFeeds), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		response := CreateFeedsResponse{Results: make([]CreateFeedsResult, 0, len(urls))}
+		for _, result := range s.service.CreateFeeds(r.Context(), urls) {
+			res := CreateFeedsResult{Link: result.Link, Status: http.StatusCreated, Feed: result.Feed}
+			if result.Err != nil {
+				res.Status, res.Error = createFeedError(result.Err)
+				if res.Status >= http.StatusInternalServerError {
+					l.Error("failed to create feed", zap.Error(result.Err), zap.String("link", result.Link))
+				}
+			}
+			response.Results = append(response.Results, res)
+		}
+
+		writeResponse(w, http.StatusOK, response)
+	}
+}
+
+// ListFeeds lists every feed, or only the feeds in the folder query parameter when it is present. An empty folder lists the uncategorized feeds.
 func (s Server) ListFeeds() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		opts := OptionsFromContext(r.Context())
 		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
-		feeds, err := s.service.ListFeeds(r.Context(), opts)
+
+		var feeds storage.FeedList
+		var err error
+		if query := r.URL.Query(); query.Has("folder") {
+			feeds, err = s.service.ListFeedsByFolder(r.Context(), query.Get("folder"), opts)
+		} else {
+			feeds, err = s.service.ListFeeds(r.Context(), opts)
+		}
 		if err != nil {
 			l.Error("failed to list feeds", zap.Error(err))
 			http.Error(w, "failed to list feeds", http.StatusInternalServerError)
@@ -126,92 +680,1005 @@ func (s Server) ListFeeds() http.HandlerFunc {
 	}
 }
 
-func (s Server) CreateArticle() http.HandlerFunc {
+func (s Server) ListFeedArticles() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		l := LoggerFromContext(r.Context())
-
-		b, err := io.ReadAll(r.Body)
+		id := mux.Vars(r)["id"]
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts), zap.String("id", id))
+		articles, err := s.service.ListFeedArticles(r.Context(), id, opts)
 		if err != nil {
-			l.Error("failed to parse request body")
-			http.Error(w, "invalid request body", http.StatusBadRequest)
+			l.Error("failed to list feed articles", zap.Error(err))
+			http.Error(w, "failed to list articles", http.StatusInternalServerError)
 			return
 		}
 
-		var request service.CreateArticleRequest
-		err = json.Unmarshal(b, &request)
-		if err != nil {
-			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
-			http.Error(w, "invalid request body", http.StatusBadRequest)
-			return
-		}
+		s.addExcerpts(articles.Articles)
+		plainText(r, articles.Articles...)
+		localizePublished(r, articles.Articles...)
+		writeResponse(w, http.StatusOK, articles)
+	}
+}
 
-		article, err := s.service.CreateArticle(r.Context(), request)
+func (s Server) UnreadCounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		counts, total, err := s.service.UnreadCounts(r.Context())
 		if err != nil {
-			l.Error("failed to create article", zap.Error(err), zap.Any("request", request))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			l.Error("failed to count unread articles", zap.Error(err))
+			http.Error(w, "failed to count unread articles", http.StatusInternalServerError)
 			return
 		}
 
-		writeResponse(w, http.StatusCreated, article)
+		writeResponse(w, http.StatusOK, UnreadCountsResponse{Feeds: counts, Total: total})
 	}
 }
 
-func (s Server) ListArticles() http.HandlerFunc {
+// FeedHealth lists the feeds whose last refresh failed along with why
+func (s Server) FeedHealth() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		opts := OptionsFromContext(r.Context())
-		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
-		articles, err := s.service.ListArticles(r.Context(), opts)
+		l := LoggerFromContext(r.Context())
+
+		feeds, err := s.service.ListFailingFeeds(r.Context())
 		if err != nil {
-			l.Error("failed to list articles", zap.Error(err))
-			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			l.Error("failed to list failing feeds", zap.Error(err))
+			http.Error(w, "failed to list failing feeds", http.StatusInternalServerError)
 			return
 		}
 
-		writeResponse(w, http.StatusOK, articles)
+		writeResponse(w, http.StatusOK, feeds)
 	}
 }
 
-func (s Server) ListReadArticles() http.HandlerFunc {
+func (s Server) Stats() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		opts := OptionsFromContext(r.Context())
-		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
-		articles, err := s.service.ListReadArticles(r.Context(), opts)
+		l := LoggerFromContext(r.Context())
+
+		stats, err := s.service.Stats(r.Context())
 		if err != nil {
-			l.Error("failed to list articles", zap.Error(err))
-			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			l.Error("failed to get stats", zap.Error(err))
+			http.Error(w, "failed to get stats", http.StatusInternalServerError)
 			return
 		}
 
-		writeResponse(w, http.StatusOK, articles)
+		writeResponse(w, http.StatusOK, stats)
 	}
 }
 
-func (s Server) ListUnreadArticles() http.HandlerFunc {
+func (s Server) GetFeed() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		opts := OptionsFromContext(r.Context())
-		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
-		articles, err := s.service.ListUnreadArticles(r.Context(), opts)
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		feed, err := s.service.GetFeed(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "feed not found", http.StatusNotFound)
+			return
+		}
 		if err != nil {
-			l.Error("failed to list articles", zap.Error(err))
-			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			l.Error("failed to get feed", zap.Error(err))
+			http.Error(w, "failed to get feed", http.StatusInternalServerError)
 			return
 		}
 
-		writeResponse(w, http.StatusOK, articles)
+		writeResponse(w, http.StatusOK, feed)
 	}
 }
 
-func (s Server) ListFavoritedArticles() http.HandlerFunc {
+func (s Server) DeleteFeed() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		opts := OptionsFromContext(r.Context())
-		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
-		articles, err := s.service.ListFavoritedArticles(r.Context(), opts)
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		err := s.service.DeleteFeed(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "feed not found", http.StatusNotFound)
+			return
+		}
 		if err != nil {
-			l.Error("failed to list articles", zap.Error(err))
-			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			l.Error("failed to delete feed", zap.Error(err))
+			http.Error(w, "failed to delete feed", http.StatusInternalServerError)
 			return
 		}
 
-		writeResponse(w, http.StatusOK, articles)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RefreshFeed fetches the feed in the path now instead of waiting for the poller and responds with the articles it added
+func (s Server) RefreshFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		articles, err := s.service.RefreshFeedByID(r.Context(), id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "feed not found", http.StatusNotFound)
+			return
+		case errors.Is(err, service.ErrFeedUnreachable):
+			l.Error("failed to fetch feed", zap.Error(err))
+			http.Error(w, "feed could not be fetched", http.StatusBadGateway)
+			return
+		case err != nil:
+			l.Error("failed to refresh feed", zap.Error(err))
+			http.Error(w, "failed to refresh feed", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, RefreshFeedResponse{Added: len(articles), Articles: articles})
+	}
+}
+
+// BackfillFeed refetches the feed to fill in fields of its stored articles, responding with how many were updated
+func (s Server) BackfillFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		updated, err := s.service.BackfillFeed(r.Context(), id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "feed not found", http.StatusNotFound)
+			return
+		case errors.Is(err, service.ErrFeedUnreachable):
+			l.Error("failed to fetch feed", zap.Error(err))
+			http.Error(w, "feed could not be fetched", http.StatusBadGateway)
+			return
+		case err != nil:
+			l.Error("failed to backfill feed", zap.Error(err), zap.Int("updated", updated))
+			http.Error(w, "failed to backfill feed", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, BackfillFeedResponse{Updated: updated})
+	}
+}
+
+// Poll refreshes every feed now, doing the work of one poller tick, and responds with how each feed went
+func (s Server) Poll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		if s.poller == nil {
+			http.Error(w, "polling is not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		summary, err := s.poller.RunOnce(r.Context())
+		switch {
+		case errors.Is(err, poller.ErrRunning):
+			http.Error(w, "a poll is already running", http.StatusConflict)
+			return
+		case err != nil:
+			l.Error("failed to poll feeds", zap.Error(err))
+			http.Error(w, "failed to poll feeds", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, summary)
+	}
+}
+
+// Prune removes the read articles outside the retention now, doing the work of one pruner tick, and responds with how many were removed
+func (s Server) Prune() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		if s.pruner == nil {
+			http.Error(w, "pruning is not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		deleted, err := s.pruner.RunOnce(r.Context())
+		if err != nil {
+			l.Error("failed to prune articles", zap.Error(err))
+			http.Error(w, "failed to prune articles", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, PruneResponse{Deleted: deleted})
+	}
+}
+
+// UpdateFeedTitle renames the feed, an empty title goes back to the title from the feed itself
+func (s Server) UpdateFeedTitle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.UpdateFeedTitleRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		feed, err := s.service.UpdateFeedTitle(r.Context(), id, request)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "feed not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to update feed title", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to update feed title", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, feed)
+	}
+}
+
+func (s Server) AssignFeedToFolder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.AssignFolderRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		feed, err := s.service.AssignFeedToFolder(r.Context(), id, request)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "feed or folder not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to assign feed to folder", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to assign feed to folder", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, feed)
+	}
+}
+
+func (s Server) CreateFolder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.FolderRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		folder, err := s.service.CreateFolder(r.Context(), request)
+		switch {
+		case errors.Is(err, service.ErrEmptyFolderName):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, storage.ErrDuplicateFolder):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			l.Error("failed to create folder", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to create folder", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusCreated, folder)
+	}
+}
+
+func (s Server) ListFolders() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		folders, err := s.service.ListFolders(r.Context())
+		if err != nil {
+			l.Error("failed to list folders", zap.Error(err))
+			http.Error(w, "failed to list folders", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, folders)
+	}
+}
+
+func (s Server) GetFolder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		folder, err := s.service.GetFolder(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "folder not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to get folder", zap.Error(err))
+			http.Error(w, "failed to get folder", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, folder)
+	}
+}
+
+func (s Server) RenameFolder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.FolderRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		folder, err := s.service.RenameFolder(r.Context(), id, request)
+		switch {
+		case errors.Is(err, service.ErrEmptyFolderName):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "folder not found", http.StatusNotFound)
+			return
+		case errors.Is(err, storage.ErrDuplicateFolder):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			l.Error("failed to rename folder", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to rename folder", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, folder)
+	}
+}
+
+func (s Server) DeleteFolder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		err := s.service.DeleteFolder(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "folder not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to delete folder", zap.Error(err))
+			http.Error(w, "failed to delete folder", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s Server) ListFolderFeeds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts), zap.String("id", id))
+
+		feeds, err := s.service.ListFeedsByFolder(r.Context(), id, opts)
+		if err != nil {
+			l.Error("failed to list folder feeds", zap.Error(err))
+			http.Error(w, "failed to list feeds", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, feeds)
+	}
+}
+
+func (s Server) DiscoverFeeds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		siteURL := r.URL.Query().Get("url")
+		l := LoggerFromContext(r.Context(), zap.String("url", siteURL))
+
+		links, err := s.service.DiscoverFeeds(r.Context(), siteURL)
+		if errors.Is(err, service.ErrInvalidURL) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			l.Error("failed to discover feeds", zap.Error(err))
+			http.Error(w, "failed to discover feeds", http.StatusBadGateway)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, links)
+	}
+}
+
+func (s Server) ImportOPML() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		feeds, errs := s.service.ImportOPML(r.Context(), r.Body)
+		if len(errs) == 1 && errors.Is(errs[0], service.ErrInvalidOPML) {
+			l.Error("failed to parse opml", zap.Error(errs[0]))
+			http.Error(w, "invalid opml document", http.StatusBadRequest)
+			return
+		}
+
+		response := ImportOPMLResponse{
+			Feeds:    feeds,
+			Failures: make([]string, 0),
+		}
+		for _, err := range errs {
+			l.Error("failed to import feed", zap.Error(err))
+			response.Failures = append(response.Failures, err.Error())
+		}
+
+		writeResponse(w, http.StatusOK, response)
+	}
+}
+
+func (s Server) ExportOPML() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		b, err := s.service.ExportOPML(r.Context())
+		if err != nil {
+			l.Error("failed to export opml", zap.Error(err))
+			http.Error(w, "failed to export feeds", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("content-type", "text/x-opml")
+		w.Header().Set("content-disposition", `attachment; filename="feedreader.opml"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	}
+}
+
+func (s Server) CreateArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.CreateArticleRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		article, err := s.service.CreateArticle(r.Context(), request)
+		if errors.Is(err, storage.ErrDuplicateArticle) {
+			http.Error(w, "article already exists", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, storage.ErrArticleDeleted) {
+			http.Error(w, "article was deleted", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, storage.ErrFeedMissing) {
+			http.Error(w, "no feed exists for the article link", http.StatusUnprocessableEntity)
+			return
+		}
+		if err != nil {
+			l.Error("failed to create article", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to create article", http.StatusBadRequest)
+			return
+		}
+
+		writeResponse(w, http.StatusCreated, article)
+	}
+}
+
+func (s Server) GetArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		article, err := s.service.GetArticle(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to get article", zap.Error(err))
+			http.Error(w, "failed to get article", http.StatusInternalServerError)
+			return
+		}
+
+		plainText(r, article)
+		localizePublished(r, article)
+		writeResponse(w, http.StatusOK, article)
+	}
+}
+
+// ArticleFeeds lists the feeds carrying the article, the feed it is stored under first followed by the feeds whose copies of it were skipped as duplicates
+func (s Server) ArticleFeeds() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		feeds, err := s.service.ListArticleFeeds(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to list article feeds", zap.Error(err))
+			http.Error(w, "failed to list article feeds", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, feeds)
+	}
+}
+
+// FullText returns the sanitized content of the page the article links to, for feeds that only publish a summary
+func (s Server) FullText() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		content, err := s.service.FullText(r.Context(), id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		case errors.Is(err, service.ErrArticleUnreachable):
+			l.Error("failed to fetch article", zap.Error(err))
+			http.Error(w, "article could not be fetched", http.StatusBadGateway)
+			return
+		case errors.Is(err, service.ErrNoContent):
+			http.Error(w, "article page has no readable content", http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			l.Error("failed to get article full text", zap.Error(err))
+			http.Error(w, "failed to get article full text", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, FullTextResponse{ID: id, Content: content})
+	}
+}
+
+func (s Server) DeleteArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		err := s.service.DeleteArticle(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to delete article", zap.Error(err))
+			http.Error(w, "failed to delete article", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListArticles lists the articles with the status query parameter, which is one of all, read, unread, favorited, or saved and defaults to all.
+// The feeds query parameter narrows the list to the articles of a comma separated list of feed ids, such as the feeds of a folder,
+// from and to narrow it to a range of published dates, and tag to the articles with a tag. Articles have to match every parameter given.
+func (s Server) ListArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
+
+		filter, err := articleFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var articles storage.ArticleList
+		if filter.FeedIDs == nil && filter.From.IsZero() && filter.To.IsZero() && filter.Tag == "" {
+			articles, err = s.service.ListArticlesByStatus(r.Context(), filter.Status, opts)
+		} else {
+			articles, err = s.service.ListFilteredArticles(r.Context(), filter, opts)
+		}
+		if errors.Is(err, storage.ErrInvalidRange) {
+			http.Error(w, "from must not be after to", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidFeedID) {
+			http.Error(w, "feeds must be a comma separated list of feed ids", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			l.Error("failed to list articles", zap.Error(err))
+			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			return
+		}
+
+		s.addExcerpts(articles.Articles)
+		plainText(r, articles.Articles...)
+		localizePublished(r, articles.Articles...)
+		// clients polling the list only download it again when it changed
+		writeConditionalResponse(w, r, articles, lastAdded(articles.Articles))
+	}
+}
+
+// CountNewArticles responds with how many articles have come ahead of the cursor query parameter, sorted and ordered by the sort and order parameters.
+// The articles are filtered by the same status, feeds, from, to, and tag parameters as ListArticles.
+// Given the head cursor of the first page a client listed, this is how many articles arrived since, without listing them again.
+func (s Server) CountNewArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
+
+		if opts.Cursor == "" {
+			http.Error(w, "cursor is required", http.StatusBadRequest)
+			return
+		}
+
+		filter, err := articleFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		count, err := s.service.CountArticlesSince(r.Context(), filter, opts)
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidRange) {
+			http.Error(w, "from must not be after to", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidFeedID) {
+			http.Error(w, "feeds must be a comma separated list of feed ids", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			l.Error("failed to count new articles", zap.Error(err))
+			http.Error(w, "failed to count new articles", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, NewArticlesResponse{Count: count})
+	}
+}
+
+func (s Server) ListReadArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
+		articles, err := s.service.ListReadArticles(r.Context(), opts)
+		if err != nil {
+			l.Error("failed to list articles", zap.Error(err))
+			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			return
+		}
+
+		s.addExcerpts(articles.Articles)
+		plainText(r, articles.Articles...)
+		localizePublished(r, articles.Articles...)
+		writeResponse(w, http.StatusOK, articles)
+	}
+}
+
+func (s Server) ListUnreadArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
+		articles, err := s.service.ListUnreadArticles(r.Context(), opts)
+		if err != nil {
+			l.Error("failed to list articles", zap.Error(err))
+			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			return
+		}
+
+		s.addExcerpts(articles.Articles)
+		plainText(r, articles.Articles...)
+		localizePublished(r, articles.Articles...)
+		writeResponse(w, http.StatusOK, articles)
+	}
+}
+
+func (s Server) ListFavoritedArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts))
+		articles, err := s.service.ListFavoritedArticles(r.Context(), opts)
+		if err != nil {
+			l.Error("failed to list articles", zap.Error(err))
+			http.Error(w, "failed to list articles", http.StatusInternalServerError)
+			return
+		}
+
+		s.addExcerpts(articles.Articles)
+		plainText(r, articles.Articles...)
+		localizePublished(r, articles.Articles...)
+		writeResponse(w, http.StatusOK, articles)
+	}
+}
+
+func (s Server) SearchArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts := OptionsFromContext(r.Context())
+		query := r.URL.Query().Get("q")
+		l := LoggerFromContext(r.Context(), zap.Any("options", opts), zap.String("query", query))
+		articles, err := s.service.SearchArticles(r.Context(), query, opts)
+		if err != nil {
+			l.Error("failed to search articles", zap.Error(err))
+			http.Error(w, "failed to search articles", http.StatusInternalServerError)
+			return
+		}
+
+		s.addExcerpts(articles.Articles)
+		plainText(r, articles.Articles...)
+		localizePublished(r, articles.Articles...)
+		writeResponse(w, http.StatusOK, articles)
+	}
+}
+
+// MarkAllRead marks the unread articles of the feed in the path as read, or every unread article when there is no feed in the path
+func (s Server) MarkAllRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		n, err := s.service.MarkAllRead(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "feed not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to mark articles read", zap.Error(err))
+			http.Error(w, "failed to mark articles read", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, MarkAllReadResponse{Marked: n})
+	}
+}
+
+func (s Server) MarkArticleRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.MarkArticleReadRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		article, err := s.service.MarkArticleRead(r.Context(), id, request)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to mark article read", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to mark article read", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, article)
+	}
+}
+
+// ReadNext marks the article in the path read and responds with the next unread article in the requested sort order, so a reader can advance in one request
+func (s Server) ReadNext() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		opts := OptionsFromContext(r.Context())
+		l := LoggerFromContext(r.Context(), zap.String("id", id), zap.Any("options", opts))
+
+		article, next, err := s.service.ReadNext(r.Context(), id, opts)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to read next article", zap.Error(err))
+			http.Error(w, "failed to read next article", http.StatusInternalServerError)
+			return
+		}
+
+		articles := []*storage.Article{article}
+		if next != nil {
+			articles = append(articles, next)
+		}
+		plainText(r, articles...)
+		localizePublished(r, articles...)
+		writeResponse(w, http.StatusOK, ReadNextResponse{Article: article, Next: next})
+	}
+}
+
+func (s Server) SetArticleFavorited() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.SetArticleFavoritedRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		article, err := s.service.SetArticleFavorited(r.Context(), id, request)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to set article favorited", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to set article favorited", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, article)
+	}
+}
+
+func (s Server) SetArticleSaved() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		l := LoggerFromContext(r.Context(), zap.String("id", id))
+
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			l.Error("failed to parse request body")
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var request service.SetArticleSavedRequest
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			l.Error("failed to unmarshal request body", zap.Error(err), zap.ByteString("body", b))
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		article, err := s.service.SetArticleSaved(r.Context(), id, request)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "article not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Error("failed to set article saved", zap.Error(err), zap.Any("request", request))
+			http.Error(w, "failed to set article saved", http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, article)
+	}
+}
+
+// StreamArticles pushes each newly stored article to the client as a server-sent event until the client disconnects
+func (s Server) StreamArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			l.Error("response writer does not support flushing")
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		published, unsubscribe := s.service.SubscribeEvents()
+		defer unsubscribe()
+
+		w.Header().Set("content-type", "text/event-stream")
+		w.Header().Set("cache-control", "no-cache")
+		w.Header().Set("connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(s.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			case e, ok := <-published:
+				if !ok {
+					return
+				}
+				if e.Type != events.ArticleCreated {
+					continue
+				}
+
+				article := e.Article
+				b, err := json.Marshal(article)
+				if err != nil {
+					l.Error("failed to marshal article", zap.Error(err))
+					continue
+				}
+
+				fmt.Fprintf(w, "event: article\nid: %s\ndata: %s\n\n", article.ID, b)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// Websocket upgrades the request to a websocket and sends each newly stored article to the client until either side closes it.
+// Clients can limit the articles to some feeds by sending a subscribe message, see wsMessage.
+// Browsers may only connect from the server's own origin or one of the origins given to WithCORS.
+func (s Server) Websocket() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := LoggerFromContext(r.Context())
+
+		conn, err := websocket.Upgrade(w, r, s.origins...)
+		if err != nil {
+			l.Info("failed to upgrade websocket", zap.Error(err))
+			return
+		}
+
+		published, unsubscribe := s.service.SubscribeEvents()
+		defer unsubscribe()
+
+		client := newWSClient(conn, s.heartbeat, l)
+		go client.readPump()
+		client.writePump(r.Context(), published)
 	}
 }
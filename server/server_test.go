@@ -0,0 +1,2057 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/kdwils/feedreader/pkg/metrics"
+	"github.com/kdwils/feedreader/pkg/parser"
+	parserMocks "github.com/kdwils/feedreader/pkg/parser/mocks"
+	"github.com/kdwils/feedreader/pkg/websocket"
+	"github.com/kdwils/feedreader/poller"
+	"github.com/kdwils/feedreader/pruner"
+	"github.com/kdwils/feedreader/service"
+	serviceMocks "github.com/kdwils/feedreader/service/mocks"
+	"github.com/kdwils/feedreader/storage"
+	storageMocks "github.com/kdwils/feedreader/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestServer(t *testing.T) (Server, *storageMocks.MockStorage, *parserMocks.MockParser) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	return New(service.New(store, p), zap.NewNop()), store, p
+}
+
+func newMockServiceServer(t *testing.T) (Server, *serviceMocks.MockService) {
+	t.Helper()
+
+	svc := serviceMocks.NewMockService(gomock.NewController(t))
+	return New(svc, zap.NewNop()), svc
+}
+
+// fakeService is an in-memory service for the handlers that only read feeds, any other call panics on the nil embedded Service
+type fakeService struct {
+	service.Service
+	feeds []*storage.Feed
+	down  bool
+}
+
+func (f fakeService) Ping(ctx context.Context) error {
+	if f.down {
+		return errors.New("database is closed")
+	}
+	return nil
+}
+
+func (f fakeService) ListFeeds(ctx context.Context, opts *storage.Options) (storage.FeedList, error) {
+	return storage.FeedList{Feeds: f.feeds}, nil
+}
+
+func TestServer_FakeService(t *testing.T) {
+	feeds := []*storage.Feed{{ID: "1", Title: "first"}, {ID: "2", Title: "second"}}
+
+	tests := []struct {
+		name       string
+		svc        fakeService
+		path       string
+		wantStatus int
+		wantFeeds  []*storage.Feed
+	}{
+		{name: "ready", path: "/readyz", wantStatus: http.StatusOK},
+		{name: "not ready", svc: fakeService{down: true}, path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{name: "list feeds", svc: fakeService{feeds: feeds}, path: "/api/feeds", wantStatus: http.StatusOK, wantFeeds: feeds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.svc, zap.NewNop())
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantFeeds != nil {
+				var got storage.FeedList
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.wantFeeds, got.Feeds)
+			}
+		})
+	}
+}
+
+func TestServer_ReadNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		article    *storage.Article
+		next       *storage.Article
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "next unread",
+			article:    &storage.Article{ID: "1", Read: true},
+			next:       &storage.Article{ID: "2"},
+			wantStatus: http.StatusOK,
+			wantBody:   `"next":{"id":"2"`,
+		},
+		{
+			name:       "last unread",
+			article:    &storage.Article{ID: "1", Read: true},
+			wantStatus: http.StatusOK,
+			wantBody:   `"next":null`,
+		},
+		{name: "not found", err: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "failure", err: errors.New("disk I/O error"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().ReadNext(gomock.Any(), "1", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, opts *storage.Options) (*storage.Article, *storage.Article, error) {
+				assert.Equal(t, storage.Ascending, opts.Order, "the sort order of the request is used")
+				return tt.article, tt.next, tt.err
+			})
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/articles/1/read-next?order=ascending", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestServer_BackfillFeed(t *testing.T) {
+	tests := []struct {
+		name       string
+		updated    int
+		err        error
+		wantStatus int
+	}{
+		{name: "backfilled", updated: 3, wantStatus: http.StatusOK},
+		{name: "not found", err: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "unreachable", err: fmt.Errorf("%w: connection refused", service.ErrFeedUnreachable), wantStatus: http.StatusBadGateway},
+		{name: "partial failure", updated: 1, err: errors.New("https://example.com/b: disk full"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().BackfillFeed(gomock.Any(), "1").Return(tt.updated, tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/feeds/1/backfill", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got BackfillFeedResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, BackfillFeedResponse{Updated: tt.updated}, got)
+			}
+		})
+	}
+}
+
+func TestServer_FeedHealth(t *testing.T) {
+	failing := []*storage.Feed{{ID: "2", Title: "broken", LastFetchTime: 1682380800, LastSuccessTime: 1682294400, LastError: "feed could not be fetched: unexpected status code 503", ConsecutiveFailures: 3}}
+
+	tests := []struct {
+		name       string
+		feeds      []*storage.Feed
+		err        error
+		wantStatus int
+	}{
+		{name: "failing feeds", feeds: failing, wantStatus: http.StatusOK},
+		{name: "all healthy", feeds: []*storage.Feed{}, wantStatus: http.StatusOK},
+		{name: "service error", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().ListFailingFeeds(gomock.Any()).Return(tt.feeds, tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/feeds/health", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got []*storage.Feed
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.feeds, got)
+			}
+		})
+	}
+}
+
+func TestServer_FullText(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		err        error
+		wantStatus int
+	}{
+		{name: "full text", content: "<p>full text</p>", wantStatus: http.StatusOK},
+		{name: "not found", err: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "unreachable", err: fmt.Errorf("%w: connection refused", service.ErrArticleUnreachable), wantStatus: http.StatusBadGateway},
+		{name: "no content", err: service.ErrNoContent, wantStatus: http.StatusUnprocessableEntity},
+		{name: "service error", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().FullText(gomock.Any(), "1").Return(tt.content, tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles/1/fulltext", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got FullTextResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, FullTextResponse{ID: "1", Content: tt.content}, got)
+			}
+		})
+	}
+}
+
+func TestServer_GetFeed(t *testing.T) {
+	tests := []struct {
+		name       string
+		feed       *storage.Feed
+		err        error
+		wantStatus int
+	}{
+		{name: "found", feed: &storage.Feed{ID: "1", Title: "example"}, wantStatus: http.StatusOK},
+		{name: "not found", err: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "service error", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().GetFeed(gomock.Any(), "1").Return(tt.feed, tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/feeds/1", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.feed != nil {
+				var got storage.Feed
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, *tt.feed, got)
+			}
+		})
+	}
+}
+
+func TestServer_ArticleFeeds(t *testing.T) {
+	tests := []struct {
+		name       string
+		feeds      []*storage.Feed
+		err        error
+		wantStatus int
+	}{
+		{name: "found", feeds: []*storage.Feed{{ID: "1", Title: "example"}, {ID: "2", Title: "category"}}, wantStatus: http.StatusOK},
+		{name: "not found", err: storage.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "service error", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().ListArticleFeeds(gomock.Any(), "a").Return(tt.feeds, tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles/a/feeds", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.feeds != nil {
+				var got []*storage.Feed
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.feeds, got)
+			}
+		})
+	}
+}
+
+func TestServer_UnreadCounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		counts     map[string]int
+		total      int
+		err        error
+		wantStatus int
+	}{
+		{name: "counts", counts: map[string]int{"1": 2, "2": 3}, total: 5, wantStatus: http.StatusOK},
+		{name: "service error", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().UnreadCounts(gomock.Any()).Return(tt.counts, tt.total, tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/feeds/unread-counts", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got UnreadCountsResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, UnreadCountsResponse{Feeds: tt.counts, Total: tt.total}, got)
+			}
+		})
+	}
+}
+
+func TestServer_ImportOPML(t *testing.T) {
+	tests := []struct {
+		name         string
+		feeds        []*storage.Feed
+		errs         []error
+		wantStatus   int
+		wantFailures []string
+	}{
+		{name: "imported", feeds: []*storage.Feed{{ID: "1"}}, wantStatus: http.StatusOK, wantFailures: []string{}},
+		{name: "partial failure", feeds: []*storage.Feed{{ID: "1"}}, errs: []error{errors.New("https://example.com/feed.xml: unreachable")}, wantStatus: http.StatusOK, wantFailures: []string{"https://example.com/feed.xml: unreachable"}},
+		{name: "invalid document", errs: []error{service.ErrInvalidOPML}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			svc.EXPECT().ImportOPML(gomock.Any(), gomock.Any()).Return(tt.feeds, tt.errs)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/feeds/import", strings.NewReader("<opml/>")))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var got ImportOPMLResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.wantFailures, got.Failures)
+			}
+		})
+	}
+}
+
+func TestServer_CreateFeeds(t *testing.T) {
+	feed := &storage.Feed{ID: "1", RSSLink: "https://example.com/feed.xml"}
+	existing := &storage.Feed{ID: "2", RSSLink: "https://example.com/existing.xml"}
+
+	tests := []struct {
+		name        string
+		body        string
+		results     []service.CreateFeedResult
+		wantStatus  int
+		wantResults []CreateFeedsResult
+	}{
+		{
+			name: "mixed results",
+			body: `["https://example.com/feed.xml","https://example.com/existing.xml","https://down.example.com/feed.xml","ftp://example.com"]`,
+			results: []service.CreateFeedResult{
+				{Link: feed.RSSLink, Feed: feed},
+				{Link: existing.RSSLink, Feed: existing, Err: storage.ErrDuplicateFeed},
+				{Link: "https://down.example.com/feed.xml", Err: fmt.Errorf("%w: connection refused", service.ErrFeedUnreachable)},
+				{Link: "ftp://example.com", Err: service.ErrInvalidURL},
+			},
+			wantStatus: http.StatusOK,
+			wantResults: []CreateFeedsResult{
+				{Link: feed.RSSLink, Status: http.StatusCreated, Feed: feed},
+				{Link: existing.RSSLink, Status: http.StatusConflict, Feed: existing, Error: "feed already exists"},
+				{Link: "https://down.example.com/feed.xml", Status: http.StatusBadGateway, Error: "feed could not be fetched"},
+				{Link: "ftp://example.com", Status: http.StatusBadRequest, Error: "feed link must be an absolute http or https url"},
+			},
+		},
+		{
+			name:       "not an array",
+			body:       `{"link":"https://example.com/feed.xml"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "empty",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "too many",
+			body:       `[` + strings.TrimSuffix(strings.Repeat(`"https://example.com/feed.xml",`, maxBatchFeeds+1), ",") + `]`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, svc := newMockServiceServer(t)
+			if tt.results != nil {
+				urls := make([]string, 0, len(tt.results))
+				for _, r := range tt.results {
+					urls = append(urls, r.Link)
+				}
+				svc.EXPECT().CreateFeeds(gomock.Any(), urls).Return(tt.results)
+			}
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/feeds/batch", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantResults != nil {
+				var got CreateFeedsResponse
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.wantResults, got.Results)
+			}
+		})
+	}
+}
+
+func TestServer_CreateFeed(t *testing.T) {
+	parsed := &parser.RSSFeed{
+		Channel: parser.Channel{
+			Title:       "example",
+			Link:        "https://example.com/",
+			Description: "example feed",
+		},
+	}
+	feed := &storage.Feed{
+		ID:          "1",
+		Title:       "example",
+		RSSLink:     "https://example.com/feed.xml",
+		SiteLink:    "https://example.com",
+		Description: "example feed",
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		setup      func(store *storageMocks.MockStorage, p *parserMocks.MockParser)
+		wantStatus int
+		wantFeed   *storage.Feed
+		wantBody   string
+	}{
+		{
+			name:       "invalid request body",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid request body",
+		},
+		{
+			name:       "invalid url",
+			body:       `{"link": "example.com/feed.xml"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "feed link must be an absolute http or https url",
+		},
+		{
+			name: "unreachable",
+			body: `{"link": "https://example.com/feed.xml"}`,
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				p.EXPECT().ParseFromURI(gomock.Any(), "https://example.com/feed.xml").Return(nil, &parser.StatusError{StatusCode: http.StatusNotFound})
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "feed could not be fetched",
+		},
+		{
+			name: "empty feed",
+			body: `{"link": "https://example.com/feed.xml"}`,
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				p.EXPECT().ParseFromURI(gomock.Any(), "https://example.com/feed.xml").Return(nil, parser.ErrEmptyFeed)
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "feed link did not return a feed",
+		},
+		{
+			name: "duplicate",
+			body: `{"link": "https://example.com/feed.xml"}`,
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				p.EXPECT().ParseFromURI(gomock.Any(), "https://example.com/feed.xml").Return(parsed, nil)
+				store.EXPECT().CreateFeed(gomock.Any(), "example", "https://example.com/feed.xml", "https://example.com/", "example feed", "", "", "").Return(feed, storage.ErrDuplicateFeed)
+			},
+			wantStatus: http.StatusConflict,
+			wantFeed:   feed,
+		},
+		{
+			name: "storage error",
+			body: `{"link": "https://example.com/feed.xml"}`,
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				p.EXPECT().ParseFromURI(gomock.Any(), "https://example.com/feed.xml").Return(parsed, nil)
+				store.EXPECT().CreateFeed(gomock.Any(), "example", "https://example.com/feed.xml", "https://example.com/", "example feed", "", "", "").Return(nil, errors.New("disk I/O error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "failed to create feed",
+		},
+		{
+			name: "created",
+			body: `{"link": " https://example.com/feed.xml "}`,
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				p.EXPECT().ParseFromURI(gomock.Any(), "https://example.com/feed.xml").Return(parsed, nil)
+				store.EXPECT().CreateFeed(gomock.Any(), "example", "https://example.com/feed.xml", "https://example.com/", "example feed", "", "", "").Return(feed, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantFeed:   feed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, p := newTestServer(t)
+			if tt.setup != nil {
+				tt.setup(store, p)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/feeds", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			s.CreateFeed()(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantFeed == nil {
+				assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+				return
+			}
+
+			var got storage.Feed
+			err := json.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.wantFeed.ID, got.ID)
+			assert.Equal(t, tt.wantFeed.RSSLink, got.RSSLink)
+		})
+	}
+}
+
+func TestServer_RefreshFeed(t *testing.T) {
+	feed := &storage.Feed{ID: "1", RSSLink: "https://example.com/feed.xml"}
+	parsed := &parser.RSSFeed{
+		Channel: parser.Channel{
+			Items: []parser.Item{
+				{Title: "old", Link: "https://example.com/old"},
+				{Title: "new", Link: "https://example.com/new", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+			},
+		},
+	}
+	added := &storage.Article{ID: "2", FeedID: "1", Title: "new", Link: "https://example.com/new"}
+
+	tests := []struct {
+		name         string
+		setup        func(store *storageMocks.MockStorage, p *parserMocks.MockParser)
+		wantStatus   int
+		wantResponse *RefreshFeedResponse
+		wantBody     string
+	}{
+		{
+			name: "not found",
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				store.EXPECT().GetFeed(gomock.Any(), "1").Return(nil, storage.ErrNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "feed not found",
+		},
+		{
+			name: "unreachable",
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				store.EXPECT().GetFeed(gomock.Any(), "1").Return(feed, nil)
+				p.EXPECT().ConditionalParseFromURI(gomock.Any(), feed.RSSLink, "", "").Return(nil, &parser.StatusError{StatusCode: http.StatusServiceUnavailable})
+				store.EXPECT().RecordFeedFetch(gomock.Any(), "1", gomock.Not("")).Return(nil)
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "feed could not be fetched",
+		},
+		{
+			name: "storage error",
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				store.EXPECT().GetFeed(gomock.Any(), "1").Return(feed, nil)
+				p.EXPECT().ConditionalParseFromURI(gomock.Any(), feed.RSSLink, "", "").Return(parsed, nil)
+				store.EXPECT().ListArticlesByFeed(gomock.Any(), "1").Return(nil, errors.New("disk I/O error"))
+				store.EXPECT().RecordFeedFetch(gomock.Any(), "1", "disk I/O error").Return(nil)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "failed to refresh feed",
+		},
+		{
+			name: "refreshed",
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				store.EXPECT().GetFeed(gomock.Any(), "1").Return(feed, nil)
+				p.EXPECT().ConditionalParseFromURI(gomock.Any(), feed.RSSLink, "", "").Return(parsed, nil)
+				store.EXPECT().ListArticlesByFeed(gomock.Any(), "1").Return([]*storage.Article{{Link: "https://example.com/old", Title: "old"}}, nil)
+				store.EXPECT().CreateArticle(gomock.Any(), "1", "https://example.com/new", "", "new", "", "", "", nil, gomock.Any(), gomock.Any(), gomock.Any()).Return(added, nil)
+				store.EXPECT().RecordFeedFetch(gomock.Any(), "1", "").Return(nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantResponse: &RefreshFeedResponse{Added: 1, Articles: []*storage.Article{added}},
+		},
+		{
+			name: "not modified",
+			setup: func(store *storageMocks.MockStorage, p *parserMocks.MockParser) {
+				store.EXPECT().GetFeed(gomock.Any(), "1").Return(feed, nil)
+				p.EXPECT().ConditionalParseFromURI(gomock.Any(), feed.RSSLink, "", "").Return(nil, parser.ErrNotModified)
+				store.EXPECT().RecordFeedFetch(gomock.Any(), "1", "").Return(nil)
+			},
+			wantStatus:   http.StatusOK,
+			wantResponse: &RefreshFeedResponse{Added: 0, Articles: []*storage.Article{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, p := newTestServer(t)
+			tt.setup(store, p)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/feeds/1/refresh", nil)
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantResponse == nil {
+				assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+				return
+			}
+
+			var got RefreshFeedResponse
+			err := json.Unmarshal(w.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, *tt.wantResponse, got)
+		})
+	}
+}
+
+func TestServer_CreateArticle(t *testing.T) {
+	body := `{"link": "https://example.com/posts/1", "title": "article 1", "author": "author", "publishedOn": "Tue, 25 Apr 2023 00:00:00 +0000"}`
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "duplicate",
+			err:        storage.ErrDuplicateArticle,
+			wantStatus: http.StatusConflict,
+			wantBody:   "article already exists",
+		},
+		{
+			name:       "missing feed",
+			err:        fmt.Errorf("%w: no feed for https://example.com", storage.ErrFeedMissing),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "no feed exists for the article link",
+		},
+		{
+			name:       "driver error is not leaked",
+			err:        errors.New("UNIQUE constraint failed: articles.link"),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "failed to create article",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().CreateArticle(gomock.Any(), "", "https://example.com/posts/1", "", "article 1", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(nil, tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/articles", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			s.CreateArticle()(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestServer_StreamArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	svc := service.New(store, parserMocks.NewMockParser(ctrl))
+	s := New(svc, zap.NewNop())
+	s.heartbeat = 10 * time.Millisecond
+
+	srv := httptest.NewServer(s.StreamArticles())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("content-type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		if !lines.Scan() {
+			t.Fatal("stream ended", lines.Err())
+		}
+		return lines.Text()
+	}
+
+	// the first heartbeat means the handler has subscribed, so the article below is not published too early
+	assert.Equal(t, ": heartbeat", next())
+	assert.Equal(t, "", next())
+
+	article := &storage.Article{ID: "1", Title: "article 1"}
+	store.EXPECT().CreateArticle(gomock.Any(), "", "https://example.com/posts/1", "", "article 1", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(article, nil)
+	_, err = svc.CreateArticle(context.Background(), service.CreateArticleRequest{
+		Article: storage.Article{Link: "https://example.com/posts/1", Title: "article 1", Author: "author", Published: "Tue, 25 Apr 2023 00:00:00 +0000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for line := next(); line != "event: article"; line = next() {
+		assert.True(t, line == ": heartbeat" || line == "", line)
+	}
+	assert.Equal(t, "id: 1", next())
+
+	data := strings.TrimPrefix(next(), "data: ")
+	var got storage.Article
+	err = json.Unmarshal([]byte(data), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "article 1", got.Title)
+}
+
+func TestServer_Websocket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	svc := service.New(store, parserMocks.NewMockParser(ctrl))
+	s := New(svc, zap.NewNop())
+
+	srv := httptest.NewServer(s.Router())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := websocket.Dial(ctx, strings.Replace(srv.URL, "http://", "ws://", 1)+"/api/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	next := func() wsMessage {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msg wsMessage
+		err = json.Unmarshal(b, &msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	err = conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "subscribe", "feedIds": ["2"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the acknowledgement means the handler has subscribed, so the articles below are not published too early
+	assert.Equal(t, wsMessage{Type: wsSubscribed, FeedIDs: []string{"2"}}, next())
+
+	for _, feedID := range []string{"1", "2"} {
+		article := &storage.Article{ID: feedID, FeedID: feedID, Title: "article " + feedID}
+		link := "https://example.com/posts/" + feedID
+		store.EXPECT().CreateArticle(gomock.Any(), "", link, "", article.Title, "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(article, nil)
+		_, err = svc.CreateArticle(context.Background(), service.CreateArticleRequest{
+			Article: storage.Article{Link: link, Title: article.Title, Author: "author", Published: "Tue, 25 Apr 2023 00:00:00 +0000"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msg := next()
+	assert.Equal(t, wsArticle, msg.Type)
+	if assert.NotNil(t, msg.Article) {
+		assert.Equal(t, "article 2", msg.Article.Title, "articles from feeds that were not subscribed to are skipped")
+	}
+}
+
+func TestServer_WebsocketOrigin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := service.New(storageMocks.NewMockStorage(ctrl), parserMocks.NewMockParser(ctrl))
+	s := New(svc, zap.NewNop(), WithCORS([]string{"https://reader.example.com"}, nil, nil))
+
+	srv := httptest.NewServer(s.Router())
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{
+			name:    "no origin",
+			allowed: true,
+		},
+		{
+			name:    "same origin",
+			origin:  srv.URL,
+			allowed: true,
+		},
+		{
+			name:    "configured origin",
+			origin:  "https://reader.example.com",
+			allowed: true,
+		},
+		{
+			name:   "other origin",
+			origin: "https://evil.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+
+			conn, err := websocket.Dial(ctx, strings.Replace(srv.URL, "http://", "ws://", 1)+"/api/ws", header)
+			if !tt.allowed {
+				assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+				assert.ErrorContains(t, err, "403")
+				return
+			}
+
+			if assert.NoError(t, err) {
+				conn.Close()
+			}
+		})
+	}
+}
+
+func TestServer_OptionsMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		opts      []Option
+		wantLimit int
+	}{
+		{
+			name:      "default page size",
+			query:     "",
+			wantLimit: storage.DefaultLimit,
+		},
+		{
+			name:      "configured default page size",
+			query:     "",
+			opts:      []Option{WithPageSize(25, 50)},
+			wantLimit: 25,
+		},
+		{
+			name:      "limit above configured max",
+			query:     "?limit=500",
+			opts:      []Option{WithPageSize(25, 50)},
+			wantLimit: 50,
+		},
+		{
+			name:      "limit below one",
+			query:     "?limit=-1",
+			opts:      []Option{WithPageSize(25, 50)},
+			wantLimit: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, zap.NewNop(), tt.opts...)
+
+			var got *storage.Options
+			h := s.OptionsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				got = OptionsFromContext(r.Context())
+			})
+
+			h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/articles"+tt.query, nil))
+			assert.Equal(t, tt.wantLimit, got.Limit)
+		})
+	}
+}
+
+func TestServer_OptionsMiddlewareInvalidCursor(t *testing.T) {
+	for _, cursor := range []string{"1672531200", "1672531200~5", "eyJmIjoicHVibGlzaGVkIn0.AAAA"} {
+		t.Run(cursor, func(t *testing.T) {
+			s := New(nil, zap.NewNop(), WithCursorKey("secret"))
+			h := s.OptionsMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("the handler is not called with an invalid cursor")
+			})
+
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(http.MethodGet, "/api/articles?cursor="+cursor, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestServer_AuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		path       string
+		header     http.Header
+		wantStatus int
+	}{
+		{
+			name:       "no keys configured",
+			path:       "/api/feeds",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing key",
+			opts:       []Option{WithAPIKeys([]string{"secret"})},
+			path:       "/api/feeds",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong key",
+			opts:       []Option{WithAPIKeys([]string{"secret"})},
+			path:       "/api/feeds",
+			header:     http.Header{"X-Api-Key": {"secreT"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "api key header",
+			opts:       []Option{WithAPIKeys([]string{"secret", "other"})},
+			path:       "/api/feeds",
+			header:     http.Header{"X-Api-Key": {"other"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "bearer token",
+			opts:       []Option{WithAPIKeys([]string{"secret"})},
+			path:       "/api/feeds",
+			header:     http.Header{"Authorization": {"Bearer secret"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "authorization without bearer scheme",
+			opts:       []Option{WithAPIKeys([]string{"secret"})},
+			path:       "/api/feeds",
+			header:     http.Header{"Authorization": {"Basic secret"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "bypassed path",
+			opts:       []Option{WithAPIKeys([]string{"secret"}, "/health")},
+			path:       "/health",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, zap.NewNop(), tt.opts...)
+			h := s.AuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_Readyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "ready",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "storage unreachable",
+			err:        errors.New("database is closed"),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().Ping(gomock.Any()).Return(tt.err)
+
+			w := httptest.NewRecorder()
+			s.Readyz()(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_DeleteArticle(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "deleted",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "not found",
+			err:        storage.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "storage error",
+			err:        errors.New("disk I/O error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().DeleteArticle(gomock.Any(), "1").Return(tt.err)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/articles/1", nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_CreateFolder(t *testing.T) {
+	folder := &storage.Folder{ID: "1", Name: "news"}
+
+	tests := []struct {
+		name       string
+		body       string
+		setup      func(store *storageMocks.MockStorage)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "empty name",
+			body:       `{"name": " "}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "folder name is empty",
+		},
+		{
+			name: "duplicate",
+			body: `{"name": "news"}`,
+			setup: func(store *storageMocks.MockStorage) {
+				store.EXPECT().CreateFolder(gomock.Any(), "news").Return(nil, storage.ErrDuplicateFolder)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   "folder already exists",
+		},
+		{
+			name: "created",
+			body: `{"name": " news "}`,
+			setup: func(store *storageMocks.MockStorage) {
+				store.EXPECT().CreateFolder(gomock.Any(), "news").Return(folder, nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":"1","name":"news"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/folders", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestServer_ListFeedsByFolder(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		setup func(store *storageMocks.MockStorage)
+	}{
+		{
+			name:  "every feed",
+			query: "",
+			setup: func(store *storageMocks.MockStorage) {
+				store.EXPECT().ListFeeds(gomock.Any(), gomock.Any()).Return(storage.FeedList{}, nil)
+			},
+		},
+		{
+			name:  "folder",
+			query: "?folder=2",
+			setup: func(store *storageMocks.MockStorage) {
+				store.EXPECT().ListFeedsByFolder(gomock.Any(), "2", gomock.Any()).Return(storage.FeedList{}, nil)
+			},
+		},
+		{
+			name:  "uncategorized",
+			query: "?folder=",
+			setup: func(store *storageMocks.MockStorage) {
+				store.EXPECT().ListFeedsByFolder(gomock.Any(), "", gomock.Any()).Return(storage.FeedList{}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			tt.setup(store)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/feeds"+tt.query, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestServer_ListArticlesByStatus(t *testing.T) {
+	tests := []struct {
+		query string
+		want  storage.Status
+	}{
+		{query: "", want: storage.StatusAll},
+		{query: "?status=all", want: storage.StatusAll},
+		{query: "?status=read", want: storage.StatusRead},
+		{query: "?status=Unread", want: storage.StatusUnread},
+		{query: "?status=favorited", want: storage.StatusFavorited},
+		{query: "?filter=favorited", want: storage.StatusFavorited},
+		{query: "?status=saved", want: storage.StatusSaved},
+		{query: "?status=bogus", want: storage.StatusAll},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().ListArticlesByStatus(gomock.Any(), tt.want, gomock.Any()).Return(storage.ArticleList{}, nil)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles"+tt.query, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestServer_PlainText(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: "<p>hello <b>world</b></p>"},
+		{query: "?plain=true", want: "hello world"},
+		{query: "?plain=false", want: "<p>hello <b>world</b></p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().GetArticle(gomock.Any(), "1").Return(&storage.Article{ID: "1", Description: "<p>hello <b>world</b></p>"}, nil)
+			store.EXPECT().ListArticlesByStatus(gomock.Any(), storage.StatusAll, gomock.Any()).Return(storage.ArticleList{Articles: []*storage.Article{{ID: "1", Description: "<p>hello <b>world</b></p>"}}}, nil)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles/1"+tt.query, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var article storage.Article
+			err := json.Unmarshal(w.Body.Bytes(), &article)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, article.Description)
+
+			w = httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles"+tt.query, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var list storage.ArticleList
+			err = json.Unmarshal(w.Body.Bytes(), &list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if assert.Len(t, list.Articles, 1) {
+				assert.Equal(t, tt.want, list.Articles[0].Description)
+			}
+		})
+	}
+}
+
+func TestServer_ListArticlesConditional(t *testing.T) {
+	added := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	articles := []*storage.Article{
+		{ID: "1", Title: "first", Timestamp: added.Add(-time.Hour).Unix()},
+		{ID: "2", Title: "second", Timestamp: added.Unix()},
+	}
+	changed := []*storage.Article{
+		{ID: "1", Title: "first", Timestamp: added.Add(-time.Hour).Unix()},
+		{ID: "2", Title: "second", Timestamp: added.Unix(), Read: true},
+	}
+
+	s, svc := newMockServiceServer(t)
+	list := func(articles []*storage.Article, header ...string) *httptest.ResponseRecorder {
+		svc.EXPECT().ListArticlesByStatus(gomock.Any(), storage.StatusAll, gomock.Any()).Return(storage.ArticleList{Articles: articles}, nil)
+
+		r := httptest.NewRequest(http.MethodGet, "/api/articles", nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, r)
+		return w
+	}
+
+	first := list(articles)
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "Mon, 02 Jan 2023 15:04:05 GMT", first.Header().Get("Last-Modified"))
+
+	tests := []struct {
+		name       string
+		articles   []*storage.Article
+		header     []string
+		wantStatus int
+	}{
+		{name: "matching etag", articles: articles, header: []string{"If-None-Match", etag}, wantStatus: http.StatusNotModified},
+		{name: "one of several etags", articles: articles, header: []string{"If-None-Match", `"stale", W/` + etag}, wantStatus: http.StatusNotModified},
+		{name: "changed dataset", articles: changed, header: []string{"If-None-Match", etag}, wantStatus: http.StatusOK},
+		{name: "not modified since", articles: articles, header: []string{"If-Modified-Since", first.Header().Get("Last-Modified")}, wantStatus: http.StatusNotModified},
+		{name: "added since", articles: articles, header: []string{"If-Modified-Since", added.Add(-time.Minute).Format(http.TimeFormat)}, wantStatus: http.StatusOK},
+		{name: "etag wins over date", articles: changed, header: []string{"If-None-Match", etag, "If-Modified-Since", first.Header().Get("Last-Modified")}, wantStatus: http.StatusOK},
+		{name: "unconditional", articles: articles, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := list(tt.articles, tt.header...)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+				assert.Equal(t, etag, w.Header().Get("ETag"))
+				return
+			}
+
+			var got storage.ArticleList
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Len(t, got.Articles, len(tt.articles))
+		})
+	}
+}
+
+func TestServer_ListArticlesBetween(t *testing.T) {
+	from := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 1, 9, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		storeErr   error
+		wantFrom   time.Time
+		wantTo     time.Time
+		wantStatus int
+		wantList   bool
+	}{
+		{name: "rfc3339", query: "?from=2023-01-02T00:00:00Z&to=2023-01-09T00:00:00Z", wantFrom: from, wantTo: to, wantStatus: http.StatusOK, wantList: true},
+		{name: "unix", query: fmt.Sprintf("?from=%d&to=%d", from.Unix(), to.Unix()), wantFrom: from, wantTo: to, wantStatus: http.StatusOK, wantList: true},
+		{name: "only from", query: "?from=2023-01-02T00:00:00Z", wantFrom: from, wantStatus: http.StatusOK, wantList: true},
+		{name: "only to", query: "?to=2023-01-09T00:00:00Z", wantTo: to, wantStatus: http.StatusOK, wantList: true},
+		{name: "from after to", query: "?from=2023-01-09T00:00:00Z&to=2023-01-02T00:00:00Z", wantFrom: to, wantTo: from, storeErr: storage.ErrInvalidRange, wantStatus: http.StatusBadRequest, wantList: true},
+		{name: "invalid from", query: "?from=last%20week", wantStatus: http.StatusBadRequest},
+		{name: "invalid to", query: "?to=tomorrow", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			if tt.wantList {
+				store.EXPECT().ListFilteredArticles(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, filter storage.ArticleFilter, opts *storage.Options) (storage.ArticleList, error) {
+					assert.Equal(t, storage.StatusAll, filter.Status)
+					assert.True(t, tt.wantFrom.Equal(filter.From), "from %v, want %v", filter.From, tt.wantFrom)
+					assert.True(t, tt.wantTo.Equal(filter.To), "to %v, want %v", filter.To, tt.wantTo)
+					return storage.ArticleList{}, tt.storeErr
+				})
+			}
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles"+tt.query, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_ListFilteredArticles(t *testing.T) {
+	from := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		storeErr   error
+		wantFilter storage.ArticleFilter
+		wantCode   int
+	}{
+		{name: "two feeds", query: "?feeds=1,2", wantFilter: storage.ArticleFilter{Status: storage.StatusAll, FeedIDs: []string{"1", "2"}}, wantCode: http.StatusOK},
+		{name: "with a status", query: "?feeds=3&status=unread", wantFilter: storage.ArticleFilter{Status: storage.StatusUnread, FeedIDs: []string{"3"}}, wantCode: http.StatusOK},
+		{name: "tag with a status", query: "?tag=golang&status=saved", wantFilter: storage.ArticleFilter{Status: storage.StatusSaved, Tag: "golang"}, wantCode: http.StatusOK},
+		{
+			name:       "every filter",
+			query:      "?feeds=1,2&from=2023-01-02T00:00:00Z&tag=golang&status=unread",
+			wantFilter: storage.ArticleFilter{Status: storage.StatusUnread, FeedIDs: []string{"1", "2"}, From: from, Tag: "golang"},
+			wantCode:   http.StatusOK,
+		},
+		{name: "empty id", query: "?feeds=1,,2", wantFilter: storage.ArticleFilter{Status: storage.StatusAll, FeedIDs: []string{"1", "", "2"}}, storeErr: storage.ErrInvalidFeedID, wantCode: http.StatusBadRequest},
+		{name: "invalid id", query: "?feeds=1,abc", wantFilter: storage.ArticleFilter{Status: storage.StatusAll, FeedIDs: []string{"1", "abc"}}, storeErr: storage.ErrInvalidFeedID, wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().ListFilteredArticles(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, filter storage.ArticleFilter, opts *storage.Options) (storage.ArticleList, error) {
+				assert.True(t, tt.wantFilter.From.Equal(filter.From), "from %v, want %v", filter.From, tt.wantFilter.From)
+				filter.From = tt.wantFilter.From
+				assert.Equal(t, tt.wantFilter, filter)
+				return storage.ArticleList{}, tt.storeErr
+			})
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles"+tt.query, nil))
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestServer_CountNewArticles(t *testing.T) {
+	store := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	_, err = store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createArticle := func(i int) {
+		t.Helper()
+		link := fmt.Sprintf("https://example.com/posts/%d", i)
+		_, err := store.CreateArticle(ctx, "", link, link, link, "author", "", "", nil, nil, time.Date(2023, 1, i, 0, 0, 0, 0, time.UTC), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		createArticle(i)
+	}
+
+	s := New(service.New(store, parserMocks.NewMockParser(gomock.NewController(t))), zap.NewNop(), WithCursorKey("secret"))
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/api/articles?limit=2")
+	var list storage.ArticleList
+	err = json.Unmarshal(w.Body.Bytes(), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.NotEmpty(t, list.Head) {
+		return
+	}
+
+	w = get("/api/articles/new-count?cursor=" + url.QueryEscape(list.Head))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"count":0}`, w.Body.String())
+
+	createArticle(4)
+	createArticle(5)
+
+	w = get("/api/articles/new-count?cursor=" + url.QueryEscape(list.Head))
+	assert.JSONEq(t, `{"count":2}`, w.Body.String())
+
+	other, err := store.CreateFeed(ctx, "other", "https://other.com/feed.xml", "https://other.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.CreateArticle(ctx, "", "https://other.com/posts/1", "", "other", "author", "", "", nil, nil, time.Date(2023, 1, 6, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the count is filtered like the list it was made from
+	w = get("/api/articles/new-count?feeds=" + other.ID + "&cursor=" + url.QueryEscape(list.Head))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"count":1}`, w.Body.String())
+
+	w = get("/api/articles/new-count?feeds=abc&cursor=" + url.QueryEscape(list.Head))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = get("/api/articles/new-count?from=yesterday&cursor=" + url.QueryEscape(list.Head))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// the cursor was issued for the list sorted by published date
+	w = get("/api/articles/new-count?sort=title&cursor=" + url.QueryEscape(list.Head))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = get("/api/articles/new-count")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_LocalizePublished(t *testing.T) {
+	// new york moved to daylight saving time at 2am on 12 Mar 2023, 7am UTC
+	beforeDST := time.Date(2023, 3, 12, 6, 30, 0, 0, time.UTC)
+	afterDST := time.Date(2023, 3, 12, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		tz   string
+		want []string
+	}{
+		{name: "no timezone", want: []string{"Sun, 12 Mar 2023", "Sun, 12 Mar 2023"}},
+		{name: "utc", tz: "UTC", want: []string{"Sun, 12 Mar 2023 06:30:00 +0000", "Sun, 12 Mar 2023 07:30:00 +0000"}},
+		{name: "across dst", tz: "America/New_York", want: []string{"Sun, 12 Mar 2023 01:30:00 -0500", "Sun, 12 Mar 2023 03:30:00 -0400"}},
+		{name: "ahead of utc", tz: "Asia/Tokyo", want: []string{"Sun, 12 Mar 2023 15:30:00 +0900", "Sun, 12 Mar 2023 16:30:00 +0900"}},
+		{name: "unknown timezone falls back to utc", tz: "Mars/Olympus_Mons", want: []string{"Sun, 12 Mar 2023 06:30:00 +0000", "Sun, 12 Mar 2023 07:30:00 +0000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, _ := newTestServer(t)
+			store.EXPECT().ListArticlesByStatus(gomock.Any(), storage.StatusAll, gomock.Any()).Return(storage.ArticleList{Articles: []*storage.Article{
+				{ID: "1", PublishedUnix: beforeDST.Unix(), Published: beforeDST.Format("Mon, 02 Jan 2006")},
+				{ID: "2", PublishedUnix: afterDST.Unix(), Published: afterDST.Format("Mon, 02 Jan 2006")},
+			}}, nil)
+
+			target := "/api/articles"
+			if tt.tz != "" {
+				target += "?tz=" + url.QueryEscape(tt.tz)
+			}
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var list storage.ArticleList
+			err := json.Unmarshal(w.Body.Bytes(), &list)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(list.Articles))
+			for _, a := range list.Articles {
+				got = append(got, a.Published)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServer_Excerpts(t *testing.T) {
+	description := "<p>The <b>quick</b> brown fox jumps over the lazy dog</p>"
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default length", want: "The quick brown fox jumps over the lazy dog"},
+		{name: "configured length", opts: []Option{WithExcerptLength(12)}, want: "The quick…"},
+		{name: "disabled", opts: []Option{WithExcerptLength(0)}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := storageMocks.NewMockStorage(ctrl)
+			s := New(service.New(store, parserMocks.NewMockParser(ctrl)), zap.NewNop(), tt.opts...)
+			store.EXPECT().ListArticlesByStatus(gomock.Any(), storage.StatusAll, gomock.Any()).Return(storage.ArticleList{Articles: []*storage.Article{{ID: "1", Description: description}}}, nil)
+			store.EXPECT().GetArticle(gomock.Any(), "1").Return(&storage.Article{ID: "1", Description: description}, nil)
+
+			w := httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var list storage.ArticleList
+			err := json.Unmarshal(w.Body.Bytes(), &list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if assert.Len(t, list.Articles, 1) {
+				assert.Equal(t, tt.want, list.Articles[0].Excerpt)
+				assert.Equal(t, description, list.Articles[0].Description)
+			}
+
+			w = httptest.NewRecorder()
+			s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/articles/1", nil))
+			assert.NotContains(t, w.Body.String(), "excerpt", "a single article has its full description")
+		})
+	}
+}
+
+func TestServer_SetArticleSaved(t *testing.T) {
+	s, store, _ := newTestServer(t)
+	store.EXPECT().SetArticleSaved(gomock.Any(), "1", true).Return(&storage.Article{ID: "1", Saved: true}, nil)
+	store.EXPECT().SetArticleSaved(gomock.Any(), "1", false).Return(&storage.Article{ID: "1"}, nil)
+	store.EXPECT().SetArticleSaved(gomock.Any(), "404", true).Return(nil, storage.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/articles/1/saved", strings.NewReader(`{"saved": true}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got storage.Article
+	err := json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, got.Saved)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/articles/1/saved", strings.NewReader(`{"saved": false}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/articles/404/saved", strings.NewReader(`{"saved": true}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/articles/1/saved", strings.NewReader(`{`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_MarkAllRead(t *testing.T) {
+	s, store, _ := newTestServer(t)
+	store.EXPECT().GetFeed(gomock.Any(), "1").Return(&storage.Feed{ID: "1"}, nil)
+	store.EXPECT().MarkAllRead(gomock.Any(), "1").Return(2, nil)
+	store.EXPECT().GetFeed(gomock.Any(), "404").Return(nil, storage.ErrNotFound)
+	store.EXPECT().MarkAllRead(gomock.Any(), "").Return(5, nil)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/feeds/1/read", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"marked": 2}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/feeds/404/read", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/articles/read", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"marked": 5}`, w.Body.String())
+}
+
+func TestServer_UpdateFeedTitle(t *testing.T) {
+	s, store, _ := newTestServer(t)
+	store.EXPECT().UpdateFeedTitle(gomock.Any(), "1", "my title").Return(nil)
+	store.EXPECT().GetFeed(gomock.Any(), "1").Return(&storage.Feed{ID: "1", Title: "my title", CustomTitle: "my title"}, nil)
+	store.EXPECT().UpdateFeedTitle(gomock.Any(), "404", "").Return(storage.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/feeds/1", strings.NewReader(`{"title": "  my title "}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got storage.Feed
+	err := json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "my title", got.Title)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/feeds/404", strings.NewReader(`{"title": ""}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/feeds/1", strings.NewReader(`not json`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_Stats(t *testing.T) {
+	s, store, _ := newTestServer(t)
+	store.EXPECT().Stats(gomock.Any()).Return(storage.Stats{Feeds: 2, Articles: 3, Unread: 2, Favorited: 1, LastAdded: 1672531200}, nil)
+	store.EXPECT().Stats(gomock.Any()).Return(storage.Stats{}, errors.New("boom"))
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"feeds": 2, "articles": 3, "unread": 2, "favorited": 1, "lastAdded": 1672531200}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestServer_Poll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	svc := service.New(store, p)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	pl := poller.New(ticker, svc, zap.NewNop(), poller.WithConcurrency(1))
+
+	feeds := []*storage.Feed{
+		{ID: "1", Title: "example", RSSLink: "https://example.com/feed.xml"},
+		{ID: "2", Title: "broken", RSSLink: "https://broken.com/feed.xml"},
+	}
+	store.EXPECT().ListFeeds(gomock.Any(), gomock.Any()).Return(storage.FeedList{Feeds: feeds}, nil)
+	p.EXPECT().ConditionalParseFromURI(gomock.Any(), "https://example.com/feed.xml", "", "").Return(&parser.RSSFeed{}, nil)
+	p.EXPECT().ConditionalParseFromURI(gomock.Any(), "https://broken.com/feed.xml", "", "").Return(nil, errors.New("connection refused"))
+	store.EXPECT().ListArticlesByFeed(gomock.Any(), "1").Return([]*storage.Article{}, nil)
+	store.EXPECT().RecordFeedFetch(gomock.Any(), "1", "").Return(nil)
+	store.EXPECT().RecordFeedFetch(gomock.Any(), "2", gomock.Not("")).Return(nil)
+
+	t.Run("summary", func(t *testing.T) {
+		s := New(svc, zap.NewNop(), WithPoller(&pl))
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/poll", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var got poller.Summary
+		err := json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, 1, got.Failed)
+		assert.Equal(t, 0, got.Added)
+		if assert.Len(t, got.Feeds, 2) {
+			assert.Equal(t, poller.FeedSummary{ID: "1", Title: "example"}, got.Feeds[0])
+			assert.Equal(t, "2", got.Feeds[1].ID)
+			assert.Contains(t, got.Feeds[1].Error, "connection refused")
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		s := New(svc, zap.NewNop())
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/poll", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestServer_Prune(t *testing.T) {
+	t.Run("deleted count", func(t *testing.T) {
+		svc := serviceMocks.NewMockService(gomock.NewController(t))
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		pr := pruner.New(ticker, svc, zap.NewNop())
+		s := New(svc, zap.NewNop(), WithPruner(&pr))
+		svc.EXPECT().PruneArticles(gomock.Any()).Return(7, nil)
+
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/prune", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"deleted":7}`, w.Body.String())
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		svc := serviceMocks.NewMockService(gomock.NewController(t))
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		pr := pruner.New(ticker, svc, zap.NewNop())
+		s := New(svc, zap.NewNop(), WithPruner(&pr))
+		svc.EXPECT().PruneArticles(gomock.Any()).Return(0, errors.New("database is locked"))
+
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/prune", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		s, _ := newMockServiceServer(t)
+		w := httptest.NewRecorder()
+		s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/prune", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestServer_AssignFeedToFolder(t *testing.T) {
+	s, store, _ := newTestServer(t)
+	feed := &storage.Feed{ID: "1", Title: "example", FolderID: "2"}
+	store.EXPECT().AssignFeedToFolder(gomock.Any(), "1", "2").Return(nil)
+	store.EXPECT().GetFeed(gomock.Any(), "1").Return(feed, nil)
+	store.EXPECT().AssignFeedToFolder(gomock.Any(), "1", "404").Return(storage.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/feeds/1/folder", strings.NewReader(`{"folderId": "2"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got storage.Feed
+	err := json.Unmarshal(w.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2", got.FolderID)
+
+	w = httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/feeds/1/folder", strings.NewReader(`{"folderId": "404"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServer_RouterProbesBypassAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	s := New(service.New(store, parserMocks.NewMockParser(ctrl)), zap.NewNop(), WithAPIKeys([]string{"secret"}))
+	rtr := s.Router()
+
+	store.EXPECT().Ping(gomock.Any()).Return(nil)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/healthz", wantStatus: http.StatusOK},
+		{path: "/readyz", wantStatus: http.StatusOK},
+		{path: "/api/feeds", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rtr.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	s := New(nil, zap.NewNop(), WithRegistry(reg))
+	// a second server sharing the registry records into the same metrics instead of failing to register
+	other := New(nil, zap.NewNop(), WithRegistry(reg))
+
+	for _, rtr := range []*mux.Router{s.Router(), other.Router()} {
+		w := httptest.NewRecorder()
+		rtr.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `feedreader_http_requests_total{route="/healthz",method="GET",status="200"} 2`)
+	assert.Contains(t, w.Body.String(), `feedreader_http_request_duration_seconds_count{route="/healthz",method="GET"} 2`)
+}
+
+func TestServer_LogMiddlewareRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantID   string
+	}{
+		{
+			name:     "incoming id is kept",
+			incoming: "abc-123",
+			wantID:   "abc-123",
+		},
+		{
+			name: "id is generated",
+		},
+		{
+			name:     "unprintable id is replaced",
+			incoming: "abc\x00123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			s := New(nil, zap.New(core))
+
+			var ctxID string
+			h := s.LogMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = RequestIDFromContext(r.Context())
+				LoggerFromContext(r.Context()).Info("handled")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/feeds", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, got)
+			} else {
+				assert.Len(t, got, 32)
+			}
+			assert.Equal(t, got, ctxID)
+
+			entries := logs.All()
+			if assert.Len(t, entries, 1) {
+				assert.Equal(t, got, entries[0].ContextMap()["requestID"])
+			}
+		})
+	}
+}
+
+func TestServer_LogMiddlewareRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		level        zapcore.Level
+		body         string
+		wantLogged   bool
+		wantBody     string
+		wantTooLarge bool
+	}{
+		{
+			name:       "debug logs the request",
+			level:      zap.DebugLevel,
+			body:       `{"link":"https://example.com/feed.xml"}`,
+			wantLogged: true,
+			wantBody:   `{"link":"https://example.com/feed.xml"}`,
+		},
+		{
+			name:         "body over the size is left out",
+			level:        zap.DebugLevel,
+			body:         strings.Repeat("a", 65),
+			wantLogged:   true,
+			wantTooLarge: true,
+		},
+		{
+			name:  "info does not log the request",
+			level: zap.InfoLevel,
+			body:  `{"link":"https://example.com/feed.xml"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(tt.level)
+			s := New(nil, zap.New(core), WithRequestLogging(true, 64, []string{"x-api-key"}))
+
+			var handled string
+			h := s.LogMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				handled = string(b)
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/feeds", strings.NewReader(tt.body))
+			req.Header.Set("X-API-Key", "secret")
+			req.Header.Set("Content-Type", "application/json")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.body, handled)
+
+			entries := logs.FilterMessage("request").All()
+			if !tt.wantLogged {
+				assert.Empty(t, entries)
+				return
+			}
+			if !assert.Len(t, entries, 1) {
+				return
+			}
+
+			fields := entries[0].ContextMap()
+			assert.Equal(t, zap.DebugLevel, entries[0].Level)
+			assert.Equal(t, http.MethodPost, fields["method"])
+			assert.Equal(t, "/api/feeds", fields["path"])
+			assert.Equal(t, int64(http.StatusCreated), fields["status"])
+			assert.Contains(t, fields, "duration")
+			assert.Equal(t, map[string]string{"X-Api-Key": "[redacted]", "Content-Type": "application/json"}, fields["headers"])
+			if tt.wantTooLarge {
+				assert.NotContains(t, fields, "body")
+				assert.Equal(t, true, fields["bodyTooLarge"])
+			} else {
+				assert.Equal(t, tt.wantBody, fields["body"])
+			}
+		})
+	}
+}
+
+func TestServer_CORSPreflight(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		origin     string
+		wantOrigin string
+	}{
+		{
+			name:   "same origin only by default",
+			origin: "https://reader.example.com",
+		},
+		{
+			name:       "configured origin",
+			opts:       []Option{WithCORS([]string{"https://reader.example.com", "https://other.example.com"}, nil, nil)},
+			origin:     "https://reader.example.com",
+			wantOrigin: "https://reader.example.com",
+		},
+		{
+			name:   "unlisted origin",
+			opts:   []Option{WithCORS([]string{"https://reader.example.com"}, nil, nil)},
+			origin: "https://evil.example.com",
+		},
+		{
+			name:       "wildcard",
+			opts:       []Option{WithCORS([]string{"*"}, nil, nil)},
+			origin:     "http://localhost:3000",
+			wantOrigin: "*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, zap.NewNop(), tt.opts...)
+
+			req := httptest.NewRequest(http.MethodOptions, "/api/feeds", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+
+	t.Run("configured methods and headers", func(t *testing.T) {
+		s := New(nil, zap.NewNop(), WithCORS([]string{"https://reader.example.com"}, []string{http.MethodGet, http.MethodDelete}, []string{"X-API-Key"}))
+
+		preflight := func(method, headers string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodOptions, "/api/feeds/1", nil)
+			req.Header.Set("Origin", "https://reader.example.com")
+			req.Header.Set("Access-Control-Request-Method", method)
+			req.Header.Set("Access-Control-Request-Headers", headers)
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			return w
+		}
+
+		w := preflight(http.MethodDelete, "x-api-key")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.MethodDelete, w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "X-Api-Key", w.Header().Get("Access-Control-Allow-Headers"))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, preflight(http.MethodPatch, "").Code)
+		assert.Equal(t, http.StatusForbidden, preflight(http.MethodGet, "X-Custom").Code)
+	})
+}
+
+func TestServer_RateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name              string
+		trustForwardedFor bool
+		trustedProxies    []netip.Prefix
+		requests          []*http.Request
+		wantStatus        []int
+	}{
+		{
+			name: "limited by remote address",
+			requests: []*http.Request{
+				newRemoteRequest("10.0.0.1:1234", ""),
+				newRemoteRequest("10.0.0.1:5678", ""),
+				newRemoteRequest("10.0.0.1:1234", ""),
+				newRemoteRequest("10.0.0.2:1234", ""),
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name: "forwarded for is ignored unless trusted",
+			requests: []*http.Request{
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.1"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.2"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.3"),
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:              "limited by forwarded for",
+			trustForwardedFor: true,
+			requests: []*http.Request{
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.1, 10.0.0.1"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.1"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.1"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.2"),
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:           "limited by forwarded for of trusted proxies",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")},
+			requests: []*http.Request{
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.1"),
+				newRemoteRequest("10.0.0.2:1234", "203.0.113.1"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.1"),
+				newRemoteRequest("10.0.0.1:1234", "203.0.113.2"),
+				// the same forwarded for from outside the proxies is the peer's own
+				newRemoteRequest("192.0.2.1:1234", "203.0.113.2"),
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusOK},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, zap.NewNop(), WithRateLimit(0.001, 2, 100, tt.trustForwardedFor), WithTrustedProxies(tt.trustedProxies...))
+			h := s.RateLimitMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			for i, req := range tt.requests {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, req)
+				assert.Equal(t, tt.wantStatus[i], w.Code, "request %d", i)
+				if w.Code == http.StatusTooManyRequests {
+					assert.NotEmpty(t, w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+func TestServer_ClientIP(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     map[string]string
+		want       string
+	}{
+		{name: "no proxy", remoteAddr: "203.0.113.1:1234", want: "203.0.113.1"},
+		{name: "untrusted peer cannot forge forwarded for", remoteAddr: "203.0.113.1:1234", header: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "203.0.113.1"},
+		{name: "untrusted peer cannot forge real ip", remoteAddr: "203.0.113.1:1234", header: map[string]string{"X-Real-IP": "198.51.100.1"}, want: "203.0.113.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", header: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1"},
+		{name: "trusted proxy without forwarded headers", remoteAddr: "10.0.0.1:1234", want: "10.0.0.1"},
+		{name: "real ip from trusted proxy", remoteAddr: "10.0.0.1:1234", header: map[string]string{"X-Real-IP": "198.51.100.1"}, want: "198.51.100.1"},
+		{name: "forwarded for wins over real ip", remoteAddr: "10.0.0.1:1234", header: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, want: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.1:1234", header: map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.3, 10.0.0.2"}, want: "198.51.100.1"},
+		{name: "entries before the first untrusted hop are ignored", remoteAddr: "10.0.0.1:1234", header: map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.1, 10.0.0.2"}, want: "198.51.100.1"},
+		{name: "malformed entry", remoteAddr: "10.0.0.1:1234", header: map[string]string{"X-Forwarded-For": "not an ip, 10.0.0.2"}, want: "10.0.0.2"},
+		{name: "ipv6 proxy", remoteAddr: "[fd00::1]:1234", header: map[string]string{"X-Forwarded-For": "2001:db8::1"}, want: "2001:db8::1"},
+		{name: "ipv4 mapped peer", remoteAddr: "[::ffff:10.0.0.1]:1234", header: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, zap.NewNop(), WithTrustedProxies(proxies...))
+			req := httptest.NewRequest(http.MethodGet, "/api/feeds", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+
+			assert.Equal(t, tt.want, s.clientIP(req))
+		})
+	}
+}
+
+func newRemoteRequest(remoteAddr, forwardedFor string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/feeds", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return req
+}
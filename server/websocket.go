@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kdwils/feedreader/events"
+	"github.com/kdwils/feedreader/pkg/websocket"
+	"github.com/kdwils/feedreader/storage"
+	"go.uber.org/zap"
+)
+
+// wsWriteWait is how long a single websocket write may take before the client is considered gone
+const wsWriteWait = 10 * time.Second
+
+// wsMessage is sent in both directions over the articles websocket.
+// Clients send {"type": "subscribe", "feedIds": [...]} to only receive articles from those feeds, an empty list receives every feed.
+// The server answers with a subscribed message and sends each new article as an article message.
+type wsMessage struct {
+	Type    string           `json:"type"`
+	FeedIDs []string         `json:"feedIds,omitempty"`
+	Article *storage.Article `json:"article,omitempty"`
+}
+
+const (
+	wsSubscribe  = "subscribe"
+	wsSubscribed = "subscribed"
+	wsArticle    = "article"
+)
+
+// wsClient is a websocket connection along with the feeds it subscribed to.
+// Its read pump handles subscribe messages and pongs, and its write pump sends articles and pings.
+// Both pumps write to the connection: reading answers pings and close frames from the read pump, so the connection serializes its writes.
+type wsClient struct {
+	conn      *websocket.Conn
+	heartbeat time.Duration
+	logger    *zap.Logger
+
+	mu    sync.Mutex
+	feeds map[string]bool
+
+	subscribed chan []string
+	done       chan struct{}
+}
+
+func newWSClient(conn *websocket.Conn, heartbeat time.Duration, logger *zap.Logger) *wsClient {
+	return &wsClient{
+		conn:       conn,
+		heartbeat:  heartbeat,
+		logger:     logger,
+		subscribed: make(chan []string, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// wants reports whether the client subscribed to the article's feed
+func (c *wsClient) wants(article *storage.Article) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.feeds) == 0 || c.feeds[article.FeedID]
+}
+
+func (c *wsClient) subscribe(feedIDs []string) {
+	feeds := make(map[string]bool, len(feedIDs))
+	for _, id := range feedIDs {
+		feeds[id] = true
+	}
+
+	c.mu.Lock()
+	c.feeds = feeds
+	c.mu.Unlock()
+
+	// only the latest subscription needs acknowledging
+	select {
+	case <-c.subscribed:
+	default:
+	}
+	c.subscribed <- feedIDs
+}
+
+// readPump reads client messages until the connection fails or the client stops answering pings, then closes done
+func (c *wsClient) readPump() {
+	defer close(c.done)
+
+	pongWait := 2 * c.heartbeat
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func() {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, b, err := c.conn.ReadMessage()
+		if errors.Is(err, websocket.ErrClosed) {
+			return
+		}
+		if err != nil {
+			c.logger.Debug("websocket read failed", zap.Error(err))
+			return
+		}
+
+		var msg wsMessage
+		err = json.Unmarshal(b, &msg)
+		if err != nil || msg.Type != wsSubscribe {
+			c.logger.Info("ignoring unknown websocket message", zap.ByteString("message", b))
+			continue
+		}
+
+		c.subscribe(msg.FeedIDs)
+	}
+}
+
+// writePump sends the client articles it subscribed to and pings it every heartbeat until the context ends or the read pump stops
+func (c *wsClient) writePump(ctx context.Context, published <-chan events.Event) {
+	defer c.conn.Close()
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		case <-heartbeat.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			err = c.conn.WriteMessage(websocket.PingMessage, nil)
+		case feedIDs := <-c.subscribed:
+			err = c.write(wsMessage{Type: wsSubscribed, FeedIDs: feedIDs})
+		case e, ok := <-published:
+			if !ok {
+				return
+			}
+			if e.Type != events.ArticleCreated || !c.wants(e.Article) {
+				continue
+			}
+			err = c.write(wsMessage{Type: wsArticle, Article: e.Article})
+		}
+		if err != nil {
+			c.logger.Debug("websocket write failed", zap.Error(err))
+			return
+		}
+	}
+}
+
+func (c *wsClient) write(msg wsMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return c.conn.WriteMessage(websocket.TextMessage, b)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/kdwils/feedreader/events"
+	"github.com/kdwils/feedreader/storage"
+)
+
+//go:generate mockgen -destination=mocks/mock_service.go -package=mocks github.com/kdwils/feedreader/service Service
+type Service interface {
+	Ping(ctx context.Context) error
+	Stats(ctx context.Context) (storage.Stats, error)
+
+	CreateFeed(ctx context.Context, request CreateFeedRequest) (*storage.Feed, error)
+	CreateFeeds(ctx context.Context, urls []string) []CreateFeedResult
+	GetFeed(ctx context.Context, id string) (*storage.Feed, error)
+	ListFeeds(ctx context.Context, opts *storage.Options) (storage.FeedList, error)
+	ListAllFeeds(ctx context.Context) ([]*storage.Feed, error)
+	DeleteFeed(ctx context.Context, id string) error
+	UpdateFeedTitle(ctx context.Context, id string, request UpdateFeedTitleRequest) (*storage.Feed, error)
+	RefreshFeedByID(ctx context.Context, id string) ([]*storage.Article, error)
+	RefreshFeed(ctx context.Context, feed *storage.Feed) ([]*storage.Article, error)
+	BackfillFeed(ctx context.Context, id string) (int, error)
+	ListFailingFeeds(ctx context.Context) ([]*storage.Feed, error)
+	UnreadCounts(ctx context.Context) (map[string]int, int, error)
+	DiscoverFeeds(ctx context.Context, siteURL string) ([]string, error)
+	ImportOPML(ctx context.Context, r io.Reader) ([]*storage.Feed, []error)
+	ExportOPML(ctx context.Context) ([]byte, error)
+
+	CreateFolder(ctx context.Context, request FolderRequest) (*storage.Folder, error)
+	GetFolder(ctx context.Context, id string) (*storage.Folder, error)
+	ListFolders(ctx context.Context) ([]*storage.Folder, error)
+	RenameFolder(ctx context.Context, id string, request FolderRequest) (*storage.Folder, error)
+	DeleteFolder(ctx context.Context, id string) error
+	AssignFeedToFolder(ctx context.Context, feedID string, request AssignFolderRequest) (*storage.Feed, error)
+	ListFeedsByFolder(ctx context.Context, folderID string, opts *storage.Options) (storage.FeedList, error)
+
+	CreateArticle(ctx context.Context, request CreateArticleRequest) (*storage.Article, error)
+	SubscribeEvents() (<-chan events.Event, func())
+	GetArticle(ctx context.Context, id string) (*storage.Article, error)
+	ListArticleFeeds(ctx context.Context, id string) ([]*storage.Feed, error)
+	FullText(ctx context.Context, id string) (string, error)
+	DeleteArticle(ctx context.Context, id string) error
+	ListArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error)
+	ListArticlesByStatus(ctx context.Context, status storage.Status, opts *storage.Options) (storage.ArticleList, error)
+	ListArticlesBetween(ctx context.Context, status storage.Status, from, to time.Time, opts *storage.Options) (storage.ArticleList, error)
+	ListFilteredArticles(ctx context.Context, filter storage.ArticleFilter, opts *storage.Options) (storage.ArticleList, error)
+	CountArticlesSince(ctx context.Context, filter storage.ArticleFilter, opts *storage.Options) (int, error)
+	ListFavoritedArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error)
+	ListSavedArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error)
+	ListTaggedArticles(ctx context.Context, tag string, opts *storage.Options) (storage.ArticleList, error)
+	ListReadArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error)
+	ListUnreadArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error)
+	ListFeedArticles(ctx context.Context, feedID string, opts *storage.Options) (storage.ArticleList, error)
+	SearchArticles(ctx context.Context, query string, opts *storage.Options) (storage.ArticleList, error)
+	MarkArticleRead(ctx context.Context, id string, request MarkArticleReadRequest) (*storage.Article, error)
+	ReadNext(ctx context.Context, id string, opts *storage.Options) (*storage.Article, *storage.Article, error)
+	MarkAllRead(ctx context.Context, feedID string) (int, error)
+	PruneArticles(ctx context.Context) (int, error)
+	SetArticleFavorited(ctx context.Context, id string, request SetArticleFavoritedRequest) (*storage.Article, error)
+	SetArticleSaved(ctx context.Context, id string, request SetArticleSavedRequest) (*storage.Article, error)
+}
@@ -2,115 +2,811 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/araddon/dateparse"
+	"github.com/kdwils/feedreader/events"
+	"github.com/kdwils/feedreader/pkg/links"
+	"github.com/kdwils/feedreader/pkg/opml"
 	"github.com/kdwils/feedreader/pkg/parser"
+	"github.com/kdwils/feedreader/pkg/sanitize"
 	"github.com/kdwils/feedreader/storage"
+	"go.uber.org/zap"
 )
 
-type Service struct {
-	store  storage.Storage
-	parser parser.Parser
+var (
+	ErrInvalidOPML     = errors.New("invalid opml document")
+	ErrInvalidURL      = links.ErrInvalidURL
+	ErrFeedUnreachable = errors.New("feed could not be fetched")
+	ErrEmptyFolderName = errors.New("folder name is empty")
+	ErrEmptyFeed       = parser.ErrEmptyFeed
+	// ErrArticleUnreachable is returned when the page an article links to could not be fetched for its full text
+	ErrArticleUnreachable = errors.New("article could not be fetched")
+	ErrNoContent          = parser.ErrNoContent
+)
+
+// DefaultConcurrency is how many feeds are fetched at once when creating feeds in bulk
+const DefaultConcurrency = 8
+
+var _ Service = service{}
+
+type service struct {
+	store       storage.Storage
+	parser      parser.Parser
+	events      *events.Bus
+	logger      *zap.Logger
+	concurrency int
+	// dedupeAcrossFeeds skips storing an article a refresh finds when another feed already has it
+	dedupeAcrossFeeds bool
+	// retention is which read articles PruneArticles removes
+	retention storage.Retention
+}
+
+// Option configures optional Service settings
+type Option func(*service)
+
+// WithLogger logs problems with feeds that do not stop them being read, such as dates that cannot be parsed
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *service) {
+		s.logger = logger
+	}
+}
+
+// WithEventBus publishes article events to the bus, so other parts of the reader can subscribe to the same events
+func WithEventBus(bus *events.Bus) Option {
+	return func(s *service) {
+		if bus != nil {
+			s.events = bus
+		}
+	}
+}
+
+// WithCrossFeedDeduplication skips storing an article during a refresh when another feed already has an article with the same canonical link or guid, such as when both a site's main feed and one of its category feeds are subscribed to.
+// The refreshed feed is recorded as carrying the article instead, see ListArticleFeeds.
+func WithCrossFeedDeduplication(enabled bool) Option {
+	return func(s *service) {
+		s.dedupeAcrossFeeds = enabled
+	}
+}
+
+// WithRetention sets which read articles PruneArticles removes, by default none are
+func WithRetention(retention storage.Retention) Option {
+	return func(s *service) {
+		s.retention = retention
+	}
+}
+
+// WithConcurrency sets the maximum number of feeds fetched at the same time when creating feeds in bulk
+func WithConcurrency(concurrency int) Option {
+	return func(s *service) {
+		if concurrency > 0 {
+			s.concurrency = concurrency
+		}
+	}
 }
 
 type CreateFeedRequest struct {
 	Link string `json:"link"`
 }
 
+// CreateFeedResult is the outcome of creating one feed of a batch. Feed is set alongside storage.ErrDuplicateFeed when the feed already exists.
+type CreateFeedResult struct {
+	Link string
+	Feed *storage.Feed
+	Err  error
+}
+
 type CreateArticleRequest struct {
 	storage.Article
 }
 
-func New(store storage.Storage, parser parser.Parser) Service {
-	return Service{
-		store:  store,
-		parser: parser,
+type MarkArticleReadRequest struct {
+	Read bool `json:"read"`
+}
+
+type SetArticleFavoritedRequest struct {
+	Favorited bool `json:"favorited"`
+}
+
+type SetArticleSavedRequest struct {
+	Saved bool `json:"saved"`
+}
+
+type FolderRequest struct {
+	Name string `json:"name"`
+}
+
+// UpdateFeedTitleRequest renames a feed, an empty title goes back to the feed's own title
+type UpdateFeedTitleRequest struct {
+	Title string `json:"title"`
+}
+
+// AssignFolderRequest moves a feed into the folder, an empty folder id leaves the feed uncategorized
+type AssignFolderRequest struct {
+	FolderID string `json:"folderId"`
+}
+
+func New(store storage.Storage, parser parser.Parser, opts ...Option) Service {
+	s := service{
+		store:       store,
+		parser:      parser,
+		events:      events.NewBus(events.DefaultBuffer),
+		logger:      zap.NewNop(),
+		concurrency: DefaultConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return s
+}
+
+// CreateFeed fetches the feed at the request's link and stores it. A feed that already exists is returned along with storage.ErrDuplicateFeed.
+func (s service) CreateFeed(ctx context.Context, request CreateFeedRequest) (*storage.Feed, error) {
+	link, err := links.Normalize(request.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedFeed, err := s.parser.ParseFromURI(ctx, link)
+	// the link was reachable, it just does not serve a feed
+	if errors.Is(err, ErrEmptyFeed) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeedUnreachable, err)
+	}
+
+	channel := parsedFeed.Channel
+	return s.store.CreateFeed(ctx, channel.Title, link, channel.Link, channel.Description, channel.Image, channel.Language, channel.Copyright)
+}
+
+// CreateFeeds creates a feed for every url, fetching up to s.concurrency feeds at once. The results are in the same order as the urls and one url failing does not stop the others.
+func (s service) CreateFeeds(ctx context.Context, urls []string) []CreateFeedResult {
+	results := make([]CreateFeedResult, len(urls))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.concurrency && i < len(urls); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				feed, err := s.CreateFeed(ctx, CreateFeedRequest{Link: urls[i]})
+				results[i] = CreateFeedResult{Link: urls[i], Feed: feed, Err: err}
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// DiscoverFeeds returns the urls of the feeds advertised by the html page at siteURL, so a feed can be chosen before subscribing
+func (s service) DiscoverFeeds(ctx context.Context, siteURL string) ([]string, error) {
+	link, err := links.Normalize(siteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.parser.DiscoverFeeds(ctx, link)
+}
+
+// ImportOPML creates a feed for every outline with an xmlUrl in the OPML document. Failures are collected per feed rather than aborting the import.
+// Each feed is put in a folder named after the outline it is nested in, creating the folder unless one with the same name already exists.
+// A feed that was already subscribed to is reported as a duplicate. It is only put in the folder when it was uncategorized, so folders chosen in the reader are kept.
+func (s service) ImportOPML(ctx context.Context, r io.Reader) ([]*storage.Feed, []error) {
+	doc, err := opml.Parse(r)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%w: %v", ErrInvalidOPML, err)}
+	}
+
+	feeds := make([]*storage.Feed, 0)
+	errs := make([]error, 0)
+	folders := make(map[string]*storage.Folder)
+	for _, sub := range doc.Subscriptions() {
+		feed, err := s.CreateFeed(ctx, CreateFeedRequest{Link: sub.XMLURL})
+		duplicate := errors.Is(err, storage.ErrDuplicateFeed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.XMLURL, err))
+			if !duplicate || feed == nil || feed.FolderID != "" {
+				continue
+			}
+		}
+
+		if sub.Folder != "" {
+			err = s.importIntoFolder(ctx, feed, sub.Folder, folders)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: failed to add to folder %q: %w", sub.XMLURL, sub.Folder, err))
+			}
+		}
+
+		if !duplicate {
+			feeds = append(feeds, feed)
+		}
+	}
+
+	return feeds, errs
+}
+
+// importIntoFolder assigns the feed to the folder with the name, creating the folder when there is none. folders holds the folders used so far by their lower case name.
+func (s service) importIntoFolder(ctx context.Context, feed *storage.Feed, name string, folders map[string]*storage.Folder) error {
+	key := strings.ToLower(name)
+	folder, ok := folders[key]
+	if !ok {
+		var err error
+		folder, err = s.store.CreateFolder(ctx, name)
+		if errors.Is(err, storage.ErrDuplicateFolder) {
+			folder, err = s.findFolder(ctx, name)
+		}
+		if err != nil {
+			return err
+		}
+
+		folders[key] = folder
+	}
+
+	err := s.store.AssignFeedToFolder(ctx, feed.ID, folder.ID)
+	if err != nil {
+		return err
 	}
+
+	feed.FolderID = folder.ID
+	return nil
 }
 
-func (s Service) CreateFeed(ctx context.Context, request CreateFeedRequest) (*storage.Feed, error) {
-	parsedFeed, err := s.parser.ParseFromURI(ctx, request.Link)
+// findFolder returns the folder with the name, ignoring case since folder names are unique regardless of case
+func (s service) findFolder(ctx context.Context, name string) (*storage.Folder, error) {
+	folders, err := s.store.ListFolders(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.store.CreateFeed(ctx, parsedFeed.Channel.Title, request.Link, parsedFeed.Channel.Link, parsedFeed.Channel.Description)
+	for _, f := range folders {
+		if strings.EqualFold(f.Name, strings.TrimSpace(name)) {
+			return f, nil
+		}
+	}
+
+	return nil, storage.ErrNotFound
 }
 
-func (s Service) CreateArticle(ctx context.Context, request CreateArticleRequest) (*storage.Article, error) {
-	publishedTime, err := dateparse.ParseAny(request.Published)
+// ExportOPML serializes every stored feed into an OPML 2.0 document
+func (s service) ExportOPML(ctx context.Context) ([]byte, error) {
+	doc := opml.New("feedreader subscriptions")
+
+	feeds, err := s.ListAllFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range feeds {
+		doc.Body.Outlines = append(doc.Body.Outlines, opml.Outline{
+			Text:    f.Title,
+			Title:   f.Title,
+			Type:    "rss",
+			XMLURL:  f.RSSLink,
+			HTMLURL: f.SiteLink,
+		})
+	}
+
+	return doc.Marshal()
+}
+
+// CreateArticle stores the article along with its publish date as the feed wrote it, since the parsed time loses the timezone.
+// An article whose publish date cannot be parsed is still stored, dated when it was fetched.
+func (s service) CreateArticle(ctx context.Context, request CreateArticleRequest) (*storage.Article, error) {
+	original := strings.TrimSpace(request.Published)
+	publishedTime, err := dateparse.ParseAny(original)
+	if err != nil {
+		s.logger.Warn("failed to parse article publish date, using the fetch time", zap.String("link", request.Link), zap.String("published", request.Published), zap.Error(err))
+		publishedTime = s.store.Now()
+	}
+
+	description, content := sanitizeDescription(request.Description, request.Content)
+	article, err := s.store.CreateArticle(ctx, request.FeedID, request.Link, request.GUID, request.Title, request.Author, description, content, request.Enclosure, request.Tags, publishedTime, original)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.store.CreateArticle(ctx, request.Link, request.Title, request.Author, request.Description, publishedTime)
+	s.events.Publish(events.Event{Type: events.ArticleCreated, Article: article})
+	return article, nil
+}
+
+// SubscribeEvents returns a channel receiving every article event published from now on. Call unsubscribe once the caller stops reading.
+func (s service) SubscribeEvents() (<-chan events.Event, func()) {
+	return s.events.Subscribe()
+}
+
+func (s service) GetArticle(ctx context.Context, id string) (*storage.Article, error) {
+	return s.store.GetArticle(ctx, id)
+}
+
+// ListArticleFeeds returns the feeds carrying the article, the feed it is stored under first
+func (s service) ListArticleFeeds(ctx context.Context, id string) ([]*storage.Feed, error) {
+	return s.store.ListArticleFeeds(ctx, id)
+}
+
+// FullText returns the readable content of the page the article links to. The page is fetched the first time and the cached copy is returned after that.
+func (s service) FullText(ctx context.Context, id string) (string, error) {
+	cached, err := s.store.GetArticleFullText(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if cached != "" {
+		return cached, nil
+	}
+
+	article, err := s.store.GetArticle(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := s.parser.FullText(ctx, article.Link)
+	// the page was reachable, it just has nothing to extract
+	if errors.Is(err, ErrNoContent) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrArticleUnreachable, err)
+	}
+
+	err = s.store.SetArticleFullText(ctx, id, content)
+	if err != nil {
+		return "", err
+	}
+
+	return content, nil
 }
 
-func (s Service) ListArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
+func (s service) ListArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
 	return s.store.ListArticles(ctx, opts)
 }
 
-func (s Service) ListFavoritedArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
+func (s service) ListArticlesByStatus(ctx context.Context, status storage.Status, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.ListArticlesByStatus(ctx, status, opts)
+}
+
+// ListArticlesBetween lists the articles with the status published within the range, a zero time leaves that end open
+func (s service) ListArticlesBetween(ctx context.Context, status storage.Status, from, to time.Time, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.ListArticlesBetween(ctx, status, from, to, opts)
+}
+
+// ListFilteredArticles lists the articles matching every field of the filter
+func (s service) ListFilteredArticles(ctx context.Context, filter storage.ArticleFilter, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.ListFilteredArticles(ctx, filter, opts)
+}
+
+// CountArticlesSince counts the articles matching the filter that came ahead of the options' cursor, see storage.Cursor.Head
+func (s service) CountArticlesSince(ctx context.Context, filter storage.ArticleFilter, opts *storage.Options) (int, error) {
+	return s.store.CountArticlesSince(ctx, filter, opts)
+}
+
+func (s service) ListFavoritedArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
 	return s.store.ListFavoritedArticles(ctx, opts)
 }
 
-func (s Service) ListReadArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
+func (s service) ListSavedArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.ListSavedArticles(ctx, opts)
+}
+
+func (s service) ListTaggedArticles(ctx context.Context, tag string, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.ListTaggedArticles(ctx, tag, opts)
+}
+
+func (s service) ListReadArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
 	return s.store.ListReadArticles(ctx, opts)
 }
 
-func (s Service) ListUnreadArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
+func (s service) ListUnreadArticles(ctx context.Context, opts *storage.Options) (storage.ArticleList, error) {
 	return s.store.ListUnreadArticles(ctx, opts)
 }
 
-func (s Service) ListFeeds(ctx context.Context, opts *storage.Options) (storage.FeedList, error) {
+func (s service) DeleteFeed(ctx context.Context, id string) error {
+	return s.store.DeleteFeed(ctx, id)
+}
+
+func (s service) CreateFolder(ctx context.Context, request FolderRequest) (*storage.Folder, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, ErrEmptyFolderName
+	}
+
+	return s.store.CreateFolder(ctx, name)
+}
+
+func (s service) GetFolder(ctx context.Context, id string) (*storage.Folder, error) {
+	return s.store.GetFolder(ctx, id)
+}
+
+func (s service) ListFolders(ctx context.Context) ([]*storage.Folder, error) {
+	return s.store.ListFolders(ctx)
+}
+
+func (s service) RenameFolder(ctx context.Context, id string, request FolderRequest) (*storage.Folder, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, ErrEmptyFolderName
+	}
+
+	return s.store.RenameFolder(ctx, id, name)
+}
+
+// DeleteFolder removes the folder, its feeds become uncategorized
+func (s service) DeleteFolder(ctx context.Context, id string) error {
+	return s.store.DeleteFolder(ctx, id)
+}
+
+// UpdateFeedTitle sets the title the feed is shown with and returns the updated feed
+func (s service) UpdateFeedTitle(ctx context.Context, id string, request UpdateFeedTitleRequest) (*storage.Feed, error) {
+	err := s.store.UpdateFeedTitle(ctx, id, strings.TrimSpace(request.Title))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetFeed(ctx, id)
+}
+
+// AssignFeedToFolder moves the feed into the request's folder and returns the updated feed
+func (s service) AssignFeedToFolder(ctx context.Context, feedID string, request AssignFolderRequest) (*storage.Feed, error) {
+	err := s.store.AssignFeedToFolder(ctx, feedID, strings.TrimSpace(request.FolderID))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.GetFeed(ctx, feedID)
+}
+
+// ListFeedsByFolder returns a page of the folder's feeds, or of the uncategorized feeds when folderID is empty
+func (s service) ListFeedsByFolder(ctx context.Context, folderID string, opts *storage.Options) (storage.FeedList, error) {
+	return s.store.ListFeedsByFolder(ctx, folderID, opts)
+}
+
+// DeleteArticle removes the article for good, refreshing its feed will not store it again
+func (s service) DeleteArticle(ctx context.Context, id string) error {
+	return s.store.DeleteArticle(ctx, id)
+}
+
+func (s service) MarkArticleRead(ctx context.Context, id string, request MarkArticleReadRequest) (*storage.Article, error) {
+	return s.store.MarkArticleRead(ctx, id, request.Read)
+}
+
+// ReadNext marks the article read and returns it along with the next unread article in the sort order of opts, for reading articles one after another.
+// The next article is nil when no unread articles are left.
+func (s service) ReadNext(ctx context.Context, id string, opts *storage.Options) (*storage.Article, *storage.Article, error) {
+	article, err := s.store.MarkArticleRead(ctx, id, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next, err := s.store.NextUnreadArticle(ctx, article, opts)
+	if errors.Is(err, storage.ErrNotFound) {
+		return article, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return article, next, nil
+}
+
+// MarkAllRead marks the feed's unread articles as read, or every unread article when feedID is empty, and returns how many were marked.
+// storage.ErrNotFound is returned when the feed does not exist.
+func (s service) MarkAllRead(ctx context.Context, feedID string) (int, error) {
+	if feedID != "" {
+		_, err := s.store.GetFeed(ctx, feedID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return s.store.MarkAllRead(ctx, feedID)
+}
+
+// PruneArticles removes the read articles outside the retention and returns how many were removed, see storage.Retention
+func (s service) PruneArticles(ctx context.Context) (int, error) {
+	return s.store.PruneArticles(ctx, s.retention)
+}
+
+func (s service) SetArticleFavorited(ctx context.Context, id string, request SetArticleFavoritedRequest) (*storage.Article, error) {
+	return s.store.SetArticleFavorited(ctx, id, request.Favorited)
+}
+
+func (s service) SetArticleSaved(ctx context.Context, id string, request SetArticleSavedRequest) (*storage.Article, error) {
+	return s.store.SetArticleSaved(ctx, id, request.Saved)
+}
+
+func (s service) ListFeedArticles(ctx context.Context, feedID string, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.ListFeedArticles(ctx, feedID, opts)
+}
+
+func (s service) SearchArticles(ctx context.Context, query string, opts *storage.Options) (storage.ArticleList, error) {
+	return s.store.SearchArticles(ctx, query, opts)
+}
+
+// Ping checks the storage backend can be reached
+func (s service) Ping(ctx context.Context) error {
+	return s.store.Ping(ctx)
+}
+
+// Stats summarizes the feeds and articles in the reader
+func (s service) Stats(ctx context.Context) (storage.Stats, error) {
+	return s.store.Stats(ctx)
+}
+
+// UnreadCounts returns the number of unread articles keyed by feed id, along with the total across every feed
+func (s service) UnreadCounts(ctx context.Context) (map[string]int, int, error) {
+	counts, err := s.store.UnreadCounts(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+
+	return counts, total, nil
+}
+
+func (s service) GetFeed(ctx context.Context, id string) (*storage.Feed, error) {
+	return s.store.GetFeed(ctx, id)
+}
+
+func (s service) ListFeeds(ctx context.Context, opts *storage.Options) (storage.FeedList, error) {
 	return s.store.ListFeeds(ctx, opts)
 }
 
-func (s Service) RefreshFeed(ctx context.Context, feed *storage.Feed) ([]*storage.Article, error) {
-	feeds, err := s.parser.ParseFromURI(ctx, feed.RSSLink)
+// ListAllFeeds pages through every stored feed
+func (s service) ListAllFeeds(ctx context.Context) ([]*storage.Feed, error) {
+	feeds := make([]*storage.Feed, 0)
+	opts := storage.DefaultOptions()
+	for {
+		feedList, err := s.store.ListFeeds(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		feeds = append(feeds, feedList.Feeds...)
+		if !feedList.HasNext {
+			return feeds, nil
+		}
+		opts.Cursor = feedList.Next
+	}
+}
+
+// RefreshFeedByID loads the feed with the id and refreshes it
+func (s service) RefreshFeedByID(ctx context.Context, id string) ([]*storage.Article, error) {
+	feed, err := s.store.GetFeed(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.RefreshFeed(ctx, feed)
+}
+
+// RefreshFeed stores the feed's new articles. When some articles fail to store, the articles that were stored are returned along with the joined errors.
+// A feed that cannot be fetched or parsed returns an error wrapping ErrFeedUnreachable. The outcome is recorded as the feed's health.
+func (s service) RefreshFeed(ctx context.Context, feed *storage.Feed) ([]*storage.Article, error) {
+	articles, err := s.refreshFeed(ctx, feed)
+	// a refresh cut short by ctx says nothing about the feed, and ctx could not be used to record it anyway
+	if ctx.Err() != nil {
+		return articles, err
+	}
+
+	var fetchError string
+	if err != nil {
+		fetchError = err.Error()
+	}
+
+	recordErr := s.store.RecordFeedFetch(ctx, feed.ID, fetchError)
+	if recordErr != nil {
+		s.logger.Error("failed to record feed health", zap.String("feed", feed.ID), zap.Error(recordErr))
+	}
+
+	return articles, err
+}
+
+// ListFailingFeeds returns the feeds whose last refresh failed
+func (s service) ListFailingFeeds(ctx context.Context) ([]*storage.Feed, error) {
+	return s.store.ListFailingFeeds(ctx)
+}
+
+func (s service) refreshFeed(ctx context.Context, feed *storage.Feed) ([]*storage.Article, error) {
+	feeds, err := s.parser.ConditionalParseFromURI(ctx, feed.RSSLink, feed.ETag, feed.LastModified)
+	if errors.Is(err, parser.ErrNotModified) {
+		return make([]*storage.Article, 0), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFeedUnreachable, err)
+	}
+
+	if feeds.RedirectURL != "" && !links.Equal(feeds.RedirectURL, feed.RSSLink) {
+		err = s.store.UpdateFeedURL(ctx, feed.ID, feeds.RedirectURL)
+		if err != nil {
+			return nil, err
+		}
+		feed.RSSLink = feeds.RedirectURL
+	}
+
 	articles, err := s.store.ListArticlesByFeed(ctx, feed.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	// an item that fails to store, such as one with an unparseable date, should not stop the rest of the feed from being stored
+	var errs []error
 	newArticles := make([]parser.Item, 0)
 	for _, fa := range feeds.Channel.Items {
-		var contains bool
-		for _, a := range articles {
-			if strings.EqualFold(fa.Link, a.Link) {
-				contains = true
-			}
+		stored := storedArticle(fa, articles)
+		if stored == nil {
+			newArticles = append(newArticles, fa)
+			continue
 		}
 
-		if !contains {
-			newArticles = append(newArticles, fa)
+		if contentChanged(fa, stored) {
+			description, content := sanitizeDescription(fa.Description, fa.ContentEncoded)
+			err = s.store.UpdateArticleContent(ctx, stored.ID, fa.Title, description, content)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", fa.Link, err))
+			}
 		}
 	}
 
 	storedArticles := make([]*storage.Article, 0)
 	for _, a := range newArticles {
+		if s.dedupeAcrossFeeds {
+			duplicate, err := s.store.FindDuplicateArticle(ctx, feed.ID, a.Link, a.GUID)
+			if err == nil {
+				// the article stays under the feed that stored it first, this feed is only recorded as carrying it
+				err = s.store.AddArticleFeed(ctx, duplicate.ID, feed.ID)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", a.Link, err))
+				}
+				continue
+			}
+			if !errors.Is(err, storage.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("%s: %w", a.Link, err))
+				continue
+			}
+		}
+
 		request := CreateArticleRequest{
 			Article: storage.Article{
+				FeedID:      feed.ID,
 				Link:        a.Link,
+				GUID:        a.GUID,
 				Title:       a.Title,
 				Description: a.Description,
+				Content:     a.ContentEncoded,
+				Enclosure:   enclosure(a.Enclosure),
+				Tags:        a.Categories,
 				Author:      a.Author,
 				Published:   a.PubDate,
 			},
 		}
 
 		new, err := s.CreateArticle(ctx, request)
+		// the link may already be stored under another feed or have been deleted, neither is a reason to stop the refresh
+		if errors.Is(err, storage.ErrDuplicateArticle) || errors.Is(err, storage.ErrArticleDeleted) {
+			continue
+		}
 		if err != nil {
-			return nil, err
+			errs = append(errs, fmt.Errorf("%s: %w", a.Link, err))
+			continue
 		}
 
 		storedArticles = append(storedArticles, new)
 	}
 
+	if len(errs) > 0 {
+		return storedArticles, errors.Join(errs...)
+	}
+
+	// validators are only stored once every new article is saved so a failed refresh refetches the full feed
+	if feeds.ETag != feed.ETag || feeds.LastModified != feed.LastModified {
+		err = s.store.SetFeedCacheHeaders(ctx, feed.ID, feeds.ETag, feeds.LastModified)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return storedArticles, nil
 }
+
+// BackfillFeed refetches the feed and fills in fields of its stored articles that are missing or have since changed, such as authors, categories, and content
+// the parser did not read when they were stored. Items that are not stored are left for a refresh to create. Returns how many articles were updated.
+func (s service) BackfillFeed(ctx context.Context, id string) (int, error) {
+	feed, err := s.store.GetFeed(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	// the feed is fetched without its cache validators since an unchanged feed still holds the fields to backfill
+	parsed, err := s.parser.ParseFromURI(ctx, feed.RSSLink)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFeedUnreachable, err)
+	}
+
+	articles, err := s.store.ListArticlesByFeed(ctx, feed.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	updated := 0
+	for _, item := range parsed.Channel.Items {
+		stored := storedArticle(item, articles)
+		if stored == nil {
+			continue
+		}
+
+		_, content := sanitizeDescription(item.Description, item.ContentEncoded)
+		changed, err := s.store.BackfillArticle(ctx, stored.ID, item.GUID, item.Author, content, enclosure(item.Enclosure), item.Categories)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.Link, err))
+			continue
+		}
+
+		if changed {
+			updated++
+		}
+	}
+
+	return updated, errors.Join(errs...)
+}
+
+// storedArticle returns the stored article the item was stored as, or nil when it is new
+func storedArticle(item parser.Item, articles []*storage.Article) *storage.Article {
+	for _, a := range articles {
+		// feeds may change an item's link or guid between fetches, so a match on either means it is already stored
+		if links.Equal(item.Link, a.Link) || (item.GUID != "" && item.GUID == a.GUID) {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// contentChanged reports whether the feed edited an item that is already stored. An item that drops its title is not treated as an edit since articles require one.
+func contentChanged(item parser.Item, stored *storage.Article) bool {
+	if item.Title == "" {
+		return false
+	}
+
+	description, content := sanitizeDescription(item.Description, item.ContentEncoded)
+	return item.Title != stored.Title || description != stored.Description || content != stored.Content
+}
+
+// sanitizeDescription strips unsafe html from a description since clients may render it directly.
+// The original description is kept as the content when the feed has no separate content so it can still be rendered in full.
+func sanitizeDescription(description, content string) (string, string) {
+	if content == "" {
+		content = description
+	}
+
+	return sanitize.HTML(description), content
+}
+
+func enclosure(e *parser.Enclosure) *storage.Enclosure {
+	if e == nil {
+		return nil
+	}
+
+	return &storage.Enclosure{
+		URL:    e.URL,
+		Type:   e.Type,
+		Length: e.Length,
+	}
+}
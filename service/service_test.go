@@ -0,0 +1,828 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kdwils/feedreader/events"
+	"github.com/kdwils/feedreader/pkg/parser"
+	parserMocks "github.com/kdwils/feedreader/pkg/parser/mocks"
+	"github.com/kdwils/feedreader/storage"
+	storageMocks "github.com/kdwils/feedreader/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestService_ExportImportOPML(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	s := New(store, p)
+	ctx := context.Background()
+
+	feeds := []*storage.Feed{
+		{
+			ID:          "2",
+			Title:       "blog.kyledev.co",
+			RSSLink:     "https://blog.kyledev.co/index.xml",
+			SiteLink:    "https://blog.kyledev.co",
+			Description: "Recent content on blog.kyledev.co",
+		},
+		{
+			ID:          "1",
+			Title:       "example",
+			RSSLink:     "https://example.com/feed.xml",
+			SiteLink:    "https://example.com",
+			Description: "example feed",
+		},
+	}
+
+	store.EXPECT().ListFeeds(ctx, storage.DefaultOptions()).Return(storage.FeedList{
+		Cursor: storage.Cursor{HasNext: true, Next: "2"},
+		Feeds:  feeds[:1],
+	}, nil)
+	store.EXPECT().ListFeeds(ctx, &storage.Options{Limit: 10, Order: storage.Descending, Cursor: "2"}).Return(storage.FeedList{
+		Feeds: feeds[1:],
+	}, nil)
+
+	b, err := s.ExportOPML(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range feeds {
+		p.EXPECT().ParseFromURI(ctx, f.RSSLink).Return(&parser.RSSFeed{
+			Channel: parser.Channel{
+				Title:       f.Title,
+				Link:        f.SiteLink,
+				Description: f.Description,
+			},
+		}, nil)
+		store.EXPECT().CreateFeed(ctx, f.Title, f.RSSLink, f.SiteLink, f.Description, f.Image, f.Language, f.Copyright).Return(f, nil)
+	}
+
+	imported, errs := s.ImportOPML(ctx, bytes.NewReader(b))
+	assert.Empty(t, errs)
+	assert.Equal(t, feeds, imported)
+}
+
+func TestService_ImportOPMLFolders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	p := parserMocks.NewMockParser(ctrl)
+	store := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := New(store, p)
+	ctx := context.Background()
+
+	p.EXPECT().ParseFromURI(ctx, gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, uri string) (*parser.RSSFeed, error) {
+		return &parser.RSSFeed{Channel: parser.Channel{Title: uri, Link: uri}}, nil
+	})
+
+	news, err := store.CreateFolder(ctx, "News")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reading, err := store.CreateFolder(ctx, "reading")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filed, err := s.CreateFeed(ctx, CreateFeedRequest{Link: "https://filed.com/feed.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AssignFeedToFolder(ctx, filed.ID, reading.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncategorized, err := s.CreateFeed(ctx, CreateFeedRequest{Link: "https://uncategorized.com/feed.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+	<opml version="2.0">
+		<head><title>subscriptions</title></head>
+		<body>
+			<outline text="news">
+				<outline text="a" type="rss" xmlUrl="https://a.com/feed.xml"/>
+				<outline text="filed" type="rss" xmlUrl="https://filed.com/feed.xml"/>
+			</outline>
+			<outline text="tech">
+				<outline text="b" type="rss" xmlUrl="https://b.com/feed.xml"/>
+				<outline text="uncategorized" type="rss" xmlUrl="https://uncategorized.com/feed.xml"/>
+			</outline>
+			<outline title="Tech">
+				<outline text="c" type="rss" xmlUrl="https://c.com/feed.xml"/>
+			</outline>
+			<outline text="top" type="rss" xmlUrl="https://top.com/feed.xml"/>
+		</body>
+	</opml>`
+
+	imported, errs := s.ImportOPML(ctx, strings.NewReader(doc))
+	if assert.Len(t, errs, 2) {
+		for _, err := range errs {
+			assert.ErrorIs(t, err, storage.ErrDuplicateFeed)
+		}
+	}
+	assert.Len(t, imported, 4)
+
+	folders, err := store.ListFolders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the existing news folder is reused and both spellings of tech share a folder
+	if assert.Len(t, folders, 3) {
+		assert.Equal(t, []string{"News", "reading", "tech"}, []string{folders[0].Name, folders[1].Name, folders[2].Name})
+	}
+	tech := folders[2]
+
+	want := map[string]string{
+		"https://a.com/feed.xml":             news.ID,
+		"https://b.com/feed.xml":             tech.ID,
+		"https://c.com/feed.xml":             tech.ID,
+		"https://top.com/feed.xml":           "",
+		"https://filed.com/feed.xml":         reading.ID,
+		"https://uncategorized.com/feed.xml": tech.ID,
+	}
+	got := make(map[string]string)
+	for _, id := range []string{filed.ID, uncategorized.ID} {
+		f, err := store.GetFeed(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.RSSLink] = f.FolderID
+	}
+	for _, f := range imported {
+		stored, err := store.GetFeed(ctx, f.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, stored.FolderID, f.FolderID, "the imported feeds are returned with their folder")
+		got[f.RSSLink] = stored.FolderID
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestService_CreateFeeds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	s := New(store, p, WithConcurrency(2))
+	ctx := context.Background()
+
+	valid := &storage.Feed{ID: "1", Title: "valid", RSSLink: "https://example.com/valid.xml"}
+	duplicate := &storage.Feed{ID: "2", Title: "duplicate", RSSLink: "https://example.com/duplicate.xml"}
+
+	var running, maxRunning int32
+	p.EXPECT().ParseFromURI(ctx, gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, uri string) (*parser.RSSFeed, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		switch uri {
+		case valid.RSSLink:
+			return &parser.RSSFeed{Channel: parser.Channel{Title: valid.Title}}, nil
+		case duplicate.RSSLink:
+			return &parser.RSSFeed{Channel: parser.Channel{Title: duplicate.Title}}, nil
+		default:
+			return nil, errors.New("connection refused")
+		}
+	})
+	store.EXPECT().CreateFeed(ctx, valid.Title, valid.RSSLink, "", "", "", "", "").Return(valid, nil)
+	store.EXPECT().CreateFeed(ctx, duplicate.Title, duplicate.RSSLink, "", "", "", "", "").Return(duplicate, storage.ErrDuplicateFeed)
+
+	results := s.CreateFeeds(ctx, []string{valid.RSSLink, "not a url", duplicate.RSSLink, "https://unreachable.example.com/feed.xml"})
+	if !assert.Len(t, results, 4) {
+		return
+	}
+
+	assert.Equal(t, CreateFeedResult{Link: valid.RSSLink, Feed: valid}, results[0])
+	assert.Equal(t, "not a url", results[1].Link)
+	assert.True(t, errors.Is(results[1].Err, ErrInvalidURL))
+	assert.Equal(t, duplicate, results[2].Feed)
+	assert.True(t, errors.Is(results[2].Err, storage.ErrDuplicateFeed))
+	assert.Nil(t, results[3].Feed)
+	assert.True(t, errors.Is(results[3].Err, ErrFeedUnreachable))
+	assert.LessOrEqual(t, maxRunning, int32(2))
+}
+
+func TestService_RefreshFeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	s := New(store, p)
+	ctx := context.Background()
+
+	feed := &storage.Feed{
+		ID:      "1",
+		RSSLink: "https://example.com/feed.xml",
+	}
+
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{
+		ETag:        `"v1"`,
+		RedirectURL: "https://example.com/moved.xml",
+		Channel: parser.Channel{
+			Items: []parser.Item{
+				{Title: "same link", Link: "https://example.com/a", GUID: "new-guid-a", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+				{Title: "same guid", Link: "https://example.com/b-moved", GUID: "guid-b", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+				{Title: "new", Link: "https://example.com/c", GUID: "guid-c", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+				{Title: "stored by another feed", Link: "https://example.com/d", GUID: "guid-d", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+				{Title: "same link spelled differently", Link: "HTTPS://Example.com:443/e/", GUID: "new-guid-e", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+			},
+		},
+	}, nil)
+
+	store.EXPECT().UpdateFeedURL(ctx, feed.ID, "https://example.com/moved.xml").Return(nil)
+	store.EXPECT().ListArticlesByFeed(ctx, feed.ID).Return([]*storage.Article{
+		{Link: "https://example.com/a", GUID: "guid-a", Title: "same link"},
+		{Link: "https://example.com/b", GUID: "guid-b", Title: "same guid"},
+		{Link: "https://example.com/e", GUID: "guid-e", Title: "same link spelled differently"},
+	}, nil)
+
+	created := &storage.Article{ID: "3", Link: "https://example.com/c", GUID: "guid-c"}
+	store.EXPECT().CreateArticle(ctx, feed.ID, "https://example.com/c", "guid-c", "new", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(created, nil)
+	store.EXPECT().CreateArticle(ctx, feed.ID, "https://example.com/d", "guid-d", "stored by another feed", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(nil, storage.ErrDuplicateArticle)
+	store.EXPECT().SetFeedCacheHeaders(ctx, feed.ID, `"v1"`, "").Return(nil)
+	store.EXPECT().RecordFeedFetch(ctx, feed.ID, "").Return(nil)
+
+	articles, err := s.RefreshFeed(ctx, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*storage.Article{created}, articles)
+	assert.Equal(t, "https://example.com/moved.xml", feed.RSSLink)
+}
+
+func TestService_RefreshFeedDeduplicatesAcrossFeeds(t *testing.T) {
+	// the category feed repeats the main feed's articles under www and with tracking parameters
+	items := []parser.Item{
+		{Title: "in the main feed", Link: "https://www.example.com/posts/1?utm_source=category", GUID: "category-1", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+		{Title: "only in the category", Link: "https://www.example.com/posts/2", GUID: "category-2", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+	}
+	mainArticle := &storage.Article{ID: "1", FeedID: "main", Link: "https://example.com/posts/1"}
+	created := &storage.Article{ID: "2", FeedID: "category", Link: "https://www.example.com/posts/2"}
+
+	tests := []struct {
+		name   string
+		dedupe bool
+		want   []*storage.Article
+	}{
+		{name: "deduplicated", dedupe: true, want: []*storage.Article{created}},
+		{name: "not deduplicated", dedupe: false, want: []*storage.Article{{ID: "3"}, created}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := storageMocks.NewMockStorage(ctrl)
+			p := parserMocks.NewMockParser(ctrl)
+			s := New(store, p, WithCrossFeedDeduplication(tt.dedupe))
+			ctx := context.Background()
+
+			feed := &storage.Feed{ID: "category", RSSLink: "https://www.example.com/category/go.xml"}
+			p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{Channel: parser.Channel{Items: items}}, nil)
+			store.EXPECT().ListArticlesByFeed(ctx, feed.ID).Return(nil, nil)
+			store.EXPECT().RecordFeedFetch(ctx, feed.ID, "").Return(nil)
+
+			if tt.dedupe {
+				store.EXPECT().FindDuplicateArticle(ctx, feed.ID, items[0].Link, items[0].GUID).Return(mainArticle, nil)
+				store.EXPECT().AddArticleFeed(ctx, mainArticle.ID, feed.ID).Return(nil)
+				store.EXPECT().FindDuplicateArticle(ctx, feed.ID, items[1].Link, items[1].GUID).Return(nil, storage.ErrNotFound)
+			} else {
+				store.EXPECT().CreateArticle(ctx, feed.ID, items[0].Link, items[0].GUID, items[0].Title, "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(&storage.Article{ID: "3"}, nil)
+			}
+			store.EXPECT().CreateArticle(ctx, feed.ID, items[1].Link, items[1].GUID, items[1].Title, "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(created, nil)
+
+			articles, err := s.RefreshFeed(ctx, feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, articles)
+		})
+	}
+}
+
+func TestService_RefreshOverlappingFeeds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	p := parserMocks.NewMockParser(ctrl)
+	store := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := New(store, p, WithCrossFeedDeduplication(true))
+	ctx := context.Background()
+
+	mainFeed, err := store.CreateFeed(ctx, "main", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the site's category feed shares the main feed's site
+	category, err := store.CreateFeed(ctx, "category", "https://example.com/category/go.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := func(link, guid string) parser.Item {
+		return parser.Item{Title: "post", Link: link, GUID: guid, Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"}
+	}
+	refresh := func(feed *storage.Feed, items ...parser.Item) []*storage.Article {
+		t.Helper()
+		p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{Channel: parser.Channel{Items: items}}, nil)
+		articles, err := s.RefreshFeed(ctx, feed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return articles
+	}
+
+	stored := refresh(mainFeed, item("https://example.com/posts/1", "1"), item("https://example.com/posts/2", "2"))
+	assert.Len(t, stored, 2)
+
+	// the first post is linked differently and the second shares its guid, only the third is new
+	stored = refresh(category,
+		item("http://www.example.com/posts/1/?utm_source=go", "category-1"),
+		item("https://example.com/2023/04/post-2", "2"),
+		item("https://example.com/posts/3", "3"),
+	)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "https://example.com/posts/3", stored[0].Link)
+		assert.Equal(t, category.ID, stored[0].FeedID, "new articles belong to the feed being refreshed rather than the site's first feed")
+	}
+
+	articles, err := store.ListArticlesByStatus(ctx, storage.StatusAll, nil)
+	assert.NoError(t, err)
+	assert.Len(t, articles.Articles, 3)
+
+	feeds, err := s.ListArticleFeeds(ctx, "1")
+	if assert.NoError(t, err) && assert.Len(t, feeds, 2) {
+		assert.Equal(t, []string{mainFeed.ID, category.ID}, []string{feeds[0].ID, feeds[1].ID})
+	}
+
+	// refreshing again finds the same duplicates without storing them
+	stored = refresh(category, item("http://www.example.com/posts/1/?utm_source=go", "category-1"))
+	assert.Empty(t, stored)
+
+	categoryArticles, err := store.ListArticlesByFeed(ctx, category.ID)
+	if assert.NoError(t, err) && assert.Len(t, categoryArticles, 1) {
+		assert.Equal(t, "https://example.com/posts/3", categoryArticles[0].Link)
+	}
+
+	// another site numbering its articles the same way is not a duplicate
+	unrelated, err := store.CreateFeed(ctx, "unrelated", "https://unrelated.com/feed.xml", "https://unrelated.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored = refresh(unrelated, item("https://unrelated.com/posts/2", "2"))
+	assert.Len(t, stored, 1)
+}
+
+func TestService_RefreshFeedNotModified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	s := New(store, p)
+	ctx := context.Background()
+
+	feed := &storage.Feed{
+		ID:           "1",
+		RSSLink:      "https://example.com/feed.xml",
+		ETag:         `"v1"`,
+		LastModified: "Tue, 25 Apr 2023 00:00:00 GMT",
+	}
+
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, feed.ETag, feed.LastModified).Return(nil, parser.ErrNotModified)
+	store.EXPECT().RecordFeedFetch(ctx, feed.ID, "").Return(nil)
+
+	articles, err := s.RefreshFeed(ctx, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, articles)
+}
+
+func TestService_RefreshFeedPartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	p := parserMocks.NewMockParser(ctrl)
+	s := New(store, p)
+	ctx := context.Background()
+
+	feed := &storage.Feed{
+		ID:      "1",
+		RSSLink: "https://example.com/feed.xml",
+	}
+
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{
+		ETag: `"v1"`,
+		Channel: parser.Channel{
+			Items: []parser.Item{
+				{Title: "first", Link: "https://example.com/a", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+				{Title: "failed", Link: "https://example.com/b", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+				{Title: "last", Link: "https://example.com/c", Author: "author", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"},
+			},
+		},
+	}, nil)
+
+	store.EXPECT().ListArticlesByFeed(ctx, feed.ID).Return([]*storage.Article{}, nil)
+
+	first := &storage.Article{ID: "1", Link: "https://example.com/a"}
+	last := &storage.Article{ID: "2", Link: "https://example.com/c"}
+	store.EXPECT().CreateArticle(ctx, feed.ID, "https://example.com/a", "", "first", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(first, nil)
+	store.EXPECT().CreateArticle(ctx, feed.ID, "https://example.com/b", "", "failed", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(nil, errors.New("disk full"))
+	store.EXPECT().CreateArticle(ctx, feed.ID, "https://example.com/c", "", "last", "author", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(last, nil)
+	store.EXPECT().RecordFeedFetch(ctx, feed.ID, "https://example.com/b: disk full").Return(nil)
+
+	articles, err := s.RefreshFeed(ctx, feed)
+	assert.ErrorContains(t, err, "https://example.com/b")
+	assert.Equal(t, []*storage.Article{first, last}, articles)
+}
+
+func TestService_CreateArticleUnparseableDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	core, logs := observer.New(zap.WarnLevel)
+	s := New(store, parserMocks.NewMockParser(ctrl), WithLogger(zap.New(core)))
+	ctx := context.Background()
+
+	fetched := time.Date(2023, 4, 25, 12, 0, 0, 0, time.UTC)
+	store.EXPECT().Now().Return(fetched)
+	store.EXPECT().CreateArticle(ctx, "", "https://example.com/a", "", "title", "author", "", "", nil, nil, fetched, "the 25th of April, teatime").Return(&storage.Article{ID: "1"}, nil)
+
+	_, err := s.CreateArticle(ctx, CreateArticleRequest{Article: storage.Article{
+		Link:      "https://example.com/a",
+		Title:     "title",
+		Author:    "author",
+		Published: "the 25th of April, teatime",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "the 25th of April, teatime", entries[0].ContextMap()["published"])
+	}
+}
+
+func TestService_CreateArticlePublishesEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	bus := events.NewBus(1)
+	s := New(store, parserMocks.NewMockParser(ctrl), WithEventBus(bus))
+	ctx := context.Background()
+
+	published, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+
+	article := &storage.Article{ID: "1"}
+	store.EXPECT().CreateArticle(ctx, "", "https://example.com/a", "", "title", "", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(article, nil)
+	store.EXPECT().CreateArticle(ctx, "", "https://example.com/b", "", "title", "", "", "", nil, nil, gomock.Any(), gomock.Any()).Return(nil, storage.ErrDuplicateArticle)
+
+	_, err := s.CreateArticle(ctx, CreateArticleRequest{Article: storage.Article{Link: "https://example.com/a", Title: "title", Published: "Tue, 25 Apr 2023 00:00:00 +0000"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CreateArticle(ctx, CreateArticleRequest{Article: storage.Article{Link: "https://example.com/b", Title: "title", Published: "Tue, 25 Apr 2023 00:00:00 +0000"}})
+	assert.ErrorIs(t, err, storage.ErrDuplicateArticle)
+
+	e := <-published
+	assert.Equal(t, events.ArticleCreated, e.Type)
+	assert.Same(t, article, e.Article)
+	select {
+	case e := <-published:
+		t.Fatalf("an article that was not stored was published: %+v", e)
+	default:
+	}
+}
+
+func TestService_FullText(t *testing.T) {
+	article := &storage.Article{ID: "1", Link: "https://example.com/posts/1"}
+
+	tests := []struct {
+		name    string
+		cached  string
+		fetched string
+		err     error
+		want    string
+		wantErr error
+	}{
+		{name: "cached", cached: "<p>cached</p>", want: "<p>cached</p>"},
+		{name: "fetched and cached", fetched: "<p>fetched</p>", want: "<p>fetched</p>"},
+		{name: "unreachable", err: errors.New("connection refused"), wantErr: ErrArticleUnreachable},
+		{name: "no content", err: parser.ErrNoContent, wantErr: ErrNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := storageMocks.NewMockStorage(ctrl)
+			p := parserMocks.NewMockParser(ctrl)
+			s := New(store, p)
+			ctx := context.Background()
+
+			store.EXPECT().GetArticleFullText(ctx, article.ID).Return(tt.cached, nil)
+			if tt.cached == "" {
+				store.EXPECT().GetArticle(ctx, article.ID).Return(article, nil)
+				p.EXPECT().FullText(ctx, article.Link).Return(tt.fetched, tt.err)
+			}
+			if tt.fetched != "" {
+				store.EXPECT().SetArticleFullText(ctx, article.ID, tt.fetched).Return(nil)
+			}
+
+			got, err := s.FullText(ctx, article.ID)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_RefreshFeedHealth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	p := parserMocks.NewMockParser(ctrl)
+	store := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := New(store, p)
+	ctx := context.Background()
+
+	feed, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh := func(err error) *storage.Feed {
+		t.Helper()
+		if err != nil {
+			p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(nil, err)
+		} else {
+			p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{}, nil)
+		}
+
+		s.RefreshFeed(ctx, feed)
+		f, err := store.GetFeed(ctx, feed.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	f := refresh(nil)
+	assert.NotZero(t, f.LastSuccessTime)
+	assert.Zero(t, f.ConsecutiveFailures)
+
+	refresh(&parser.StatusError{StatusCode: 503})
+	f = refresh(&parser.StatusError{StatusCode: 503})
+	assert.Equal(t, 2, f.ConsecutiveFailures)
+	assert.Equal(t, "feed could not be fetched: unexpected status code 503", f.LastError)
+
+	failing, err := s.ListFailingFeeds(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, failing, 1)
+
+	f = refresh(nil)
+	assert.Zero(t, f.ConsecutiveFailures)
+	assert.Empty(t, f.LastError)
+
+	// a refresh abandoned when ctx is canceled is not held against the feed
+	canceled, cancel := context.WithCancel(ctx)
+	p.EXPECT().ConditionalParseFromURI(canceled, feed.RSSLink, "", "").DoAndReturn(func(ctx context.Context, uri, etag, lastModified string) (*parser.RSSFeed, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+	s.RefreshFeed(canceled, feed)
+
+	f, err = store.GetFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Zero(t, f.ConsecutiveFailures)
+}
+
+func TestService_BackfillFeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	p := parserMocks.NewMockParser(ctrl)
+	store := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := New(store, p)
+	ctx := context.Background()
+
+	feed, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the article was stored before the parser read its guid, categories, content, or enclosure
+	minimal := parser.Item{Title: "post", Link: "https://example.com/post", Author: "unknown", Description: "summary", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"}
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{Channel: parser.Channel{Items: []parser.Item{minimal}}}, nil)
+
+	added, err := s.RefreshFeed(ctx, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, added, 1) {
+		return
+	}
+
+	_, err = store.MarkArticleRead(ctx, added[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.SetArticleFavorited(ctx, added[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rich := minimal
+	rich.GUID = "post-1"
+	rich.Author = "Kyle"
+	rich.ContentEncoded = "<p>the whole post</p>"
+	rich.Categories = []string{"go", "rss"}
+	rich.Enclosure = &parser.Enclosure{URL: "https://example.com/post.mp3", Type: "audio/mpeg", Length: 1024}
+	unstored := parser.Item{Title: "newer post", Link: "https://example.com/newer", Author: "Kyle", PubDate: "Wed, 26 Apr 2023 00:00:00 +0000"}
+	p.EXPECT().ParseFromURI(ctx, feed.RSSLink).Times(2).Return(&parser.RSSFeed{Channel: parser.Channel{Items: []parser.Item{unstored, rich}}}, nil)
+
+	updated, err := s.BackfillFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, updated)
+
+	got, err := store.GetArticle(ctx, added[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "post-1", got.GUID)
+	assert.Equal(t, "Kyle", got.Author)
+	assert.Equal(t, "<p>the whole post</p>", got.Content)
+	assert.Equal(t, "summary", got.Description)
+	assert.Equal(t, []string{"go", "rss"}, got.Tags)
+	assert.Equal(t, &storage.Enclosure{URL: "https://example.com/post.mp3", Type: "audio/mpeg", Length: 1024}, got.Enclosure)
+	assert.True(t, got.Read)
+	assert.True(t, got.Favorited)
+
+	articles, err := store.ListArticlesByFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, articles, 1, "a backfill does not add articles")
+
+	// nothing is left to fill in the second time
+	updated, err = s.BackfillFeed(ctx, feed.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, updated)
+}
+
+func TestService_ReadNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		next     *storage.Article
+		nextErr  error
+		wantNext *storage.Article
+		wantErr  error
+	}{
+		{name: "next unread", next: &storage.Article{ID: "2"}, wantNext: &storage.Article{ID: "2"}},
+		{name: "last unread", nextErr: storage.ErrNotFound},
+		{name: "lookup fails", nextErr: errors.New("disk I/O error"), wantErr: errors.New("disk I/O error")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := storageMocks.NewMockStorage(ctrl)
+			ctx := context.Background()
+			opts := storage.DefaultOptions()
+
+			marked := &storage.Article{ID: "1", Read: true}
+			store.EXPECT().MarkArticleRead(ctx, "1", true).Return(marked, nil)
+			store.EXPECT().NextUnreadArticle(ctx, marked, opts).Return(tt.next, tt.nextErr)
+
+			article, next, err := New(store, nil).ReadNext(ctx, "1", opts)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, marked, article)
+			assert.Equal(t, tt.wantNext, next)
+		})
+	}
+}
+
+func TestService_RefreshFeedUpdatesEditedArticles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	p := parserMocks.NewMockParser(ctrl)
+	store := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := New(store, p)
+	ctx := context.Background()
+
+	feed, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := parser.Item{Title: "typo", Link: "https://example.com/a", GUID: "guid-a", Author: "author", Description: "first draft", PubDate: "Tue, 25 Apr 2023 00:00:00 +0000"}
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{Channel: parser.Channel{Items: []parser.Item{item}}}, nil)
+
+	added, err := s.RefreshFeed(ctx, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, added, 1) {
+		return
+	}
+
+	_, err = store.MarkArticleRead(ctx, added[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item.Title = "fixed"
+	item.Description = "corrected"
+	item.ContentEncoded = "<p>corrected</p>"
+	p.EXPECT().ConditionalParseFromURI(ctx, feed.RSSLink, "", "").Return(&parser.RSSFeed{Channel: parser.Channel{Items: []parser.Item{item}}}, nil)
+
+	added, err = s.RefreshFeed(ctx, feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, added, "an edited item is not a new article")
+
+	articles, err := store.ListArticlesByFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, articles, 1) {
+		assert.Equal(t, "fixed", articles[0].Title)
+		assert.Equal(t, "corrected", articles[0].Description)
+		assert.Equal(t, "<p>corrected</p>", articles[0].Content)
+		assert.True(t, articles[0].Read, "an edit keeps the read state")
+	}
+}
+
+func TestService_CreateArticleSanitizesDescription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := storageMocks.NewMockStorage(ctrl)
+	s := New(store, parserMocks.NewMockParser(ctrl))
+	ctx := context.Background()
+
+	description := `<p onclick="steal()">hello</p><script>alert("xss")</script><b>unclosed`
+	tests := []struct {
+		name        string
+		content     string
+		wantContent string
+	}{
+		{name: "original kept as content", content: "", wantContent: description},
+		{name: "feed content kept", content: "<p>full</p>", wantContent: "<p>full</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.EXPECT().CreateArticle(ctx, "", "https://example.com/a", "", "title", "author", "<p>hello</p><b>unclosed</b>", tt.wantContent, nil, nil, gomock.Any(), gomock.Any()).Return(&storage.Article{ID: "1"}, nil)
+
+			_, err := s.CreateArticle(ctx, CreateArticleRequest{Article: storage.Article{
+				Link:        "https://example.com/a",
+				Title:       "title",
+				Author:      "author",
+				Description: description,
+				Content:     tt.content,
+				Published:   "2023-04-25T00:00:00Z",
+			}})
+			assert.NoError(t, err)
+		})
+	}
+}
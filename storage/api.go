@@ -7,22 +7,65 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/kdwils/feedreader/pkg/links"
 )
 
 //go:generate mockgen -destination=mocks/mock_storage.go -package=mocks github.com/kdwils/feedreader/storage Storage
 type Storage interface {
 	Connect() error
 	Close() error
+	Ping(ctx context.Context) error
 
-	CreateFeed(ctx context.Context, title, rssLink, siteLink, description string) (*Feed, error)
+	CreateFeed(ctx context.Context, title, rssLink, siteLink, description, image, language, copyright string) (*Feed, error)
+	GetFeed(ctx context.Context, id string) (*Feed, error)
 	ListFeeds(ctx context.Context, opts *Options) (FeedList, error)
-
-	CreateArticle(ctx context.Context, link, title, author, description string, published time.Time) (*Article, error)
+	DeleteFeed(ctx context.Context, id string) error
+	SetFeedCacheHeaders(ctx context.Context, id, etag, lastModified string) error
+	UpdateFeedURL(ctx context.Context, id, rssLink string) error
+	UpdateFeedTitle(ctx context.Context, id, title string) error
+	UnreadCounts(ctx context.Context) (map[string]int, error)
+	RecordFeedFetch(ctx context.Context, id, fetchError string) error
+	ListFailingFeeds(ctx context.Context) ([]*Feed, error)
+	Stats(ctx context.Context) (Stats, error)
+
+	CreateFolder(ctx context.Context, name string) (*Folder, error)
+	GetFolder(ctx context.Context, id string) (*Folder, error)
+	ListFolders(ctx context.Context) ([]*Folder, error)
+	RenameFolder(ctx context.Context, id, name string) (*Folder, error)
+	DeleteFolder(ctx context.Context, id string) error
+	AssignFeedToFolder(ctx context.Context, feedID, folderID string) error
+	ListFeedsByFolder(ctx context.Context, folderID string, opts *Options) (FeedList, error)
+
+	CreateArticle(ctx context.Context, feedID, link, guid, title, author, description, content string, enclosure *Enclosure, tags []string, published time.Time, originalPublished string) (*Article, error)
+	GetArticle(ctx context.Context, id string) (*Article, error)
+	DeleteArticle(ctx context.Context, id string) error
+	PruneArticles(ctx context.Context, retention Retention) (int, error)
 	ListArticles(ctx context.Context, opts *Options) (ArticleList, error)
+	ListArticlesByStatus(ctx context.Context, status Status, opts *Options) (ArticleList, error)
+	ListArticlesBetween(ctx context.Context, status Status, from, to time.Time, opts *Options) (ArticleList, error)
 	ListArticlesByFeed(ctx context.Context, feed string) ([]*Article, error)
+	ListFeedArticles(ctx context.Context, feed string, opts *Options) (ArticleList, error)
+	ListFilteredArticles(ctx context.Context, filter ArticleFilter, opts *Options) (ArticleList, error)
+	CountArticlesSince(ctx context.Context, filter ArticleFilter, opts *Options) (int, error)
 	ListUnreadArticles(ctx context.Context, opts *Options) (ArticleList, error)
 	ListReadArticles(ctx context.Context, opts *Options) (ArticleList, error)
 	ListFavoritedArticles(ctx context.Context, opts *Options) (ArticleList, error)
+	ListSavedArticles(ctx context.Context, opts *Options) (ArticleList, error)
+	ListTaggedArticles(ctx context.Context, tag string, opts *Options) (ArticleList, error)
+	SearchArticles(ctx context.Context, query string, opts *Options) (ArticleList, error)
+	UpdateArticleContent(ctx context.Context, id, title, description, content string) error
+	BackfillArticle(ctx context.Context, id, guid, author, content string, enclosure *Enclosure, tags []string) (bool, error)
+	GetArticleFullText(ctx context.Context, id string) (string, error)
+	SetArticleFullText(ctx context.Context, id, fullText string) error
+	MarkArticleRead(ctx context.Context, id string, read bool) (*Article, error)
+	NextUnreadArticle(ctx context.Context, after *Article, opts *Options) (*Article, error)
+	MarkAllRead(ctx context.Context, feedID string) (int, error)
+	SetArticleFavorited(ctx context.Context, id string, favorited bool) (*Article, error)
+	SetArticleSaved(ctx context.Context, id string, saved bool) (*Article, error)
+	FindDuplicateArticle(ctx context.Context, feedID, link, guid string) (*Article, error)
+	AddArticleFeed(ctx context.Context, articleID, feedID string) error
+	ListArticleFeeds(ctx context.Context, articleID string) ([]*Feed, error)
 
 	Now() time.Time
 }
@@ -32,70 +75,55 @@ type Cursor struct {
 	Prev    string `json:"prev"`
 	HasNext bool   `json:"hasNext"`
 	HasPrev bool   `json:"hasPrev"`
+	// Head points at the first article of the page. Kept from the first page, it counts the articles that have since come ahead of it, see CountArticlesSince.
+	Head string `json:"head,omitempty"`
 }
 
 type CursorItem interface {
 	GetPaginationField() string
 }
 
+// Meta describes the page that was returned. Total is only set when the options asked for it.
+type Meta struct {
+	Limit int  `json:"limit"`
+	Total *int `json:"total,omitempty"`
+}
+
 type FeedList struct {
 	Cursor `json:"cursor"`
+	Meta   Meta    `json:"meta"`
 	Feeds  []*Feed `json:"feeds"`
 }
 
 type ArticleList struct {
 	Cursor   `json:"cursor"`
+	Meta     Meta       `json:"meta"`
 	Articles []*Article `json:"articles"`
 }
 
-func getPagination[T CursorItem](next, prev []T, limit int, maximumPaginatedValue string) ([]T, Cursor) {
-	var hasNext bool
-	var nextCursor string
-	var prevCursor string
-	var hasPrev bool
-	maxLimit := limit + 1
-
-	if len(next) == 0 {
-		hasNext = false
-		nextCursor = ""
-	}
-	if len(next) == maxLimit {
-		hasNext = true
-		// return 2nd to last, it is assumed queries are doing limit +1 and therefore don't care about last item
-		nextCursor = next[len(next)-1-1].GetPaginationField()
-	}
-
-	// at least 1 item up to exact match on limit means there is are no further pages
-	if len(next) > 1 && len(next) <= limit-1 {
-		hasNext = false
-		nextCursor = next[len(next)-1].GetPaginationField()
-	}
-
-	if len(prev) == 0 {
-		hasPrev = false
-		prevCursor = ""
-	}
+// getPagination builds a page from the items fetched on either side of the cursor. Both queries fetch up to limit+1 items in display order.
+// next holds the items after the cursor. When the extra item was fetched there is another page, which starts after the last item kept.
+// prev holds the items before the cursor, nearest last. When the extra item was fetched the previous page starts after it, otherwise the previous page is the first page, reached with the first cursor.
+// cursor returns the cursor pointing just after an item.
+func getPagination[T any](next, prev []T, limit int, first string, cursor func(T) string) ([]T, Cursor) {
+	var c Cursor
 
-	if len(prev) == limit {
-		hasPrev = true
-		prevCursor = prev[1].GetPaginationField()
+	if len(next) > limit {
+		next = next[:limit]
+		c.HasNext = true
+		c.Next = cursor(next[len(next)-1])
 	}
 
-	if len(prev) > 1 && len(prev) <= limit-1 {
-		hasPrev = true
-		prevCursor = maximumPaginatedValue
+	if len(prev) > 0 {
+		c.HasPrev = true
+		c.Prev = first
 	}
 
-	if len(next) == maxLimit {
-		next = next[:limit]
+	if len(prev) > limit {
+		c.Prev = cursor(prev[len(prev)-limit-1])
 	}
 
-	return next, Cursor{
-		HasNext: hasNext,
-		Next:    nextCursor,
-		HasPrev: hasPrev,
-		Prev:    prevCursor,
-	}
+	return next, c
 }
 
 type Feed struct {
@@ -104,26 +132,102 @@ type Feed struct {
 	SiteLink    string `db:"siteLink" json:"siteLink"`
 	RSSLink     string `db:"rssLink" json:"rssLink"`
 	Description string `db:"description" json:"description"`
-	Timestamp   int64  `db:"timestamp" json:"-"`
+	Image       string `db:"image" json:"image"`
+	Language    string `db:"language" json:"language"`
+	Copyright   string `db:"copyright" json:"copyright"`
+	// FolderID is empty when the feed is uncategorized
+	FolderID string `db:"folder_id" json:"folderId,omitempty"`
+	// CustomTitle is the title set by the user, Title holds it in place of the channel's title when set
+	CustomTitle string `db:"custom_title" json:"customTitle,omitempty"`
+	// LastFetchTime and LastSuccessTime are unix timestamps of the last refresh and the last one that succeeded, 0 when there has not been one
+	LastFetchTime   int64 `db:"last_fetch_time" json:"lastFetchTime"`
+	LastSuccessTime int64 `db:"last_success_time" json:"lastSuccessTime"`
+	// LastError is why the last refresh failed, empty once a refresh succeeds
+	LastError           string `db:"last_error" json:"lastError,omitempty"`
+	ConsecutiveFailures int    `db:"consecutive_failures" json:"consecutiveFailures"`
+	Timestamp           int64  `db:"timestamp" json:"-"`
+	ETag                string `db:"etag" json:"-"`
+	LastModified        string `db:"lastModified" json:"-"`
 }
 
 func (f *Feed) GetPaginationField() string {
 	return f.ID
 }
 
+// Stats summarizes the feeds and articles in the reader
+type Stats struct {
+	Feeds     int `db:"feeds" json:"feeds"`
+	Articles  int `db:"articles" json:"articles"`
+	Unread    int `db:"unread" json:"unread"`
+	Favorited int `db:"favorited" json:"favorited"`
+	// LastAdded is when the most recent article was added as a unix timestamp, 0 when there are no articles
+	LastAdded int64 `db:"last_added" json:"lastAdded"`
+}
+
+// Retention is how long read articles are kept before PruneArticles removes them. A zero limit is not enforced.
+// Unread, favorited, and saved articles are kept regardless of either limit.
+type Retention struct {
+	// MaxAge removes read articles published longer ago than this
+	MaxAge time.Duration
+	// MaxPerFeed removes read articles once this many newer articles of the same feed are stored
+	MaxPerFeed int
+}
+
+// Folder groups feeds. A feed belongs to at most one folder.
+type Folder struct {
+	ID        string `db:"id" json:"id"`
+	Name      string `db:"name" json:"name"`
+	Timestamp int64  `db:"timestamp" json:"-"`
+}
+
 type Article struct {
-	ID            string `db:"id" json:"id"`
-	FeedID        string `db:"feed" json:"feedID"`
-	Link          string `db:"link" json:"link"`
-	Title         string `db:"title" json:"title"`
-	Description   string `db:"description" json:"description"`
-	Published     string `db:"-" json:"publishedOn"`
-	ReadDate      string `db:"readDate" json:"readDate"`
-	Author        string `db:"author" json:"author"`
-	PublishedUnix int64  `db:"published" json:"published"`
-	Read          bool   `db:"read" json:"read"`
-	Favorited     bool   `db:"favorited" json:"favorited"`
-	Timestamp     int64  `db:"timestamp" json:"timestamp"`
+	ID            string     `db:"id" json:"id"`
+	FeedID        string     `db:"feed" json:"feedID"`
+	Link          string     `db:"link" json:"link"`
+	GUID          string     `db:"guid" json:"guid"`
+	Title         string     `db:"title" json:"title"`
+	Description   string     `db:"description" json:"description"`
+	Content       string     `db:"content" json:"content"`
+	Enclosure     *Enclosure `db:"-" json:"enclosure,omitempty"`
+	Tags          []string   `db:"-" json:"tags"`
+	Published     string     `db:"-" json:"publishedOn"`
+	ReadDate      string     `db:"readDate" json:"readDate"`
+	Author        string     `db:"author" json:"author"`
+	PublishedUnix int64      `db:"published" json:"published"`
+	// OriginalPublished is the publish date as the feed wrote it
+	OriginalPublished string `db:"original_published" json:"originalPublished,omitempty"`
+	Read              bool   `db:"read" json:"read"`
+	Favorited         bool   `db:"favorited" json:"favorited"`
+	Saved             bool   `db:"saved" json:"saved"`
+	Excerpt           string `db:"-" json:"excerpt,omitempty"`
+	Timestamp         int64  `db:"timestamp" json:"timestamp"`
+	// CanonicalLink is the link in the form used to recognise the same article in other feeds, see links.Canonical
+	CanonicalLink string `db:"canonical_link" json:"canonicalLink"`
+}
+
+// Enclosure is a media file attached to an article, such as a podcast episode's audio
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	Length int64  `json:"length"`
+}
+
+// normalizeTags trims each tag and drops empty tags and tags that repeat an earlier one, ignoring case
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
 }
 
 func (a *Article) GetPaginationField() string {
@@ -167,22 +271,267 @@ func (o order) opposite() string {
 	}
 }
 
+// comparison is the keyset operator used to fetch the page after a cursor
+func (o order) comparison() string {
+	if o == Ascending {
+		return ">"
+	}
+
+	return "<"
+}
+
+// oppositeComparison is the keyset operator used to fetch the items before a cursor. The cursor points just after the last item of the previous page, so that item is included.
+func (o order) oppositeComparison() string {
+	if o == Ascending {
+		return "<="
+	}
+
+	return ">="
+}
+
+// Status filters articles by their read, favorited, and saved state
+type Status string
+
+const (
+	StatusAll       Status = "all"
+	StatusRead      Status = "read"
+	StatusUnread    Status = "unread"
+	StatusFavorited Status = "favorited"
+	StatusSaved     Status = "saved"
+)
+
+// ParseStatus returns the status named by s, or StatusAll when s is not a known status
+func ParseStatus(s string) Status {
+	switch status := Status(strings.ToLower(strings.TrimSpace(s))); status {
+	case StatusRead, StatusUnread, StatusFavorited, StatusSaved:
+		return status
+	default:
+		return StatusAll
+	}
+}
+
+// where is the clause selecting articles with the status. Only the known statuses have a clause so the status never reaches the query itself.
+func (s Status) where() string {
+	switch s {
+	case StatusRead:
+		return "read = true"
+	case StatusUnread:
+		return "read = false"
+	case StatusFavorited:
+		return "favorited = true"
+	case StatusSaved:
+		return "saved = true"
+	default:
+		return "1 = 1"
+	}
+}
+
+// ArticleFilter narrows the articles that are listed or counted, an article has to match every field that is set. The zero value matches every article.
+type ArticleFilter struct {
+	Status Status
+	// FeedIDs limits the articles to those of any of the feeds when not nil, such as every feed of a folder. An empty list matches no articles.
+	FeedIDs []string
+	// From and To limit the published date, inclusive. A zero time leaves that end of the range open.
+	From time.Time
+	To   time.Time
+	// Tag limits the articles to those with the tag, ignoring case
+	Tag string
+}
+
+// where is the clause selecting the articles matching the filter along with the args to bind to its placeholders.
+// ErrInvalidRange is returned when From is after To and ErrInvalidFeedID when a feed id is not a number.
+func (f ArticleFilter) where() (string, []any, error) {
+	clauses := []string{f.Status.where()}
+	args := make([]any, 0, len(f.FeedIDs)+3)
+
+	if f.FeedIDs != nil {
+		seen := make(map[int64]bool, len(f.FeedIDs))
+		ids := make([]any, 0, len(f.FeedIDs))
+		for _, id := range f.FeedIDs {
+			n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: %q", ErrInvalidFeedID, id)
+			}
+
+			if !seen[n] {
+				seen[n] = true
+				ids = append(ids, n)
+			}
+		}
+
+		if len(ids) == 0 {
+			clauses = append(clauses, "1 = 0")
+		} else {
+			clauses = append(clauses, fmt.Sprintf("feed IN (?%s)", strings.Repeat(", ?", len(ids)-1)))
+			args = append(args, ids...)
+		}
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		return "", nil, ErrInvalidRange
+	}
+	if !f.From.IsZero() {
+		clauses = append(clauses, "published >= ?")
+		args = append(args, f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		clauses = append(clauses, "published <= ?")
+		args = append(args, f.To.Unix())
+	}
+
+	if tag := strings.TrimSpace(f.Tag); tag != "" {
+		clauses = append(clauses, "id IN (SELECT article_id FROM article_tags WHERE tag = ?)")
+		args = append(args, tag)
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// SortBy is the field articles are listed by. The zero value sorts by published date.
+type SortBy string
+
+const (
+	SortPublished SortBy = "published"
+	SortAdded     SortBy = "added"
+	SortTitle     SortBy = "title"
+)
+
+// parseSortBy returns the sort field named by s. Only known fields are accepted since the field ends up in the query's ORDER BY.
+func parseSortBy(s string) (SortBy, bool) {
+	switch strings.ToLower(s) {
+	case string(SortPublished):
+		return SortPublished, true
+	case string(SortAdded), "timestamp":
+		return SortAdded, true
+	case string(SortTitle):
+		return SortTitle, true
+	default:
+		return "", false
+	}
+}
+
+// name is the field's name, naming the zero value published
+func (s SortBy) name() string {
+	if s == "" {
+		return string(SortPublished)
+	}
+
+	return string(s)
+}
+
+// column is the sql expression articles are ordered and paged by
+func (s SortBy) column() string {
+	switch s {
+	case SortAdded:
+		return "timestamp"
+	case SortTitle:
+		return "title COLLATE NOCASE"
+	default:
+		return "published"
+	}
+}
+
+// field returns the article's value of the sort column, which is what cursors hold
+func (s SortBy) field(a *Article) string {
+	switch s {
+	case SortAdded:
+		return strconv.FormatInt(a.Timestamp, 10)
+	case SortTitle:
+		return a.Title
+	default:
+		return a.GetPaginationField()
+	}
+}
+
+// cursorSeparator joins an article cursor's sort value and id. The id is split from the end since sort values like titles may contain the separator.
+const cursorSeparator = "~"
+
+// cursor returns the article's position in the sort order, its sort value followed by its id to break ties
+func (s SortBy) cursor(a *Article) string {
+	return s.field(a) + cursorSeparator + a.ID
+}
+
+// splitCursor returns the sort value and id of an article cursor. A cursor without an id is returned as the value alone.
+func splitCursor(cursor string) (string, string) {
+	i := strings.LastIndex(cursor, cursorSeparator)
+	if i < 0 {
+		return cursor, ""
+	}
+
+	return cursor[:i], cursor[i+len(cursorSeparator):]
+}
+
 type Options struct {
 	Cursor string
 	Order  order
 	Limit  int
+	SortBy SortBy
+	// Total counts every matching item, which costs an extra query
+	Total bool
+	// cursors encodes the cursors of the returned page, they are returned as is without one
+	cursors *CursorCodec
+	// cursorField is the field the cursor was issued for, empty when the cursor was not decoded from a token
+	cursorField string
+}
+
+const (
+	// DefaultLimit is the page size used when a request does not ask for one
+	DefaultLimit = 10
+	// MaxLimit is the largest page size a request can ask for
+	MaxLimit = 100
+)
+
+// PageSize bounds the page size requests can ask for
+type PageSize struct {
+	Default int
+	Max     int
 }
 
-func ParseOptions(req url.Values) *Options {
+// DefaultPageSize returns the page size bounds used when none are configured
+func DefaultPageSize() PageSize {
+	return PageSize{
+		Default: DefaultLimit,
+		Max:     MaxLimit,
+	}
+}
+
+// normalize replaces unset bounds with the defaults and keeps the default page size within the maximum
+func (p PageSize) normalize() PageSize {
+	if p.Max <= 0 {
+		p.Max = MaxLimit
+	}
+	if p.Default <= 0 {
+		p.Default = DefaultLimit
+	}
+	if p.Default > p.Max {
+		p.Default = p.Max
+	}
+
+	return p
+}
+
+// ParseOptions reads the pagination options from the query, counting the total when total is true and sorting by the sort field when it is known. A missing or non numeric limit falls back to the default page size and any other limit is clamped into [1, size.Max].
+// The cursor is decoded with cursors, returning ErrInvalidCursor when it is not a token the codec issued for the same order. Pages listed with the options have their cursors encoded the same way.
+// Without a codec cursors are used and returned as is, which is only safe for callers trusted with the database.
+func ParseOptions(req url.Values, size PageSize, cursors *CursorCodec) (*Options, error) {
+	size = size.normalize()
 	opts := DefaultOptions()
-	limit := req.Get("limit")
-	if limit != "" {
-		i, err := strconv.Atoi(limit)
-		if err == nil {
-			opts.Limit = i
-		}
+	opts.Limit = size.Default
+	if i, err := strconv.Atoi(req.Get("limit")); err == nil {
+		opts.Limit = i
+	}
+
+	if opts.Limit < 1 {
+		opts.Limit = 1
+	}
+	if opts.Limit > size.Max {
+		opts.Limit = size.Max
+	}
+	opts.Total, _ = strconv.ParseBool(req.Get("total"))
+
+	if sortBy, ok := parseSortBy(req.Get("sort")); ok {
+		opts.SortBy = sortBy
 	}
-	opts.Cursor = req.Get("cursor")
 
 	if order := req.Get("order"); order != "" {
 		switch strings.ToLower(order) {
@@ -193,19 +542,53 @@ func ParseOptions(req url.Values) *Options {
 		}
 	}
 
-	return opts
+	opts.cursors = cursors
+	opts.Cursor = req.Get("cursor")
+	if cursors == nil || opts.Cursor == "" {
+		return opts, nil
+	}
+
+	token, err := cursors.decode(opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	// a cursor from a list in the other direction would page the wrong way
+	if token.Direction != opts.Order.string() {
+		return nil, ErrInvalidCursor
+	}
+
+	opts.Cursor = token.Value
+	opts.cursorField = token.Field
+	return opts, nil
 }
 
 func DefaultOptions() *Options {
 	return &Options{
-		Limit:  10,
+		Limit:  DefaultLimit,
 		Cursor: "",
 		Order:  Descending,
 	}
 }
 
+// siteHost returns the host of the link's site the way links.Canonical writes it, without a leading www., so feeds and articles of one site share it whether they use www. or not.
+// A link that is not an absolute http or https url has no site and returns an empty string.
+func siteHost(link string) string {
+	u, err := url.Parse(links.Canonical(link))
+	if err != nil || u.Scheme == "" {
+		return ""
+	}
+
+	return u.Host
+}
+
+// parseSiteLinkFromURI returns the normalized scheme and host of the uri, or links.ErrInvalidURL when it is not an absolute http or https url
 func parseSiteLinkFromURI(uri string) (string, error) {
-	u, err := url.Parse(uri)
+	normalized, err := links.Normalize(uri)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(normalized)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		size  PageSize
+		want  *Options
+	}{
+		{
+			name:  "defaults",
+			query: url.Values{},
+			size:  DefaultPageSize(),
+			want:  DefaultOptions(),
+		},
+		{
+			name:  "limit",
+			query: url.Values{"limit": {"25"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 25, Order: Descending},
+		},
+		{
+			name:  "negative limit is clamped to one",
+			query: url.Values{"limit": {"-5"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 1, Order: Descending},
+		},
+		{
+			name:  "zero limit is clamped to one",
+			query: url.Values{"limit": {"0"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 1, Order: Descending},
+		},
+		{
+			name:  "oversized limit is clamped to the max",
+			query: url.Values{"limit": {"99999999"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: MaxLimit, Order: Descending},
+		},
+		{
+			name:  "invalid limit",
+			query: url.Values{"limit": {"ten"}},
+			size:  DefaultPageSize(),
+			want:  DefaultOptions(),
+		},
+		{
+			name:  "configured default",
+			query: url.Values{},
+			size:  PageSize{Default: 20, Max: 50},
+			want:  &Options{Limit: 20, Order: Descending},
+		},
+		{
+			name:  "configured max",
+			query: url.Values{"limit": {"51"}},
+			size:  PageSize{Default: 20, Max: 50},
+			want:  &Options{Limit: 50, Order: Descending},
+		},
+		{
+			name:  "default above max",
+			query: url.Values{},
+			size:  PageSize{Default: 200, Max: 50},
+			want:  &Options{Limit: 50, Order: Descending},
+		},
+		{
+			name:  "unset page size",
+			query: url.Values{"limit": {"1000"}},
+			size:  PageSize{},
+			want:  &Options{Limit: MaxLimit, Order: Descending},
+		},
+		{
+			name:  "total",
+			query: url.Values{"total": {"true"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 10, Order: Descending, Total: true},
+		},
+		{
+			name:  "sort",
+			query: url.Values{"sort": {"Title"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 10, Order: Descending, SortBy: SortTitle},
+		},
+		{
+			name:  "sort by timestamp",
+			query: url.Values{"sort": {"timestamp"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 10, Order: Descending, SortBy: SortAdded},
+		},
+		{
+			name:  "unknown sort is ignored",
+			query: url.Values{"sort": {"id; DROP TABLE articles"}},
+			size:  DefaultPageSize(),
+			want:  DefaultOptions(),
+		},
+		{
+			name:  "cursor and order",
+			query: url.Values{"cursor": {"1672531200"}, "order": {"Ascending"}},
+			size:  DefaultPageSize(),
+			want:  &Options{Limit: 10, Cursor: "1672531200", Order: Ascending},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseOptions(tt.query, tt.size, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
+
+func TestGetPagination(t *testing.T) {
+	const limit = 3
+	// items are numbered by their position so the cursor after item n is "n"
+	items := func(from, count int) []int {
+		s := make([]int, 0, count)
+		for i := 0; i < count; i++ {
+			s = append(s, from+i)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		next       []int
+		prev       []int
+		wantItems  []int
+		wantCursor Cursor
+	}{
+		{
+			name:       "no items",
+			next:       items(1, 0),
+			wantItems:  items(1, 0),
+			wantCursor: Cursor{},
+		},
+		{
+			name:       "one item",
+			next:       items(1, 1),
+			wantItems:  items(1, 1),
+			wantCursor: Cursor{},
+		},
+		{
+			name:       "one less than the limit",
+			next:       items(1, limit-1),
+			wantItems:  items(1, limit-1),
+			wantCursor: Cursor{},
+		},
+		{
+			name:       "exactly the limit",
+			next:       items(1, limit),
+			wantItems:  items(1, limit),
+			wantCursor: Cursor{},
+		},
+		{
+			name:       "one more than the limit",
+			next:       items(1, limit+1),
+			wantItems:  items(1, limit),
+			wantCursor: Cursor{HasNext: true, Next: "3"},
+		},
+		{
+			name:       "one item before",
+			next:       items(2, 1),
+			prev:       items(1, 1),
+			wantItems:  items(2, 1),
+			wantCursor: Cursor{HasPrev: true, Prev: "first"},
+		},
+		{
+			name:       "one less than the limit before",
+			next:       items(3, 1),
+			prev:       items(1, limit-1),
+			wantItems:  items(3, 1),
+			wantCursor: Cursor{HasPrev: true, Prev: "first"},
+		},
+		{
+			name:       "exactly the limit before",
+			next:       items(4, 1),
+			prev:       items(1, limit),
+			wantItems:  items(4, 1),
+			wantCursor: Cursor{HasPrev: true, Prev: "first"},
+		},
+		{
+			name:       "one more than the limit before",
+			next:       items(5, limit+1),
+			prev:       items(1, limit+1),
+			wantItems:  items(5, limit),
+			wantCursor: Cursor{HasNext: true, Next: "7", HasPrev: true, Prev: "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cursor := getPagination(tt.next, tt.prev, limit, "first", strconv.Itoa)
+			assert.Equal(t, tt.wantItems, got)
+			assert.Equal(t, tt.wantCursor, cursor)
+		})
+	}
+}
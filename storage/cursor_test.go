@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	want := cursorToken{Field: "published", Value: "1672531200~5", Direction: "DESC"}
+
+	token := codec.encode(want)
+	assert.NotContains(t, token, "1672531200", "the token does not expose the cursor")
+
+	got, err := codec.decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, want, got)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"f":"published","v":"0 OR 1=1","d":"DESC"}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "raw value", token: "1672531200"},
+		{name: "forged payload", token: forged + "." + signature},
+		{name: "corrupted payload", token: payload[:len(payload)-2] + "." + signature},
+		{name: "corrupted signature", token: payload + "." + strings.Repeat("A", len(signature))},
+		{name: "not base64", token: "!!!.???"},
+		{name: "another key", token: NewCursorCodec([]byte("other")).encode(want)},
+		{name: "random key", token: NewCursorCodec(nil).encode(want)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codec.decode(tt.token)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+func TestParseOptionsCursor(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	token := codec.encode(cursorToken{Field: "title", Value: "b~2", Direction: "DESC"})
+
+	opts, err := ParseOptions(url.Values{"cursor": {token}, "sort": {"title"}}, DefaultPageSize(), codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "b~2", opts.Cursor)
+	assert.Equal(t, "title", opts.cursorField)
+
+	_, err = ParseOptions(url.Values{"cursor": {token}, "order": {"ascending"}}, DefaultPageSize(), codec)
+	assert.ErrorIs(t, err, ErrInvalidCursor, "a cursor is only valid in the order it was issued for")
+
+	_, err = ParseOptions(url.Values{"cursor": {"b~2"}}, DefaultPageSize(), codec)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	opts, err = ParseOptions(url.Values{}, DefaultPageSize(), codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, opts.Cursor, "no cursor is the first page")
+}
+
+func TestSQLite_ListArticlesSignedCursors(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 5)
+	codec := NewCursorCodec([]byte("secret"))
+
+	parse := func(query url.Values) *Options {
+		opts, err := ParseOptions(query, PageSize{Default: 2, Max: 2}, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return opts
+	}
+
+	titles := make([]string, 0)
+	query := url.Values{}
+	for {
+		list, err := store.ListArticles(ctx, parse(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range list.Articles {
+			titles = append(titles, a.Title)
+		}
+
+		if !list.HasNext {
+			break
+		}
+		query = url.Values{"cursor": {list.Next}}
+	}
+	assert.Equal(t, []string{"article 5", "article 4", "article 3", "article 2", "article 1"}, titles)
+
+	first, err := store.ListArticles(ctx, parse(url.Values{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, first.HasNext)
+
+	second, err := store.ListArticles(ctx, parse(url.Values{"cursor": {first.Next}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, second.HasPrev)
+	assert.Empty(t, second.Prev, "the first page is reached without a cursor")
+
+	_, err = store.ListArticles(ctx, parse(url.Values{"cursor": {first.Next}, "sort": {"title"}}))
+	assert.ErrorIs(t, err, ErrInvalidCursor, "a published cursor cannot page a title sort")
+
+	_, err = store.ListFeeds(ctx, parse(url.Values{"cursor": {first.Next}}))
+	assert.ErrorIs(t, err, ErrInvalidCursor, "an article cursor cannot page feeds")
+}
@@ -3,5 +3,17 @@ package storage
 import "errors"
 
 var (
-	ErrNilDB = errors.New("db is nil")
-)
\ No newline at end of file
+	ErrNilDB            = errors.New("db is nil")
+	ErrNotFound         = errors.New("not found")
+	ErrDuplicateFeed    = errors.New("feed already exists")
+	ErrDuplicateArticle = errors.New("article already exists")
+	ErrDuplicateFolder  = errors.New("folder already exists")
+	// ErrFeedMissing is returned when creating an article for a feed that does not exist
+	ErrFeedMissing = errors.New("article feed does not exist")
+	// ErrInvalidRange is returned when listing articles between a from date after the to date
+	ErrInvalidRange = errors.New("range starts after it ends")
+	// ErrInvalidFeedID is returned when filtering by a feed id that is not a number
+	ErrInvalidFeedID = errors.New("invalid feed id")
+	// ErrArticleDeleted is returned when creating an article that was deleted, so refreshing its feed does not bring it back
+	ErrArticleDeleted = errors.New("article was deleted")
+)
@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kdwils/feedreader/pkg/links"
+)
+
+// migration moves the schema up to its version. Databases created before the schema was versioned start at version 0 with part of the schema already in place, so every migration must be idempotent.
+type migration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, tx *sqlx.Tx) error
+	// rebuildsTables turns foreign keys off while the migration runs, since dropping a table other tables reference would otherwise fail, see rebuildTable
+	rebuildsTables bool
+}
+
+// migrations are applied in order and must never be edited once released, only appended to
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create feeds and articles",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS feeds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			rssLink TEXT NOT NULL UNIQUE,
+			siteLink TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL,
+			timestamp INT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS articles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			feed INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			author TEXT NOT NULL,
+			description TEXT NOT NULL,
+			link TEXT NOT NULL UNIQUE,
+			published TEXT NOT NULL,
+			read BOOLEAN NOT NULL,
+			read_date TEXT NOT NULL,
+			favorited BOOLEAN NOT NULL,
+			timestamp INT NOT NULL,
+			FOREIGN KEY(feed) REFERENCES feeds(id)
+		);`),
+	},
+	{
+		version:     2,
+		description: "add article content and guid",
+		up: addColumns(
+			column{table: "articles", name: "content", definition: "TEXT NOT NULL DEFAULT ''"},
+			column{table: "articles", name: "guid", definition: "TEXT NOT NULL DEFAULT ''"},
+		),
+	},
+	{
+		version:     3,
+		description: "add feed cache headers",
+		up: addColumns(
+			column{table: "feeds", name: "etag", definition: "TEXT NOT NULL DEFAULT ''"},
+			column{table: "feeds", name: "lastModified", definition: "TEXT NOT NULL DEFAULT ''"},
+		),
+	},
+	{
+		version:     4,
+		description: "add article enclosures",
+		up: addColumns(
+			column{table: "articles", name: "enclosure_url", definition: "TEXT NOT NULL DEFAULT ''"},
+			column{table: "articles", name: "enclosure_type", definition: "TEXT NOT NULL DEFAULT ''"},
+			column{table: "articles", name: "enclosure_length", definition: "INT NOT NULL DEFAULT 0"},
+		),
+	},
+	{
+		version:     5,
+		description: "add article tags",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS article_tags (
+			article_id INTEGER NOT NULL,
+			tag TEXT NOT NULL COLLATE NOCASE,
+			PRIMARY KEY(article_id, tag),
+			FOREIGN KEY(article_id) REFERENCES articles(id)
+		);`),
+	},
+	{
+		version:     6,
+		description: "add feed images",
+		up: addColumns(
+			column{table: "feeds", name: "image", definition: "TEXT NOT NULL DEFAULT ''"},
+		),
+	},
+	{
+		version:     7,
+		description: "add feed language and copyright",
+		up: addColumns(
+			column{table: "feeds", name: "language", definition: "TEXT NOT NULL DEFAULT ''"},
+			column{table: "feeds", name: "copyright", definition: "TEXT NOT NULL DEFAULT ''"},
+		),
+	},
+	{
+		version:     8,
+		description: "add article tombstones",
+		up: execStatements(`
+		CREATE TABLE IF NOT EXISTS article_tombstones (
+			link TEXT NOT NULL PRIMARY KEY,
+			guid TEXT NOT NULL,
+			feed INTEGER NOT NULL,
+			deleted INT NOT NULL,
+			FOREIGN KEY(feed) REFERENCES feeds(id)
+		);`),
+	},
+	{
+		version:     9,
+		description: "add folders",
+		up: func(ctx context.Context, tx *sqlx.Tx) error {
+			err := execStatements(`
+			CREATE TABLE IF NOT EXISTS folders (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+				timestamp INT NOT NULL
+			);`)(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			// feeds without a folder are uncategorized
+			return addColumns(column{table: "feeds", name: "folder_id", definition: "INTEGER REFERENCES folders(id)"})(ctx, tx)
+		},
+	},
+	{
+		version:     10,
+		description: "add saved articles",
+		up: addColumns(
+			column{table: "articles", name: "saved", definition: "BOOLEAN NOT NULL DEFAULT false"},
+		),
+	},
+	{
+		version:     11,
+		description: "add custom feed titles",
+		up: addColumns(
+			column{table: "feeds", name: "custom_title", definition: "TEXT"},
+		),
+	},
+	{
+		version:     12,
+		description: "remove orphaned articles",
+		// foreign keys were not enforced before, so rows can reference feeds, articles, or folders that were deleted
+		up: execStatements(`
+		DELETE FROM article_tags WHERE article_id NOT IN (SELECT id FROM articles WHERE feed IN (SELECT id FROM feeds));
+		DELETE FROM articles WHERE feed NOT IN (SELECT id FROM feeds);
+		DELETE FROM article_tombstones WHERE feed NOT IN (SELECT id FROM feeds);
+		UPDATE feeds SET folder_id = NULL WHERE folder_id NOT IN (SELECT id FROM folders);`),
+	},
+	{
+		version:     13,
+		description: "add original article publish dates",
+		up: addColumns(
+			column{table: "articles", name: "original_published", definition: "TEXT NOT NULL DEFAULT ''"},
+		),
+	},
+	{
+		version:     14,
+		description: "cache the full text of articles",
+		up: addColumns(
+			column{table: "articles", name: "full_text", definition: "TEXT NOT NULL DEFAULT ''"},
+		),
+	},
+	{
+		version:     15,
+		description: "track the health of feeds",
+		up: addColumns(
+			column{table: "feeds", name: "last_fetch_time", definition: "INTEGER NOT NULL DEFAULT 0"},
+			column{table: "feeds", name: "last_success_time", definition: "INTEGER NOT NULL DEFAULT 0"},
+			column{table: "feeds", name: "last_error", definition: "TEXT NOT NULL DEFAULT ''"},
+			column{table: "feeds", name: "consecutive_failures", definition: "INTEGER NOT NULL DEFAULT 0"},
+		),
+	},
+	{
+		version:     16,
+		description: "track canonical article links and the feeds carrying an article",
+		up: func(ctx context.Context, tx *sqlx.Tx) error {
+			err := addColumns(column{table: "articles", name: "canonical_link", definition: "TEXT NOT NULL DEFAULT ''"})(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			err = backfillCanonicalLinks(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			return execStatements(`
+			CREATE INDEX IF NOT EXISTS articles_canonical_link ON articles(canonical_link);
+			CREATE INDEX IF NOT EXISTS articles_guid ON articles(guid);
+			CREATE TABLE IF NOT EXISTS article_feeds (
+				article_id INTEGER NOT NULL,
+				feed_id INTEGER NOT NULL,
+				PRIMARY KEY(article_id, feed_id),
+				FOREIGN KEY(article_id) REFERENCES articles(id),
+				FOREIGN KEY(feed_id) REFERENCES feeds(id)
+			);`)(ctx, tx)
+		},
+	},
+	{
+		version:     17,
+		description: "store article publish dates as integers",
+		// published was declared TEXT, so it was compared and sorted as a string and dates before 2001 with fewer digits came out of order
+		up: rebuildTable("articles", `
+		CREATE TABLE articles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			feed INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			author TEXT NOT NULL,
+			description TEXT NOT NULL,
+			link TEXT NOT NULL UNIQUE,
+			published INTEGER NOT NULL,
+			read BOOLEAN NOT NULL,
+			read_date TEXT NOT NULL,
+			favorited BOOLEAN NOT NULL,
+			timestamp INT NOT NULL,
+			content TEXT NOT NULL DEFAULT '',
+			guid TEXT NOT NULL DEFAULT '',
+			enclosure_url TEXT NOT NULL DEFAULT '',
+			enclosure_type TEXT NOT NULL DEFAULT '',
+			enclosure_length INT NOT NULL DEFAULT 0,
+			saved BOOLEAN NOT NULL DEFAULT false,
+			original_published TEXT NOT NULL DEFAULT '',
+			full_text TEXT NOT NULL DEFAULT '',
+			canonical_link TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY(feed) REFERENCES feeds(id)
+		);`,
+			"CREATE INDEX IF NOT EXISTS articles_canonical_link ON articles(canonical_link)",
+			"CREATE INDEX IF NOT EXISTS articles_guid ON articles(guid)",
+		),
+		rebuildsTables: true,
+	},
+	{
+		version:     18,
+		description: "allow several feeds per site",
+		// a site's main feed and its category feeds share a site link, only the rss link tells feeds apart
+		up: func(ctx context.Context, tx *sqlx.Tx) error {
+			err := rebuildTable("feeds", `
+			CREATE TABLE feeds (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				title TEXT NOT NULL,
+				rssLink TEXT NOT NULL UNIQUE,
+				siteLink TEXT NOT NULL,
+				description TEXT NOT NULL,
+				timestamp INT NOT NULL,
+				etag TEXT NOT NULL DEFAULT '',
+				lastModified TEXT NOT NULL DEFAULT '',
+				image TEXT NOT NULL DEFAULT '',
+				language TEXT NOT NULL DEFAULT '',
+				copyright TEXT NOT NULL DEFAULT '',
+				folder_id INTEGER REFERENCES folders(id),
+				custom_title TEXT,
+				last_fetch_time INTEGER NOT NULL DEFAULT 0,
+				last_success_time INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				consecutive_failures INTEGER NOT NULL DEFAULT 0,
+				site TEXT NOT NULL DEFAULT ''
+			);`,
+				"CREATE INDEX IF NOT EXISTS feeds_site ON feeds(site)",
+			)(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			return backfillFeedSites(ctx, tx)
+		},
+		rebuildsTables: true,
+	},
+}
+
+// backfillFeedSites sets the site of the feeds stored before it was tracked
+func backfillFeedSites(ctx context.Context, tx *sqlx.Tx) error {
+	var feeds []struct {
+		ID       int64  `db:"id"`
+		SiteLink string `db:"siteLink"`
+	}
+	err := tx.SelectContext(ctx, &feeds, "SELECT id, siteLink FROM feeds WHERE site = ''")
+	if err != nil {
+		return err
+	}
+
+	for _, f := range feeds {
+		_, err = tx.ExecContext(ctx, "UPDATE feeds SET site = ? WHERE id = ?", siteHost(f.SiteLink), f.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildTable replaces the table with one created by the definition, which is how sqlite changes the type or constraints of a column.
+// The definition creates the table under its own name and must have every column of the existing table. Rows are copied over by column name, converted to the new column types, and the indexes are created again since dropping the old table drops them.
+// Tables referencing the table keep doing so by name, but only while foreign keys are off, see migration.rebuildsTables.
+func rebuildTable(table, definition string, indexes ...string) func(ctx context.Context, tx *sqlx.Tx) error {
+	return func(ctx context.Context, tx *sqlx.Tx) error {
+		var columns []string
+		err := tx.SelectContext(ctx, &columns, "SELECT name FROM pragma_table_info(?)", table)
+		if err != nil {
+			return err
+		}
+
+		rebuilt := table + "_rebuilt"
+		statements := []string{
+			strings.Replace(definition, "CREATE TABLE "+table+" ", "CREATE TABLE "+rebuilt+" ", 1),
+			fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", rebuilt, strings.Join(columns, ", "), strings.Join(columns, ", "), table),
+			fmt.Sprintf("DROP TABLE %s", table),
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", rebuilt, table),
+		}
+		for _, statement := range append(statements, indexes...) {
+			_, err = tx.ExecContext(ctx, statement)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// backfillCanonicalLinks sets the canonical link of the articles stored before it was tracked
+func backfillCanonicalLinks(ctx context.Context, tx *sqlx.Tx) error {
+	var articles []struct {
+		ID   int64  `db:"id"`
+		Link string `db:"link"`
+	}
+	err := tx.SelectContext(ctx, &articles, "SELECT id, link FROM articles WHERE canonical_link = ''")
+	if err != nil {
+		return err
+	}
+
+	for _, a := range articles {
+		_, err = tx.ExecContext(ctx, "UPDATE articles SET canonical_link = ? WHERE id = ?", links.Canonical(a.Link), a.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrate applies every migration newer than the database's schema version, each in its own transaction along with the record of it being applied
+func (s *SQLite) migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied INT NOT NULL
+	);`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = s.db.GetContext(ctx, &current, "SELECT COALESCE(MAX(version), 0) FROM schema_version")
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		err = s.applyMigration(ctx, m)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", m.version, m.description, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) applyMigration(ctx context.Context, m migration) error {
+	// foreign_keys cannot change inside a transaction and only applies to the connection it is set on, so the migration gets a connection of its own
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.rebuildsTables {
+		_, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF")
+		if err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, fmt.Sprintf("PRAGMA foreign_keys = %t", s.pragmas.ForeignKeys))
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = m.up(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	// with foreign keys off nothing stopped the rebuilt tables from breaking a reference, so they are checked before committing
+	if m.rebuildsTables && s.pragmas.ForeignKeys {
+		var violations int
+		err = tx.GetContext(ctx, &violations, "SELECT COUNT(*) FROM pragma_foreign_key_check")
+		if err != nil {
+			return err
+		}
+		if violations > 0 {
+			return fmt.Errorf("%d rows reference rows that no longer exist", violations)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_version (version, description, applied) VALUES (?, ?, ?)", m.version, m.description, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func execStatements(statements string) func(ctx context.Context, tx *sqlx.Tx) error {
+	return func(ctx context.Context, tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, statements)
+		return err
+	}
+}
+
+type column struct {
+	table      string
+	name       string
+	definition string
+}
+
+// addColumns adds the columns that are not already in their table
+func addColumns(columns ...column) func(ctx context.Context, tx *sqlx.Tx) error {
+	return func(ctx context.Context, tx *sqlx.Tx) error {
+		for _, c := range columns {
+			err := addColumnIfMissing(ctx, tx, c)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func addColumnIfMissing(ctx context.Context, tx *sqlx.Tx, c column) error {
+	var columns []struct {
+		CID          int            `db:"cid"`
+		Name         string         `db:"name"`
+		Type         string         `db:"type"`
+		NotNull      bool           `db:"notnull"`
+		DefaultValue sql.NullString `db:"dflt_value"`
+		PK           int            `db:"pk"`
+	}
+
+	err := tx.SelectContext(ctx, &columns, fmt.Sprintf("PRAGMA table_info(%s)", c.table))
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range columns {
+		if existing.Name == c.name {
+			return nil
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.name, c.definition))
+	return err
+}
@@ -36,6 +36,49 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddArticleFeed mocks base method.
+func (m *MockStorage) AddArticleFeed(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddArticleFeed", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddArticleFeed indicates an expected call of AddArticleFeed.
+func (mr *MockStorageMockRecorder) AddArticleFeed(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddArticleFeed", reflect.TypeOf((*MockStorage)(nil).AddArticleFeed), arg0, arg1, arg2)
+}
+
+// AssignFeedToFolder mocks base method.
+func (m *MockStorage) AssignFeedToFolder(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignFeedToFolder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignFeedToFolder indicates an expected call of AssignFeedToFolder.
+func (mr *MockStorageMockRecorder) AssignFeedToFolder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignFeedToFolder", reflect.TypeOf((*MockStorage)(nil).AssignFeedToFolder), arg0, arg1, arg2)
+}
+
+// BackfillArticle mocks base method.
+func (m *MockStorage) BackfillArticle(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 *storage.Enclosure, arg6 []string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackfillArticle", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BackfillArticle indicates an expected call of BackfillArticle.
+func (mr *MockStorageMockRecorder) BackfillArticle(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackfillArticle", reflect.TypeOf((*MockStorage)(nil).BackfillArticle), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -64,34 +107,196 @@ func (mr *MockStorageMockRecorder) Connect() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockStorage)(nil).Connect))
 }
 
+// CountArticlesSince mocks base method.
+func (m *MockStorage) CountArticlesSince(arg0 context.Context, arg1 storage.ArticleFilter, arg2 *storage.Options) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountArticlesSince", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountArticlesSince indicates an expected call of CountArticlesSince.
+func (mr *MockStorageMockRecorder) CountArticlesSince(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountArticlesSince", reflect.TypeOf((*MockStorage)(nil).CountArticlesSince), arg0, arg1, arg2)
+}
+
 // CreateArticle mocks base method.
-func (m *MockStorage) CreateArticle(arg0 context.Context, arg1, arg2, arg3, arg4 string, arg5 time.Time) (*storage.Article, error) {
+func (m *MockStorage) CreateArticle(arg0 context.Context, arg1, arg2, arg3, arg4, arg5, arg6, arg7 string, arg8 *storage.Enclosure, arg9 []string, arg10 time.Time, arg11 string) (*storage.Article, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateArticle", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret := m.ctrl.Call(m, "CreateArticle", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11)
 	ret0, _ := ret[0].(*storage.Article)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateArticle indicates an expected call of CreateArticle.
-func (mr *MockStorageMockRecorder) CreateArticle(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) CreateArticle(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArticle", reflect.TypeOf((*MockStorage)(nil).CreateArticle), arg0, arg1, arg2, arg3, arg4, arg5)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArticle", reflect.TypeOf((*MockStorage)(nil).CreateArticle), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11)
 }
 
 // CreateFeed mocks base method.
-func (m *MockStorage) CreateFeed(arg0 context.Context, arg1, arg2, arg3, arg4 string) (*storage.Feed, error) {
+func (m *MockStorage) CreateFeed(arg0 context.Context, arg1, arg2, arg3, arg4, arg5, arg6, arg7 string) (*storage.Feed, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFeed", arg0, arg1, arg2, arg3, arg4)
+	ret := m.ctrl.Call(m, "CreateFeed", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 	ret0, _ := ret[0].(*storage.Feed)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateFeed indicates an expected call of CreateFeed.
-func (mr *MockStorageMockRecorder) CreateFeed(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) CreateFeed(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeed", reflect.TypeOf((*MockStorage)(nil).CreateFeed), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+}
+
+// CreateFolder mocks base method.
+func (m *MockStorage) CreateFolder(arg0 context.Context, arg1 string) (*storage.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFolder", arg0, arg1)
+	ret0, _ := ret[0].(*storage.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockStorageMockRecorder) CreateFolder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockStorage)(nil).CreateFolder), arg0, arg1)
+}
+
+// DeleteArticle mocks base method.
+func (m *MockStorage) DeleteArticle(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteArticle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteArticle indicates an expected call of DeleteArticle.
+func (mr *MockStorageMockRecorder) DeleteArticle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteArticle", reflect.TypeOf((*MockStorage)(nil).DeleteArticle), arg0, arg1)
+}
+
+// DeleteFeed mocks base method.
+func (m *MockStorage) DeleteFeed(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFeed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFeed indicates an expected call of DeleteFeed.
+func (mr *MockStorageMockRecorder) DeleteFeed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeed", reflect.TypeOf((*MockStorage)(nil).DeleteFeed), arg0, arg1)
+}
+
+// DeleteFolder mocks base method.
+func (m *MockStorage) DeleteFolder(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFolder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFolder indicates an expected call of DeleteFolder.
+func (mr *MockStorageMockRecorder) DeleteFolder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFolder", reflect.TypeOf((*MockStorage)(nil).DeleteFolder), arg0, arg1)
+}
+
+// FindDuplicateArticle mocks base method.
+func (m *MockStorage) FindDuplicateArticle(arg0 context.Context, arg1, arg2, arg3 string) (*storage.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicateArticle", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*storage.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicateArticle indicates an expected call of FindDuplicateArticle.
+func (mr *MockStorageMockRecorder) FindDuplicateArticle(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicateArticle", reflect.TypeOf((*MockStorage)(nil).FindDuplicateArticle), arg0, arg1, arg2, arg3)
+}
+
+// GetArticle mocks base method.
+func (m *MockStorage) GetArticle(arg0 context.Context, arg1 string) (*storage.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArticle", arg0, arg1)
+	ret0, _ := ret[0].(*storage.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArticle indicates an expected call of GetArticle.
+func (mr *MockStorageMockRecorder) GetArticle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticle", reflect.TypeOf((*MockStorage)(nil).GetArticle), arg0, arg1)
+}
+
+// GetArticleFullText mocks base method.
+func (m *MockStorage) GetArticleFullText(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArticleFullText", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArticleFullText indicates an expected call of GetArticleFullText.
+func (mr *MockStorageMockRecorder) GetArticleFullText(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticleFullText", reflect.TypeOf((*MockStorage)(nil).GetArticleFullText), arg0, arg1)
+}
+
+// GetFeed mocks base method.
+func (m *MockStorage) GetFeed(arg0 context.Context, arg1 string) (*storage.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeed", arg0, arg1)
+	ret0, _ := ret[0].(*storage.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeed indicates an expected call of GetFeed.
+func (mr *MockStorageMockRecorder) GetFeed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeed", reflect.TypeOf((*MockStorage)(nil).GetFeed), arg0, arg1)
+}
+
+// GetFolder mocks base method.
+func (m *MockStorage) GetFolder(arg0 context.Context, arg1 string) (*storage.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolder", arg0, arg1)
+	ret0, _ := ret[0].(*storage.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolder indicates an expected call of GetFolder.
+func (mr *MockStorageMockRecorder) GetFolder(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeed", reflect.TypeOf((*MockStorage)(nil).CreateFeed), arg0, arg1, arg2, arg3, arg4)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolder", reflect.TypeOf((*MockStorage)(nil).GetFolder), arg0, arg1)
+}
+
+// ListArticleFeeds mocks base method.
+func (m *MockStorage) ListArticleFeeds(arg0 context.Context, arg1 string) ([]*storage.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArticleFeeds", arg0, arg1)
+	ret0, _ := ret[0].([]*storage.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArticleFeeds indicates an expected call of ListArticleFeeds.
+func (mr *MockStorageMockRecorder) ListArticleFeeds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArticleFeeds", reflect.TypeOf((*MockStorage)(nil).ListArticleFeeds), arg0, arg1)
 }
 
 // ListArticles mocks base method.
@@ -109,6 +314,21 @@ func (mr *MockStorageMockRecorder) ListArticles(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArticles", reflect.TypeOf((*MockStorage)(nil).ListArticles), arg0, arg1)
 }
 
+// ListArticlesBetween mocks base method.
+func (m *MockStorage) ListArticlesBetween(arg0 context.Context, arg1 storage.Status, arg2, arg3 time.Time, arg4 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArticlesBetween", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArticlesBetween indicates an expected call of ListArticlesBetween.
+func (mr *MockStorageMockRecorder) ListArticlesBetween(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArticlesBetween", reflect.TypeOf((*MockStorage)(nil).ListArticlesBetween), arg0, arg1, arg2, arg3, arg4)
+}
+
 // ListArticlesByFeed mocks base method.
 func (m *MockStorage) ListArticlesByFeed(arg0 context.Context, arg1 string) ([]*storage.Article, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +344,36 @@ func (mr *MockStorageMockRecorder) ListArticlesByFeed(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArticlesByFeed", reflect.TypeOf((*MockStorage)(nil).ListArticlesByFeed), arg0, arg1)
 }
 
+// ListArticlesByStatus mocks base method.
+func (m *MockStorage) ListArticlesByStatus(arg0 context.Context, arg1 storage.Status, arg2 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArticlesByStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArticlesByStatus indicates an expected call of ListArticlesByStatus.
+func (mr *MockStorageMockRecorder) ListArticlesByStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArticlesByStatus", reflect.TypeOf((*MockStorage)(nil).ListArticlesByStatus), arg0, arg1, arg2)
+}
+
+// ListFailingFeeds mocks base method.
+func (m *MockStorage) ListFailingFeeds(arg0 context.Context) ([]*storage.Feed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFailingFeeds", arg0)
+	ret0, _ := ret[0].([]*storage.Feed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFailingFeeds indicates an expected call of ListFailingFeeds.
+func (mr *MockStorageMockRecorder) ListFailingFeeds(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFailingFeeds", reflect.TypeOf((*MockStorage)(nil).ListFailingFeeds), arg0)
+}
+
 // ListFavoritedArticles mocks base method.
 func (m *MockStorage) ListFavoritedArticles(arg0 context.Context, arg1 *storage.Options) (storage.ArticleList, error) {
 	m.ctrl.T.Helper()
@@ -139,6 +389,21 @@ func (mr *MockStorageMockRecorder) ListFavoritedArticles(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFavoritedArticles", reflect.TypeOf((*MockStorage)(nil).ListFavoritedArticles), arg0, arg1)
 }
 
+// ListFeedArticles mocks base method.
+func (m *MockStorage) ListFeedArticles(arg0 context.Context, arg1 string, arg2 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedArticles", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedArticles indicates an expected call of ListFeedArticles.
+func (mr *MockStorageMockRecorder) ListFeedArticles(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedArticles", reflect.TypeOf((*MockStorage)(nil).ListFeedArticles), arg0, arg1, arg2)
+}
+
 // ListFeeds mocks base method.
 func (m *MockStorage) ListFeeds(arg0 context.Context, arg1 *storage.Options) (storage.FeedList, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +419,51 @@ func (mr *MockStorageMockRecorder) ListFeeds(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeeds", reflect.TypeOf((*MockStorage)(nil).ListFeeds), arg0, arg1)
 }
 
+// ListFeedsByFolder mocks base method.
+func (m *MockStorage) ListFeedsByFolder(arg0 context.Context, arg1 string, arg2 *storage.Options) (storage.FeedList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedsByFolder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.FeedList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedsByFolder indicates an expected call of ListFeedsByFolder.
+func (mr *MockStorageMockRecorder) ListFeedsByFolder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedsByFolder", reflect.TypeOf((*MockStorage)(nil).ListFeedsByFolder), arg0, arg1, arg2)
+}
+
+// ListFilteredArticles mocks base method.
+func (m *MockStorage) ListFilteredArticles(arg0 context.Context, arg1 storage.ArticleFilter, arg2 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFilteredArticles", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFilteredArticles indicates an expected call of ListFilteredArticles.
+func (mr *MockStorageMockRecorder) ListFilteredArticles(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFilteredArticles", reflect.TypeOf((*MockStorage)(nil).ListFilteredArticles), arg0, arg1, arg2)
+}
+
+// ListFolders mocks base method.
+func (m *MockStorage) ListFolders(arg0 context.Context) ([]*storage.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFolders", arg0)
+	ret0, _ := ret[0].([]*storage.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFolders indicates an expected call of ListFolders.
+func (mr *MockStorageMockRecorder) ListFolders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFolders", reflect.TypeOf((*MockStorage)(nil).ListFolders), arg0)
+}
+
 // ListReadArticles mocks base method.
 func (m *MockStorage) ListReadArticles(arg0 context.Context, arg1 *storage.Options) (storage.ArticleList, error) {
 	m.ctrl.T.Helper()
@@ -169,6 +479,36 @@ func (mr *MockStorageMockRecorder) ListReadArticles(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReadArticles", reflect.TypeOf((*MockStorage)(nil).ListReadArticles), arg0, arg1)
 }
 
+// ListSavedArticles mocks base method.
+func (m *MockStorage) ListSavedArticles(arg0 context.Context, arg1 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSavedArticles", arg0, arg1)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSavedArticles indicates an expected call of ListSavedArticles.
+func (mr *MockStorageMockRecorder) ListSavedArticles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSavedArticles", reflect.TypeOf((*MockStorage)(nil).ListSavedArticles), arg0, arg1)
+}
+
+// ListTaggedArticles mocks base method.
+func (m *MockStorage) ListTaggedArticles(arg0 context.Context, arg1 string, arg2 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTaggedArticles", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTaggedArticles indicates an expected call of ListTaggedArticles.
+func (mr *MockStorageMockRecorder) ListTaggedArticles(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTaggedArticles", reflect.TypeOf((*MockStorage)(nil).ListTaggedArticles), arg0, arg1, arg2)
+}
+
 // ListUnreadArticles mocks base method.
 func (m *MockStorage) ListUnreadArticles(arg0 context.Context, arg1 *storage.Options) (storage.ArticleList, error) {
 	m.ctrl.T.Helper()
@@ -184,6 +524,51 @@ func (mr *MockStorageMockRecorder) ListUnreadArticles(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnreadArticles", reflect.TypeOf((*MockStorage)(nil).ListUnreadArticles), arg0, arg1)
 }
 
+// MarkAllRead mocks base method.
+func (m *MockStorage) MarkAllRead(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllRead", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAllRead indicates an expected call of MarkAllRead.
+func (mr *MockStorageMockRecorder) MarkAllRead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllRead", reflect.TypeOf((*MockStorage)(nil).MarkAllRead), arg0, arg1)
+}
+
+// MarkArticleRead mocks base method.
+func (m *MockStorage) MarkArticleRead(arg0 context.Context, arg1 string, arg2 bool) (*storage.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkArticleRead", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*storage.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkArticleRead indicates an expected call of MarkArticleRead.
+func (mr *MockStorageMockRecorder) MarkArticleRead(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkArticleRead", reflect.TypeOf((*MockStorage)(nil).MarkArticleRead), arg0, arg1, arg2)
+}
+
+// NextUnreadArticle mocks base method.
+func (m *MockStorage) NextUnreadArticle(arg0 context.Context, arg1 *storage.Article, arg2 *storage.Options) (*storage.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextUnreadArticle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*storage.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextUnreadArticle indicates an expected call of NextUnreadArticle.
+func (mr *MockStorageMockRecorder) NextUnreadArticle(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextUnreadArticle", reflect.TypeOf((*MockStorage)(nil).NextUnreadArticle), arg0, arg1, arg2)
+}
+
 // Now mocks base method.
 func (m *MockStorage) Now() time.Time {
 	m.ctrl.T.Helper()
@@ -197,3 +582,206 @@ func (mr *MockStorageMockRecorder) Now() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*MockStorage)(nil).Now))
 }
+
+// Ping mocks base method.
+func (m *MockStorage) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), arg0)
+}
+
+// PruneArticles mocks base method.
+func (m *MockStorage) PruneArticles(arg0 context.Context, arg1 storage.Retention) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneArticles", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneArticles indicates an expected call of PruneArticles.
+func (mr *MockStorageMockRecorder) PruneArticles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneArticles", reflect.TypeOf((*MockStorage)(nil).PruneArticles), arg0, arg1)
+}
+
+// RecordFeedFetch mocks base method.
+func (m *MockStorage) RecordFeedFetch(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFeedFetch", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFeedFetch indicates an expected call of RecordFeedFetch.
+func (mr *MockStorageMockRecorder) RecordFeedFetch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFeedFetch", reflect.TypeOf((*MockStorage)(nil).RecordFeedFetch), arg0, arg1, arg2)
+}
+
+// RenameFolder mocks base method.
+func (m *MockStorage) RenameFolder(arg0 context.Context, arg1, arg2 string) (*storage.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameFolder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*storage.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameFolder indicates an expected call of RenameFolder.
+func (mr *MockStorageMockRecorder) RenameFolder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameFolder", reflect.TypeOf((*MockStorage)(nil).RenameFolder), arg0, arg1, arg2)
+}
+
+// SearchArticles mocks base method.
+func (m *MockStorage) SearchArticles(arg0 context.Context, arg1 string, arg2 *storage.Options) (storage.ArticleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchArticles", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.ArticleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchArticles indicates an expected call of SearchArticles.
+func (mr *MockStorageMockRecorder) SearchArticles(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchArticles", reflect.TypeOf((*MockStorage)(nil).SearchArticles), arg0, arg1, arg2)
+}
+
+// SetArticleFavorited mocks base method.
+func (m *MockStorage) SetArticleFavorited(arg0 context.Context, arg1 string, arg2 bool) (*storage.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetArticleFavorited", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*storage.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetArticleFavorited indicates an expected call of SetArticleFavorited.
+func (mr *MockStorageMockRecorder) SetArticleFavorited(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetArticleFavorited", reflect.TypeOf((*MockStorage)(nil).SetArticleFavorited), arg0, arg1, arg2)
+}
+
+// SetArticleFullText mocks base method.
+func (m *MockStorage) SetArticleFullText(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetArticleFullText", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetArticleFullText indicates an expected call of SetArticleFullText.
+func (mr *MockStorageMockRecorder) SetArticleFullText(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetArticleFullText", reflect.TypeOf((*MockStorage)(nil).SetArticleFullText), arg0, arg1, arg2)
+}
+
+// SetArticleSaved mocks base method.
+func (m *MockStorage) SetArticleSaved(arg0 context.Context, arg1 string, arg2 bool) (*storage.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetArticleSaved", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*storage.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetArticleSaved indicates an expected call of SetArticleSaved.
+func (mr *MockStorageMockRecorder) SetArticleSaved(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetArticleSaved", reflect.TypeOf((*MockStorage)(nil).SetArticleSaved), arg0, arg1, arg2)
+}
+
+// SetFeedCacheHeaders mocks base method.
+func (m *MockStorage) SetFeedCacheHeaders(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFeedCacheHeaders", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFeedCacheHeaders indicates an expected call of SetFeedCacheHeaders.
+func (mr *MockStorageMockRecorder) SetFeedCacheHeaders(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFeedCacheHeaders", reflect.TypeOf((*MockStorage)(nil).SetFeedCacheHeaders), arg0, arg1, arg2, arg3)
+}
+
+// Stats mocks base method.
+func (m *MockStorage) Stats(arg0 context.Context) (storage.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", arg0)
+	ret0, _ := ret[0].(storage.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockStorageMockRecorder) Stats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockStorage)(nil).Stats), arg0)
+}
+
+// UnreadCounts mocks base method.
+func (m *MockStorage) UnreadCounts(arg0 context.Context) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadCounts", arg0)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnreadCounts indicates an expected call of UnreadCounts.
+func (mr *MockStorageMockRecorder) UnreadCounts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadCounts", reflect.TypeOf((*MockStorage)(nil).UnreadCounts), arg0)
+}
+
+// UpdateArticleContent mocks base method.
+func (m *MockStorage) UpdateArticleContent(arg0 context.Context, arg1, arg2, arg3, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateArticleContent", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateArticleContent indicates an expected call of UpdateArticleContent.
+func (mr *MockStorageMockRecorder) UpdateArticleContent(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateArticleContent", reflect.TypeOf((*MockStorage)(nil).UpdateArticleContent), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UpdateFeedTitle mocks base method.
+func (m *MockStorage) UpdateFeedTitle(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFeedTitle", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFeedTitle indicates an expected call of UpdateFeedTitle.
+func (mr *MockStorageMockRecorder) UpdateFeedTitle(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFeedTitle", reflect.TypeOf((*MockStorage)(nil).UpdateFeedTitle), arg0, arg1, arg2)
+}
+
+// UpdateFeedURL mocks base method.
+func (m *MockStorage) UpdateFeedURL(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFeedURL", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFeedURL indicates an expected call of UpdateFeedURL.
+func (mr *MockStorageMockRecorder) UpdateFeedURL(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFeedURL", reflect.TypeOf((*MockStorage)(nil).UpdateFeedURL), arg0, arg1, arg2)
+}
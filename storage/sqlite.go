@@ -2,441 +2,2055 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/kdwils/feedreader/pkg/links"
+	"github.com/mattn/go-sqlite3"
 )
 
 type SQLite struct {
-	db       *sqlx.DB
-	filePath string
+	db           *sqlx.DB
+	filePath     string
+	pragmas      Pragmas
+	pool         Pool
+	queryTimeout time.Duration
+
+	// stmts are the prepared statements by their query, kept until Close
+	stmtsMu sync.Mutex
+	stmts   map[string]*sql.Stmt
+}
+
+// Pragmas are the sqlite settings applied to every connection
+type Pragmas struct {
+	// JournalMode is the journal_mode, WAL lets the poller write while the api reads
+	JournalMode string
+	// Synchronous is how often sqlite waits for writes to reach the disk, NORMAL is safe with WAL
+	Synchronous string
+	// BusyTimeout is how long a connection waits for a lock held by another before failing with database is locked
+	BusyTimeout time.Duration
+	// ForeignKeys enforces the foreign key constraints of the tables
+	ForeignKeys bool
+}
+
+// DefaultPragmas are the pragmas used unless WithPragmas sets others
+func DefaultPragmas() Pragmas {
+	return Pragmas{
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5 * time.Second,
+		ForeignKeys: true,
+	}
+}
+
+// Pool limits the connections the database keeps open
+type Pool struct {
+	// MaxOpenConns is the most connections open at once, 0 is unlimited. sqlite allows one writer at a time, so a single connection serializes writes rather than leaving them to fail with database is locked.
+	MaxOpenConns int
+	// MaxIdleConns is the most connections kept open while unused, 0 keeps none
+	MaxIdleConns int
+	// ConnMaxLifetime is how long a connection is reused before it is closed, 0 reuses connections forever
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultPool is the pool used unless WithPool sets another
+func DefaultPool() Pool {
+	return Pool{
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}
+}
+
+// SQLiteOption configures optional SQLite settings
+type SQLiteOption func(*SQLite)
+
+// WithPragmas sets the pragmas applied to every connection. An empty journal mode or synchronous setting keeps sqlite's default.
+func WithPragmas(p Pragmas) SQLiteOption {
+	return func(s *SQLite) {
+		s.pragmas = p
+	}
+}
+
+// WithPool sets the limits of the connection pool
+func WithPool(p Pool) SQLiteOption {
+	return func(s *SQLite) {
+		s.pool = p
+	}
+}
+
+// WithQueryTimeout sets how long a storage call may run before it is canceled, 0 leaves calls bounded only by their context
+func WithQueryTimeout(d time.Duration) SQLiteOption {
+	return func(s *SQLite) {
+		s.queryTimeout = d
+	}
 }
 
 const (
-	maxPublishedDate = "9999999999"
-	maxFeedID        = "9999999999"
+	maxFeedID = "9999999999"
 )
 
-func NewSQLiteStorage(filePath string) Storage {
-	return &SQLite{
+// feedColumns and articleColumns are the column orders used by every query so scans never depend on the table definition
+const (
+	feedColumns    = "id, COALESCE(custom_title, title) AS title, rssLink, siteLink, description, timestamp, etag, lastModified, image, language, copyright, COALESCE(folder_id, '') AS folder_id, COALESCE(custom_title, '') AS custom_title, last_fetch_time, last_success_time, last_error, consecutive_failures"
+	articleColumns = "id, feed, title, author, description, link, published, read, read_date, favorited, saved, timestamp, content, guid, enclosure_url, enclosure_type, enclosure_length, original_published, canonical_link"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFeed(row scanner) (*Feed, error) {
+	var f Feed
+	err := row.Scan(&f.ID, &f.Title, &f.RSSLink, &f.SiteLink, &f.Description, &f.Timestamp, &f.ETag, &f.LastModified, &f.Image, &f.Language, &f.Copyright, &f.FolderID, &f.CustomTitle, &f.LastFetchTime, &f.LastSuccessTime, &f.LastError, &f.ConsecutiveFailures)
+	return &f, err
+}
+
+// scanRows scans every row, returning the context's error as soon as it is canceled so an abandoned request stops reading rows. The rows are closed once scanned.
+func scanRows[T any](ctx context.Context, rows *sql.Rows, scan func(scanner) (T, error)) ([]T, error) {
+	defer rows.Close()
+
+	items := make([]T, 0)
+	for rows.Next() {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	// the driver may notice the cancellation first, ending the rows early with the context's error
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func scanArticle(row scanner) (*Article, error) {
+	var a Article
+	var enclosure Enclosure
+	err := row.Scan(&a.ID, &a.FeedID, &a.Title, &a.Author, &a.Description, &a.Link, &a.PublishedUnix, &a.Read, &a.ReadDate, &a.Favorited, &a.Saved, &a.Timestamp, &a.Content, &a.GUID, &enclosure.URL, &enclosure.Type, &enclosure.Length, &a.OriginalPublished, &a.CanonicalLink)
+	if err != nil {
+		return nil, err
+	}
+
+	if enclosure.URL != "" {
+		a.Enclosure = &enclosure
+	}
+
+	a.Published = time.Unix(a.PublishedUnix, 0).UTC().Format("Mon, 02 Jan 2006")
+	return &a, nil
+}
+
+// isUniqueConstraintError reports whether the insert failed because it would duplicate a UNIQUE column
+func isUniqueConstraintError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// isForeignKeyConstraintError reports whether the statement failed because it references a row that does not exist
+func isForeignKeyConstraintError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
+
+func NewSQLiteStorage(filePath string, opts ...SQLiteOption) Storage {
+	s := &SQLite{
 		filePath: filePath,
+		pragmas:  DefaultPragmas(),
+		pool:     DefaultPool(),
+		stmts:    make(map[string]*sql.Stmt),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *SQLite) Now() time.Time {
+	return time.Now()
+}
+
+func (s *SQLite) Connect() error {
+	db, err := sqlx.Open("sqlite3", s.dsn())
+	if err != nil {
+		return err
+	}
+
+	db.SetMaxOpenConns(s.pool.MaxOpenConns)
+	db.SetMaxIdleConns(s.pool.MaxIdleConns)
+	db.SetConnMaxLifetime(s.pool.ConnMaxLifetime)
+	s.db = db
+
+	return s.migrate(context.Background())
+}
+
+// withTimeout bounds ctx by the query timeout when one is set. The returned cancel func must be called once the call's rows are read.
+func (s *SQLite) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// dsn adds the pragmas to the file path as parameters of the sqlite driver, which applies them to each connection it opens.
+// Setting them with a PRAGMA statement would only apply to whichever pooled connection ran it.
+func (s *SQLite) dsn() string {
+	params := url.Values{}
+	if s.pragmas.JournalMode != "" {
+		params.Set("_journal_mode", s.pragmas.JournalMode)
+	}
+	if s.pragmas.Synchronous != "" {
+		params.Set("_synchronous", s.pragmas.Synchronous)
+	}
+	if s.pragmas.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(s.pragmas.BusyTimeout.Milliseconds(), 10))
+	}
+	params.Set("_foreign_keys", strconv.FormatBool(s.pragmas.ForeignKeys))
+
+	sep := "?"
+	if strings.Contains(s.filePath, "?") {
+		sep = "&"
+	}
+
+	return s.filePath + sep + params.Encode()
+}
+
+// Close closes the prepared statements and then the database
+func (s *SQLite) Close() error {
+	s.stmtsMu.Lock()
+	defer s.stmtsMu.Unlock()
+
+	var errs []error
+	for query, stmt := range s.stmts {
+		errs = append(errs, stmt.Close())
+		delete(s.stmts, query)
+	}
+
+	return errors.Join(append(errs, s.db.Close())...)
+}
+
+// prepare returns the prepared statement for the query, preparing it the first time the query is run. The statement is shared, so callers must not close it.
+// The query text must not vary with user input, which belongs in the statement's arguments, or the cache grows without bound.
+func (s *SQLite) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.stmtsMu.Lock()
+	stmt, ok := s.stmts[query]
+	s.stmtsMu.Unlock()
+	if ok {
+		return stmt, nil
+	}
+
+	// preparing waits for a connection, so the lock is not held while another caller may be using the only one
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.stmtsMu.Lock()
+	defer s.stmtsMu.Unlock()
+
+	// another caller may have prepared the same query in the meantime
+	if existing, ok := s.stmts[query]; ok {
+		stmt.Close()
+		return existing, nil
+	}
+	s.stmts[query] = stmt
+
+	return stmt, nil
+}
+
+// Ping runs a trivial query to check the database can be reached
+func (s *SQLite) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var one int
+	return s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// CreateFeed stores a new feed. When a feed with the same rss link already exists, that feed is returned along with ErrDuplicateFeed.
+// Several feeds may share a site, such as a site's main feed and its category feeds.
+func (s *SQLite) CreateFeed(ctx context.Context, title, rssLink, siteLink, description, image, language, copyright string) (*Feed, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if rssLink == "" {
+		return nil, errors.New("feed link is empty")
+	}
+
+	if title == "" {
+		return nil, errors.New("feed title is empty")
+	}
+
+	rssLink, err := links.Normalize(rssLink)
+	if err != nil {
+		return nil, err
+	}
+
+	siteLink, err = parseSiteLinkFromURI(rssLink)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO feeds (title, rssLink, siteLink, site, description, image, language, copyright, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Feed{
+		Title:       title,
+		SiteLink:    siteLink,
+		RSSLink:     rssLink,
+		Description: description,
+		Image:       image,
+		Language:    language,
+		Copyright:   copyright,
+		Timestamp:   s.Now().UTC().Unix(),
+	}
+
+	result, err := stmt.ExecContext(ctx, f.Title, f.RSSLink, f.SiteLink, siteHost(f.SiteLink), f.Description, f.Image, f.Language, f.Copyright, f.Timestamp)
+	if isUniqueConstraintError(err) {
+		existing, err := s.getFeedByRSSLink(ctx, f.RSSLink)
+		if err != nil {
+			return nil, err
+		}
+
+		return existing, ErrDuplicateFeed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return f, err
+	}
+
+	f.ID = fmt.Sprintf("%d", id)
+	return f, nil
+}
+
+// getFeedBySite returns the first feed stored for the site the link belongs to
+func (s *SQLite) getFeedBySite(ctx context.Context, link string) (*Feed, error) {
+	query := fmt.Sprintf("SELECT %s FROM feeds WHERE site = ? ORDER BY id LIMIT 1", feedColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeed(stmt.QueryRowContext(ctx, siteHost(link)))
+}
+
+// GetFeed returns the feed with the id, or ErrNotFound when there is none
+func (s *SQLite) GetFeed(ctx context.Context, id string) (*Feed, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT %s FROM feeds WHERE id = ?", feedColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := scanFeed(stmt.QueryRowContext(ctx, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// RecordFeedFetch records the outcome of refreshing the feed. An empty fetchError is a success, which clears the error and failure count left by earlier failures.
+func (s *SQLite) RecordFeedFetch(ctx context.Context, id, fetchError string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := s.Now().Unix()
+	query := "UPDATE feeds SET last_fetch_time = ?, last_success_time = ?, last_error = '', consecutive_failures = 0 WHERE id = ?"
+	args := []any{now, now, id}
+	if fetchError != "" {
+		query = "UPDATE feeds SET last_fetch_time = ?, last_error = ?, consecutive_failures = consecutive_failures + 1 WHERE id = ?"
+		args = []any{now, fetchError, id}
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListFailingFeeds returns the feeds whose last refresh failed, those failing the longest first
+func (s *SQLite) ListFailingFeeds(ctx context.Context) ([]*Feed, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT %s FROM feeds WHERE consecutive_failures > 0 ORDER BY consecutive_failures DESC, id", feedColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRows(ctx, rows, scanFeed)
+}
+
+// UnreadCounts returns the number of unread articles keyed by feed id. Feeds without unread articles have a count of 0.
+func (s *SQLite) UnreadCounts(ctx context.Context) (map[string]int, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT feeds.id, COUNT(articles.id) FROM feeds LEFT JOIN articles ON articles.feed = feeds.id AND articles.read = false GROUP BY feeds.id"
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type unreadCount struct {
+		feedID string
+		count  int
+	}
+	unread, err := scanRows(ctx, rows, func(row scanner) (unreadCount, error) {
+		var c unreadCount
+		err := row.Scan(&c.feedID, &c.count)
+		return c, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(unread))
+	for _, c := range unread {
+		counts[c.feedID] = c.count
+	}
+
+	return counts, nil
+}
+
+// Stats counts the feeds and articles with a single aggregate query
+func (s *SQLite) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	if s.db == nil {
+		return stats, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT
+		(SELECT COUNT(*) FROM feeds) AS feeds,
+		COUNT(*) AS articles,
+		COALESCE(SUM(read = false), 0) AS unread,
+		COALESCE(SUM(favorited = true), 0) AS favorited,
+		COALESCE(MAX(timestamp), 0) AS last_added
+		FROM articles`
+	err := s.db.GetContext(ctx, &stats, query)
+	return stats, err
+}
+
+// getFeedByRSSLink finds the feed fetched from the rss link
+func (s *SQLite) getFeedByRSSLink(ctx context.Context, rssLink string) (*Feed, error) {
+	query := fmt.Sprintf("SELECT %s FROM feeds WHERE rssLink = ?", feedColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeed(stmt.QueryRowContext(ctx, rssLink))
+}
+
+func (s *SQLite) ListFeeds(ctx context.Context, opts *Options) (FeedList, error) {
+	if s.db == nil {
+		return FeedList{}, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doFeedQueries(ctx, "1 = 1", opts)
+}
+
+// ListFeedsByFolder returns a page of the folder's feeds, or of the uncategorized feeds when folderID is empty
+func (s *SQLite) ListFeedsByFolder(ctx context.Context, folderID string, opts *Options) (FeedList, error) {
+	if s.db == nil {
+		return FeedList{}, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	if folderID == "" {
+		return s.doFeedQueries(ctx, "folder_id IS NULL", opts)
+	}
+
+	return s.doFeedQueries(ctx, "folder_id = ?", opts, folderID)
+}
+
+// count runs a query selecting a single count
+func (s *SQLite) count(ctx context.Context, query string, args ...any) (int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&total)
+	return total, err
+}
+
+// doFeedQueries runs the next and prev page queries for feeds matching the where clause, newest first. args are bound to the where clause placeholders ahead of the cursor and limit.
+func (s *SQLite) doFeedQueries(ctx context.Context, where string, opts *Options, args ...any) (FeedList, error) {
+	cursor, limit := opts.Cursor, opts.Limit
+	feedList := FeedList{
+		Meta:  Meta{Limit: limit},
+		Feeds: make([]*Feed, 0),
+	}
+
+	err := opts.checkCursorField(cursorFieldID)
+	if err != nil {
+		return feedList, err
+	}
+
+	if opts.Total {
+		total, err := s.count(ctx, fmt.Sprintf("SELECT COUNT(*) FROM feeds WHERE %s", where), args...)
+		if err != nil {
+			return feedList, err
+		}
+		feedList.Meta.Total = &total
+	}
+
+	nextQuery := fmt.Sprintf("SELECT %s FROM feeds WHERE %s AND id < ? ORDER BY id %s LIMIT ?", feedColumns, where, Descending.string())
+	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT %s FROM feeds WHERE %s AND id >= ? ORDER BY id %s LIMIT ? ) AS data ORDER BY id %s", feedColumns, where, Ascending.string(), Descending.string())
+
+	nextStmt, err := s.prepare(ctx, nextQuery)
+	if err != nil {
+		return feedList, err
+	}
+
+	nextPagination := cursor
+	if nextPagination == "" {
+		nextPagination = maxFeedID
+	}
+	// one more than the limit is fetched to tell whether there is another page
+	next, err := nextStmt.QueryContext(ctx, append(append([]any{}, args...), nextPagination, limit+1)...)
+	if err != nil {
+		return feedList, err
+	}
+
+	nextFeeds, err := scanRows(ctx, next, scanFeed)
+	if err != nil {
+		return feedList, err
+	}
+
+	prevStmt, err := s.prepare(ctx, prevQuery)
+	if err != nil {
+		return feedList, err
+	}
+
+	prev, err := prevStmt.QueryContext(ctx, append(append([]any{}, args...), cursor, limit+1)...)
+	if err != nil {
+		return feedList, err
+	}
+
+	prevFeeds, err := scanRows(ctx, prev, scanFeed)
+	if err != nil {
+		return feedList, err
+	}
+
+	nextArticles, nextCursor := getPagination(nextFeeds, prevFeeds, limit, maxFeedID, (*Feed).GetPaginationField)
+	feedList.Feeds = nextArticles
+	feedList.Cursor = opts.encodeCursor(nextCursor, cursorFieldID)
+	return feedList, nil
+}
+
+// CreateFolder stores a new folder. Folder names are unique regardless of case, ErrDuplicateFolder is returned for a name already in use.
+func (s *SQLite) CreateFolder(ctx context.Context, name string) (*Folder, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("folder name is empty")
+	}
+
+	f := &Folder{
+		Name:      name,
+		Timestamp: s.Now().UTC().Unix(),
+	}
+
+	result, err := s.db.ExecContext(ctx, "INSERT INTO folders (name, timestamp) VALUES (?, ?)", f.Name, f.Timestamp)
+	if isUniqueConstraintError(err) {
+		return nil, ErrDuplicateFolder
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	f.ID = fmt.Sprintf("%d", id)
+	return f, nil
+}
+
+func (s *SQLite) GetFolder(ctx context.Context, id string) (*Folder, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var f Folder
+	err := s.db.GetContext(ctx, &f, "SELECT id, name, timestamp FROM folders WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// ListFolders returns every folder ordered by name
+func (s *SQLite) ListFolders(ctx context.Context) ([]*Folder, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	folders := make([]*Folder, 0)
+	err := s.db.SelectContext(ctx, &folders, "SELECT id, name, timestamp FROM folders ORDER BY name COLLATE NOCASE, id")
+	return folders, err
+}
+
+func (s *SQLite) RenameFolder(ctx context.Context, id, name string) (*Folder, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("folder name is empty")
+	}
+
+	result, err := s.db.ExecContext(ctx, "UPDATE folders SET name = ? WHERE id = ?", name, id)
+	if isUniqueConstraintError(err) {
+		return nil, ErrDuplicateFolder
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetFolder(ctx, id)
+}
+
+// DeleteFolder removes the folder, leaving its feeds uncategorized
+func (s *SQLite) DeleteFolder(ctx context.Context, id string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE feeds SET folder_id = NULL WHERE folder_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM folders WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// AssignFeedToFolder moves the feed into the folder, or out of any folder when folderID is empty. ErrNotFound is returned when either does not exist.
+func (s *SQLite) AssignFeedToFolder(ctx context.Context, feedID, folderID string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var folder any
+	if folderID != "" {
+		_, err := s.GetFolder(ctx, folderID)
+		if err != nil {
+			return err
+		}
+		folder = folderID
+	}
+
+	result, err := s.db.ExecContext(ctx, "UPDATE feeds SET folder_id = ? WHERE id = ?", folder, feedID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SetFeedCacheHeaders stores the cache validators from the feed's last response so the next fetch can be conditional
+func (s *SQLite) SetFeedCacheHeaders(ctx context.Context, id, etag, lastModified string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE feeds SET etag = ?, lastModified = ? WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	_, err = stmt.ExecContext(ctx, etag, lastModified, id)
+	return err
+}
+
+// UpdateFeedURL replaces the link the feed is fetched from, such as after the feed has permanently moved
+func (s *SQLite) UpdateFeedURL(ctx context.Context, id, rssLink string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rssLink, err := links.Normalize(rssLink)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE feeds SET rssLink = ? WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, rssLink, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateFeedTitle sets the title the feed is shown with in place of its channel's title. An empty title goes back to the channel's title.
+func (s *SQLite) UpdateFeedTitle(ctx context.Context, id, title string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var customTitle any
+	if title != "" {
+		customTitle = title
+	}
+
+	query := "UPDATE feeds SET custom_title = ? WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	result, err := stmt.ExecContext(ctx, customTitle, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteFeed removes the feed and all of its articles in a single transaction
+func (s *SQLite) DeleteFeed(ctx context.Context, id string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id IN (SELECT id FROM articles WHERE feed = ?)", id)
+	if err != nil {
+		return err
+	}
+
+	// the feed stops carrying other feeds' articles, and its own articles go with it
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_feeds WHERE feed_id = ? OR article_id IN (SELECT id FROM articles WHERE feed = ?)", id, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM articles WHERE feed = ?", id)
+	if err != nil {
+		return err
+	}
+
+	// resubscribing to the feed should start from every article it publishes
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_tombstones WHERE feed = ?", id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM feeds WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// CreateArticle stores a new article of the feed. An empty feedID stores it under the first feed of the site the link belongs to, for articles that do not come from refreshing a feed.
+// originalPublished is the publish date as the feed wrote it. ErrFeedMissing is returned when there is no such feed.
+func (s *SQLite) CreateArticle(ctx context.Context, feedID, link, guid, title, author, description, content string, enclosure *Enclosure, tags []string, published time.Time, originalPublished string) (*Article, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if link == "" {
+		return nil, errors.New("article link is empty")
+	}
+
+	if title == "" {
+		return nil, errors.New("article title is empty")
+	}
+
+	if author == "" {
+		return nil, errors.New("article author is empty")
+	}
+
+	_, err := links.Normalize(link)
+	if err != nil {
+		return nil, err
+	}
+
+	if feedID == "" {
+		feed, err := s.getFeedBySite(ctx, link)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: no feed for %s", ErrFeedMissing, link)
+		}
+		if err != nil {
+			return nil, err
+		}
+		feedID = feed.ID
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var deleted int
+	err = tx.GetContext(ctx, &deleted, "SELECT COUNT(*) FROM article_tombstones WHERE link = ? OR (guid != '' AND guid = ? AND feed = ?)", link, guid, feedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deleted > 0 {
+		return nil, ErrArticleDeleted
+	}
+
+	query := "INSERT INTO articles (feed, link, canonical_link, guid, title, author, description, content, enclosure_url, enclosure_type, enclosure_length, published, original_published, read_date, read, favorited, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	article := &Article{
+		Link:              link,
+		CanonicalLink:     links.Canonical(link),
+		GUID:              guid,
+		FeedID:            feedID,
+		Title:             title,
+		Description:       description,
+		Content:           content,
+		Enclosure:         enclosure,
+		Tags:              normalizeTags(tags),
+		Author:            author,
+		PublishedUnix:     published.UTC().Unix(),
+		OriginalPublished: originalPublished,
+		ReadDate:          "",
+		Favorited:         false,
+		Read:              false,
+		Timestamp:         s.Now().UTC().Unix(),
+	}
+
+	var media Enclosure
+	if enclosure != nil {
+		media = *enclosure
+	}
+
+	result, err := stmt.ExecContext(ctx, feedID, article.Link, article.CanonicalLink, article.GUID, article.Title, article.Author, article.Description, article.Content, media.URL, media.Type, media.Length, article.PublishedUnix, article.OriginalPublished, article.ReadDate, article.Read, article.Favorited, article.Timestamp)
+	if isUniqueConstraintError(err) {
+		return nil, ErrDuplicateArticle
+	}
+	// the feed does not exist or was deleted after it was looked up
+	if isForeignKeyConstraintError(err) {
+		return nil, fmt.Errorf("%w: feed %s", ErrFeedMissing, feedID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range article.Tags {
+		_, err = tx.ExecContext(ctx, "INSERT INTO article_tags (article_id, tag) VALUES (?, ?)", id, tag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	article.ID = fmt.Sprintf("%d", id)
+	return article, nil
+}
+
+func (s *SQLite) ListReadArticles(ctx context.Context, opts *Options) (ArticleList, error) {
+	var articleList ArticleList
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "read = true", opts)
+}
+
+func (s *SQLite) ListUnreadArticles(ctx context.Context, opts *Options) (ArticleList, error) {
+	var articleList ArticleList
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "read = false", opts)
+}
+
+func (s *SQLite) ListFavoritedArticles(ctx context.Context, opts *Options) (ArticleList, error) {
+	var articleList ArticleList
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "favorited = true", opts)
+}
+
+// ListSavedArticles returns a page of the articles saved to read later
+func (s *SQLite) ListSavedArticles(ctx context.Context, opts *Options) (ArticleList, error) {
+	var articleList ArticleList
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "saved = true", opts)
+}
+
+// articleQueries builds the keyset pagination queries for articles matching the where clause, sorted by opts.SortBy in the direction of opts.Order with the id breaking ties.
+// Articles often share a published date, so the cursor holds both the sort value and the id and is compared against them together, otherwise a page boundary between equal values would skip or repeat articles.
+// The first page has no cursor so the next query only compares against the cursor when there is one.
+// The cursor and limit are left as placeholders for doArticleQueries to bind, with the cursor's values returned as the args to bind them to.
+func articleQueries(where string, opts *Options) (string, string, []any) {
+	column := opts.SortBy.column()
+	orderBy := fmt.Sprintf("%s %s, id %s", column, opts.Order.string(), opts.Order.string())
+	oppositeOrderBy := fmt.Sprintf("%s %s, id %s", column, opts.Order.opposite(), opts.Order.opposite())
+
+	key, placeholder, cursorArgs := cursorKey(column, opts.Cursor)
+
+	nextWhere := where
+	if opts.Cursor != "" {
+		nextWhere = fmt.Sprintf("%s AND %s %s %s", where, key, opts.Order.comparison(), placeholder)
+	}
+
+	nextQuery := fmt.Sprintf("SELECT %s FROM articles WHERE %s ORDER BY %s LIMIT ?", articleColumns, nextWhere, orderBy)
+	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT %s FROM articles WHERE %s AND %s %s %s ORDER BY %s LIMIT ? ) AS data ORDER BY %s", articleColumns, where, key, opts.Order.oppositeComparison(), placeholder, oppositeOrderBy, orderBy)
+	return nextQuery, prevQuery, cursorArgs
+}
+
+// cursorKey returns the expression an article cursor is compared against, the placeholder to compare it with, and the cursor's values to bind to the placeholder
+func cursorKey(column, cursor string) (string, string, []any) {
+	value, id := splitCursor(cursor)
+	// cursors from before the id was added only hold the sort value
+	if id == "" {
+		return column, "?", []any{value}
+	}
+
+	return fmt.Sprintf("(%s, id)", column), "(?, ?)", []any{value, id}
+}
+
+// doArticleQueries runs the next and prev page queries for articles matching the where clause. args are bound to the where clause placeholders ahead of the cursor and limit.
+func (s *SQLite) doArticleQueries(ctx context.Context, where string, opts *Options, args ...any) (ArticleList, error) {
+	articleList := ArticleList{
+		Meta:     Meta{Limit: opts.Limit},
+		Articles: make([]*Article, 0),
+	}
+
+	err := opts.checkCursorField(opts.SortBy.name())
+	if err != nil {
+		return articleList, err
+	}
+
+	if opts.Total {
+		total, err := s.count(ctx, fmt.Sprintf("SELECT COUNT(*) FROM articles WHERE %s", where), args...)
+		if err != nil {
+			return articleList, err
+		}
+		articleList.Meta.Total = &total
+	}
+
+	nextQuery, prevQuery, cursorArgs := articleQueries(where, opts)
+
+	nextStmt, err := s.prepare(ctx, nextQuery)
+	if err != nil {
+		return articleList, err
+	}
+
+	// one more than the limit is fetched to tell whether there is another page
+	limit := opts.Limit + 1
+	nextArgs := append([]any{}, args...)
+	if opts.Cursor != "" {
+		nextArgs = append(nextArgs, cursorArgs...)
+	}
+	next, err := nextStmt.QueryContext(ctx, append(nextArgs, limit)...)
+	if err != nil {
+		return articleList, err
+	}
+
+	nextArticles, err := scanRows(ctx, next, scanArticle)
+	if err != nil {
+		return articleList, err
+	}
+
+	// the first page has nothing before it
+	prevArticles := make([]*Article, 0)
+	if opts.Cursor != "" {
+		prevStmt, err := s.prepare(ctx, prevQuery)
+		if err != nil {
+			return articleList, err
+		}
+
+		prevArgs := append(append(append([]any{}, args...), cursorArgs...), limit)
+		prev, err := prevStmt.QueryContext(ctx, prevArgs...)
+		if err != nil {
+			return articleList, err
+		}
+
+		prevArticles, err = scanRows(ctx, prev, scanArticle)
+		if err != nil {
+			return articleList, err
+		}
+	}
+
+	// an empty cursor leads back to the first page
+	nextArticles, nextCursor := getPagination(nextArticles, prevArticles, opts.Limit, "", opts.SortBy.cursor)
+	if len(nextArticles) > 0 {
+		nextCursor.Head = opts.SortBy.cursor(nextArticles[0])
+	}
+	err = s.loadTags(ctx, nextArticles)
+	if err != nil {
+		return articleList, err
+	}
+
+	articleList.Articles = nextArticles
+	articleList.Cursor = opts.encodeCursor(nextCursor, opts.SortBy.name())
+	return articleList, nil
+}
+
+// ListArticlesByStatus returns a page of the articles with the status
+func (s *SQLite) ListArticlesByStatus(ctx context.Context, status Status, opts *Options) (ArticleList, error) {
+	if s.db == nil {
+		return ArticleList{}, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, status.where(), opts)
+}
+
+// ListArticlesBetween returns a page of the articles with the status published from from to to, inclusive. A zero from or to leaves that end of the range open.
+func (s *SQLite) ListArticlesBetween(ctx context.Context, status Status, from, to time.Time, opts *Options) (ArticleList, error) {
+	if s.db == nil {
+		return ArticleList{}, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	where, args, err := ArticleFilter{Status: status, From: from, To: to}.where()
+	if err != nil {
+		return ArticleList{}, err
+	}
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, where, opts, args...)
+}
+
+func (s *SQLite) ListArticles(ctx context.Context, opts *Options) (ArticleList, error) {
+	articleList := ArticleList{
+		Articles: make([]*Article, 0),
+	}
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "read = false", opts)
+}
+
+// ListTaggedArticles returns a page of the articles with the tag, ignoring case
+func (s *SQLite) ListTaggedArticles(ctx context.Context, tag string, opts *Options) (ArticleList, error) {
+	var articleList ArticleList
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "id IN (SELECT article_id FROM article_tags WHERE tag = ?)", opts, strings.TrimSpace(tag))
+}
+
+// loadTags sets the tags of each article using a single query
+func (s *SQLite) loadTags(ctx context.Context, articles []*Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*Article, len(articles))
+	args := make([]any, 0, len(articles))
+	for _, a := range articles {
+		a.Tags = make([]string, 0)
+		byID[a.ID] = a
+		args = append(args, a.ID)
+	}
+
+	query := fmt.Sprintf("SELECT article_id, tag FROM article_tags WHERE article_id IN (?%s) ORDER BY rowid", strings.Repeat(", ?", len(articles)-1))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, tag string
+		err = rows.Scan(&id, &tag)
+		if err != nil {
+			return err
+		}
+
+		if a, ok := byID[id]; ok {
+			a.Tags = append(a.Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchArticles matches the query against article titles, descriptions, and authors. A blank query returns no articles.
+func (s *SQLite) SearchArticles(ctx context.Context, query string, opts *Options) (ArticleList, error) {
+	articleList := ArticleList{
+		Articles: make([]*Article, 0),
+	}
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return articleList, nil
+	}
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	return s.doArticleQueries(ctx, `(title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\' OR author LIKE ? ESCAPE '\')`, opts, pattern, pattern, pattern)
+}
+
+// ListArticlesByFeed returns every article of the feed without its tags
+func (s *SQLite) ListArticlesByFeed(ctx context.Context, feedID string) ([]*Article, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := fmt.Sprintf("SELECT %s FROM articles WHERE feed = ?", articleColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(ctx, feedID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanRows(ctx, rows, scanArticle)
+}
+
+// ListFeedArticles returns a page of the feed's articles. Use ListArticlesByFeed when every article is needed.
+func (s *SQLite) ListFeedArticles(ctx context.Context, feedID string, opts *Options) (ArticleList, error) {
+	var articleList ArticleList
+
+	if s.db == nil {
+		return articleList, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	return s.doArticleQueries(ctx, "feed = ?", opts, feedID)
+}
+
+// CountArticlesSince counts the articles matching the filter that come ahead of opts.Cursor in the list sorted and ordered by opts, without listing them.
+// Given the head cursor of the first page, newest first, this is how many articles arrived since the page was listed. Sorted by published date, an article published before the head is not counted however recently it was added.
+// ErrInvalidCursor is returned without a cursor, and the filter's errors are those of ListFilteredArticles.
+func (s *SQLite) CountArticlesSince(ctx context.Context, filter ArticleFilter, opts *Options) (int, error) {
+	if s.db == nil {
+		return 0, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if opts == nil || opts.Cursor == "" {
+		return 0, ErrInvalidCursor
+	}
+
+	err := opts.checkCursorField(opts.SortBy.name())
+	if err != nil {
+		return 0, err
+	}
+
+	where, args, err := filter.where()
+	if err != nil {
+		return 0, err
+	}
+
+	// only articles strictly ahead are counted, so the article the cursor was made from is not
+	comparison := ">"
+	if opts.Order == Ascending {
+		comparison = "<"
+	}
+
+	key, placeholder, cursorArgs := cursorKey(opts.SortBy.column(), opts.Cursor)
+	return s.count(ctx, fmt.Sprintf("SELECT COUNT(*) FROM articles WHERE %s AND %s %s %s", where, key, comparison, placeholder), append(args, cursorArgs...)...)
+}
+
+// ListFilteredArticles returns a page of the articles matching every field of the filter, such as the unread articles of a folder's feeds published this week.
+// ErrInvalidFeedID is returned when a feed id is not a number and ErrInvalidRange when the filter's range ends before it starts.
+func (s *SQLite) ListFilteredArticles(ctx context.Context, filter ArticleFilter, opts *Options) (ArticleList, error) {
+	if s.db == nil {
+		return ArticleList{}, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	where, args, err := filter.where()
+	if err != nil {
+		return ArticleList{}, err
+	}
+
+	if opts == nil {
+		opts = DefaultOptions()
 	}
+
+	return s.doArticleQueries(ctx, where, opts, args...)
 }
 
-func (s *SQLite) Now() time.Time {
-	return time.Now()
+// GetArticle returns the article with the id, or ErrNotFound when there is none
+func (s *SQLite) GetArticle(ctx context.Context, id string) (*Article, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.getArticleByID(ctx, id)
 }
 
-func (s *SQLite) Connect() error {
-	db, err := sqlx.Open("sqlite3", s.filePath)
+// DeleteArticle removes the article and leaves a tombstone of its link and guid behind.
+// CreateArticle refuses to store an article matching a tombstone with ErrArticleDeleted, so the next refresh of its feed does not store it again.
+// The tombstones are removed along with their feed.
+func (s *SQLite) DeleteArticle(ctx context.Context, id string) error {
+	if s.db == nil {
+		return ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	s.db = db
+	var article struct {
+		Link string `db:"link"`
+		GUID string `db:"guid"`
+		Feed string `db:"feed"`
+	}
+	err = tx.GetContext(ctx, &article, "SELECT link, guid, feed FROM articles WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
 
-	init := `
-	CREATE TABLE IF NOT EXISTS feeds (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		rssLink TEXT NOT NULL UNIQUE,
-		siteLink TEXT NOT NULL UNIQUE,
-		description TEXT NOT NULL,
-		timestamp INT NOT NULL
-	);
-	
-	CREATE TABLE IF NOT EXISTS articles (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		feed INTEGER NOT NULL,
-		title TEXT NOT NULL,
-		author TEXT NOT NULL,
-		description TEXT NOT NULL,
-		link TEXT NOT NULL UNIQUE,
-		published TEXT NOT NULL,
-		read BOOLEAN NOT NULL,
-		read_date TEXT NOT NULL,
-		favorited BOOLEAN NOT NULL,
-		timestamp INT NOT NULL,
-		FOREIGN KEY(feed) REFERENCES feeds(id)
-	);`
-
-	_, err = db.Exec(init)
-	return err
-}
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = ?", id)
+	if err != nil {
+		return err
+	}
 
-func (s *SQLite) Close() error {
-	return s.db.Close()
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_feeds WHERE article_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM articles WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO article_tombstones (link, guid, feed, deleted) VALUES (?, ?, ?, ?)", article.Link, article.GUID, article.Feed, s.Now().UTC().Unix())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *SQLite) CreateFeed(ctx context.Context, title, rssLink, siteLink, description string) (*Feed, error) {
+// prunableArticles selects the ids of the read articles outside the retention, each article is numbered by its position among its feed's articles from newest to oldest
+const prunableArticles = `
+	SELECT id FROM (
+		SELECT id, read, favorited, saved, published, ROW_NUMBER() OVER (PARTITION BY feed ORDER BY published DESC, id DESC) AS position
+		FROM articles
+	)
+	WHERE read = true AND favorited = false AND saved = false
+	AND ((? > 0 AND published < ?) OR (? > 0 AND position > ?))`
+
+// PruneArticles removes the read articles outside the retention and returns how many were removed.
+// Like DeleteArticle, each removed article leaves a tombstone behind so the next refresh of its feed does not store it again.
+func (s *SQLite) PruneArticles(ctx context.Context, retention Retention) (int, error) {
 	if s.db == nil {
-		return nil, ErrNilDB
+		return 0, ErrNilDB
 	}
 
-	if rssLink == "" {
-		return nil, errors.New("feed link is empty")
+	if retention.MaxAge <= 0 && retention.MaxPerFeed <= 0 {
+		return 0, nil
 	}
 
-	if title == "" {
-		return nil, errors.New("feed title is empty")
-	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	siteLink, err := parseSiteLinkFromURI(rssLink)
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	query := "INSERT INTO feeds (title, siteLink, rssLink, description, timestamp) VALUES (?, ?, ?, ?, ?)"
+	now := s.Now().UTC()
+	args := []any{int64(retention.MaxAge), now.Add(-retention.MaxAge).Unix(), retention.MaxPerFeed, retention.MaxPerFeed}
 
-	stmt, err := s.db.PrepareContext(ctx, query)
+	// the articles are removed last since removing them changes which articles are prunable
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO article_tombstones (link, guid, feed, deleted) SELECT link, guid, feed, ? FROM articles WHERE id IN ("+prunableArticles+")", append([]any{now.Unix()}, args...)...)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer stmt.Close()
 
-	f := &Feed{
-		Title:       title,
-		SiteLink:    siteLink,
-		RSSLink:     rssLink,
-		Description: description,
-		Timestamp:   s.Now().UTC().Unix(),
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id IN ("+prunableArticles+")", args...)
+	if err != nil {
+		return 0, err
 	}
 
-	result, err := stmt.ExecContext(ctx, f.Title, f.SiteLink, f.RSSLink, f.Description, f.Timestamp)
+	_, err = tx.ExecContext(ctx, "DELETE FROM article_feeds WHERE article_id IN ("+prunableArticles+")", args...)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	id, err := result.LastInsertId()
+	result, err := tx.ExecContext(ctx, "DELETE FROM articles WHERE id IN ("+prunableArticles+")", args...)
 	if err != nil {
-		return f, err
+		return 0, err
 	}
 
-	f.ID = fmt.Sprintf("%d", id)
-	return f, nil
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), tx.Commit()
 }
 
-func (s *SQLite) getFeedByLink(ctx context.Context, link string) (Feed, error) {
-	query := "SELECT * FROM feeds WHERE siteLink = ?"
-	stmt, err := s.db.PrepareContext(ctx, query)
+func (s *SQLite) getArticleByID(ctx context.Context, id string) (*Article, error) {
+	query := fmt.Sprintf("SELECT %s FROM articles WHERE id = ?", articleColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
 
-	var f Feed
+	a, err := scanArticle(stmt.QueryRowContext(ctx, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		return f, err
+		return nil, err
 	}
 
-	row := stmt.QueryRow(link)
-	if row.Err() != nil {
-		return f, row.Err()
+	err = s.loadTags(ctx, []*Article{a})
+	if err != nil {
+		return nil, err
 	}
 
-	err = row.Scan(&f.ID, &f.Title, &f.SiteLink, &f.RSSLink, &f.Description, &f.Timestamp)
-	return f, err
+	return a, nil
 }
 
-func (s *SQLite) ListFeeds(ctx context.Context, opts *Options) (FeedList, error) {
+// BackfillArticle fills in fields of a stored article from a later parse of its feed, such as after the parser learns to read a field it used to miss.
+// Each non-empty value replaces the stored one, while empty values leave the stored value alone. Tags are added to the article's tags rather than replacing them.
+// Its read, favorited, and saved state are kept. Reports whether anything changed, or returns ErrNotFound when there is no article with the id.
+func (s *SQLite) BackfillArticle(ctx context.Context, id, guid, author, content string, enclosure *Enclosure, tags []string) (bool, error) {
 	if s.db == nil {
-		return FeedList{}, ErrNilDB
+		return false, ErrNilDB
 	}
 
-	if opts == nil {
-		opts = DefaultOptions()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	article, err := s.getArticleByID(ctx, id)
+	if err != nil {
+		return false, err
 	}
 
-	limit := opts.Limit + 1
+	changed := false
+	for _, field := range []struct {
+		stored *string
+		value  string
+	}{
+		{&article.GUID, guid},
+		{&article.Author, author},
+		{&article.Content, content},
+	} {
+		if field.value != "" && field.value != *field.stored {
+			*field.stored = field.value
+			changed = true
+		}
+	}
 
-	nextQuery := fmt.Sprintf("SELECT * FROM feeds WHERE id < ? ORDER BY id %s LIMIT %d", Descending.string(), limit)
-	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT * FROM feeds WHERE id > ? ORDER BY id %s LIMIT %d ) AS data ORDER BY id %s", Ascending.string(), limit, Descending.string())
+	if enclosure != nil && enclosure.URL != "" && (article.Enclosure == nil || *article.Enclosure != *enclosure) {
+		article.Enclosure = enclosure
+		changed = true
+	}
 
-	return s.doFeedQueries(ctx, nextQuery, prevQuery, opts.Cursor, opts.Limit)
-}
+	// tags match without regard to case, like the article_tags table
+	stored := make(map[string]bool, len(article.Tags))
+	for _, tag := range article.Tags {
+		stored[strings.ToLower(tag)] = true
+	}
 
-func (s *SQLite) doFeedQueries(ctx context.Context, nextQuery, prevQuery, cursor string, limit int) (FeedList, error) {
-	feedList := FeedList{
-		Feeds: make([]*Feed, 0),
+	newTags := make([]string, 0)
+	for _, tag := range normalizeTags(tags) {
+		if !stored[strings.ToLower(tag)] {
+			newTags = append(newTags, tag)
+		}
+	}
+
+	if !changed && len(newTags) == 0 {
+		return false, nil
 	}
 
-	nextStmt, err := s.db.PrepareContext(ctx, nextQuery)
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return feedList, err
+		return false, err
 	}
+	defer tx.Rollback()
 
-	nextPagination := cursor
-	if nextPagination == "" {
-		nextPagination = maxFeedID
+	var media Enclosure
+	if article.Enclosure != nil {
+		media = *article.Enclosure
 	}
-	next, err := nextStmt.QueryContext(ctx, nextPagination)
+
+	_, err = tx.ExecContext(ctx, "UPDATE articles SET guid = ?, author = ?, content = ?, enclosure_url = ?, enclosure_type = ?, enclosure_length = ? WHERE id = ?", article.GUID, article.Author, article.Content, media.URL, media.Type, media.Length, id)
 	if err != nil {
-		return feedList, err
+		return false, err
 	}
 
-	nextFeeds := make([]*Feed, 0)
-	for next.Next() {
-		var f Feed
-		err = next.Scan(&f.ID, &f.Title, &f.RSSLink, &f.SiteLink, &f.Description, &f.Timestamp)
+	for _, tag := range newTags {
+		_, err = tx.ExecContext(ctx, "INSERT INTO article_tags (article_id, tag) VALUES (?, ?)", id, tag)
 		if err != nil {
-			return feedList, err
+			return false, err
 		}
+	}
+
+	return true, tx.Commit()
+}
 
-		nextFeeds = append(nextFeeds, &f)
+// GetArticleFullText returns the full text cached for the article, which is empty when it has not been fetched, or ErrNotFound when there is no article with the id
+func (s *SQLite) GetArticleFullText(ctx context.Context, id string) (string, error) {
+	if s.db == nil {
+		return "", ErrNilDB
 	}
 
-	prevStmt, err := s.db.PrepareContext(ctx, prevQuery)
-	if err != nil {
-		return feedList, err
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var fullText string
+	err := s.db.GetContext(ctx, &fullText, "SELECT full_text FROM articles WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+
+	return fullText, err
+}
+
+// SetArticleFullText caches the full text fetched from the article's link
+func (s *SQLite) SetArticleFullText(ctx context.Context, id, fullText string) error {
+	if s.db == nil {
+		return ErrNilDB
 	}
 
-	prev, err := prevStmt.QueryContext(ctx, cursor)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "UPDATE articles SET full_text = ? WHERE id = ?", fullText, id)
 	if err != nil {
-		return feedList, err
+		return err
 	}
 
-	prevFeeds := make([]*Feed, 0)
-	for prev.Next() {
-		var f Feed
-		err = next.Scan(&f.ID, &f.Title, &f.RSSLink, &f.SiteLink, &f.Description, &f.Timestamp)
-		if err != nil {
-			return feedList, err
-		}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-		prevFeeds = append(prevFeeds, &f)
+	if n == 0 {
+		return ErrNotFound
 	}
 
-	nextArticles, nextCursor := getPagination(nextFeeds, prevFeeds, limit, maxFeedID)
-	feedList.Feeds = nextArticles
-	feedList.Cursor = nextCursor
-	return feedList, nil
+	return nil
 }
 
-func (s *SQLite) CreateArticle(ctx context.Context, link, title, author, description string, published time.Time) (*Article, error) {
+// UpdateArticleContent replaces the article's title, description, and content, such as when a feed publishes a correction. Its read and favorited state are kept, and its cached full text is cleared so it is fetched again.
+func (s *SQLite) UpdateArticleContent(ctx context.Context, id, title, description, content string) error {
 	if s.db == nil {
-		return nil, ErrNilDB
+		return ErrNilDB
 	}
 
-	if link == "" {
-		return nil, errors.New("article link is empty")
-	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
 	if title == "" {
-		return nil, errors.New("article title is empty")
+		return errors.New("article title is empty")
 	}
 
-	if author == "" {
-		return nil, errors.New("article author is empty")
+	query := "UPDATE articles SET title = ?, description = ?, content = ?, full_text = '' WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return err
 	}
 
-	feedLink, err := parseSiteLinkFromURI(link)
+	result, err := stmt.ExecContext(ctx, title, description, content, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	feed, err := s.getFeedByLink(ctx, feedLink)
+	n, err := result.RowsAffected()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	query := "INSERT INTO articles (feed, link, title, author, description, published, read_date, read, favorited, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-	stmt, err := s.db.PrepareContext(ctx, query)
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLite) MarkArticleRead(ctx context.Context, id string, read bool) (*Article, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	article, err := s.getArticleByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
 
-	article := &Article{
-		Link:          link,
-		FeedID:        feed.ID,
-		Title:         title,
-		Description:   description,
-		Author:        author,
-		PublishedUnix: published.UTC().Unix(),
-		ReadDate:      "",
-		Favorited:     false,
-		Read:          false,
-		Timestamp:     s.Now().UTC().Unix(),
+	// only stamp the read date when the article transitions to read so re-marking keeps the original date
+	readDate := ""
+	if read {
+		readDate = article.ReadDate
+		if !article.Read {
+			readDate = s.Now().UTC().Format(time.RFC3339)
+		}
 	}
 
-	result, err := stmt.ExecContext(ctx, feed.ID, article.Link, article.Title, article.Author, article.Description, article.PublishedUnix, article.ReadDate, article.Read, article.Favorited, article.Timestamp)
+	query := "UPDATE articles SET read = ?, read_date = ? WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	id, err := result.LastInsertId()
+	_, err = stmt.ExecContext(ctx, read, readDate, id)
 	if err != nil {
 		return nil, err
 	}
 
-	article.ID = fmt.Sprintf("%d", id)
+	article.Read = read
+	article.ReadDate = readDate
 	return article, nil
 }
 
-func (s *SQLite) ListReadArticles(ctx context.Context, opts *Options) (ArticleList, error) {
-	var articleList ArticleList
-
+// NextUnreadArticle returns the first unread article after the given article in the sort order of opts, starting over from the top when no unread article comes after it.
+// ErrNotFound is returned when there is no other unread article.
+func (s *SQLite) NextUnreadArticle(ctx context.Context, after *Article, opts *Options) (*Article, error) {
 	if s.db == nil {
-		return articleList, ErrNilDB
+		return nil, ErrNilDB
 	}
 
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	if opts == nil {
 		opts = DefaultOptions()
 	}
 
-	limit := opts.Limit + 1
-
-	nextQuery := fmt.Sprintf("SELECT * FROM articles WHERE read = true AND published < ? ORDER BY published %s LIMIT %d", Descending.string(), limit)
+	column := opts.SortBy.column()
+	orderBy := fmt.Sprintf("%s %s, id %s", column, opts.Order.string(), opts.Order.string())
 
-	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT * FROM articles WHERE read = true AND published > ? ORDER BY published %s LIMIT %d ) AS data ORDER BY published %s", Ascending.string(), limit, Descending.string())
+	query := fmt.Sprintf("SELECT %s FROM articles WHERE read = false AND (%s, id) %s (?, ?) ORDER BY %s LIMIT 1", articleColumns, column, opts.Order.comparison(), orderBy)
+	a, err := s.firstArticle(ctx, query, opts.SortBy.field(after), after.ID)
+	if errors.Is(err, ErrNotFound) {
+		query = fmt.Sprintf("SELECT %s FROM articles WHERE read = false AND id != ? ORDER BY %s LIMIT 1", articleColumns, orderBy)
+		a, err = s.firstArticle(ctx, query, after.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	articleList, err := s.doArticleQueries(ctx, nextQuery, prevQuery, opts.Cursor, opts.Limit)
-	return articleList, err
+	return a, nil
 }
 
-func (s *SQLite) ListUnreadArticles(ctx context.Context, opts *Options) (ArticleList, error) {
-	var articleList ArticleList
-
+// FindDuplicateArticle returns an article stored under a feed other than feedID with the same canonical link as the link, or the same guid when the guid is not empty.
+// Guids are only unique within a feed, many feeds number their articles from 1, so a guid that is not an absolute url only matches the articles of feeds on the same site as feedID.
+// ErrNotFound is returned when no other feed has the article.
+func (s *SQLite) FindDuplicateArticle(ctx context.Context, feedID, link, guid string) (*Article, error) {
 	if s.db == nil {
-		return articleList, ErrNilDB
+		return nil, ErrNilDB
 	}
 
-	if opts == nil {
-		opts = DefaultOptions()
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := links.Normalize(guid)
+	absolute := err == nil
+
+	query := fmt.Sprintf(`SELECT %s FROM articles WHERE feed != ? AND (
+		canonical_link = ?
+		OR (? != '' AND guid = ? AND (? OR feed IN (SELECT id FROM feeds WHERE site != '' AND site = (SELECT site FROM feeds WHERE id = ?))))
+	) ORDER BY id LIMIT 1`, articleColumns)
+	return s.firstArticle(ctx, query, feedID, links.Canonical(link), guid, guid, absolute, feedID)
+}
+
+// AddArticleFeed records that the feed carries the article too, though the article is stored under another feed. Recording it again does nothing.
+// ErrNotFound is returned when the article or the feed does not exist.
+func (s *SQLite) AddArticleFeed(ctx context.Context, articleID, feedID string) error {
+	if s.db == nil {
+		return ErrNilDB
 	}
 
-	limit := opts.Limit + 1
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	nextQuery := fmt.Sprintf("SELECT * FROM articles WHERE read = false AND published < ? ORDER BY published %s LIMIT %d", Descending.string(), limit)
+	stmt, err := s.prepare(ctx, "INSERT OR IGNORE INTO article_feeds (article_id, feed_id) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
 
-	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT * FROM articles WHERE read = false AND published > ? ORDER BY published %s LIMIT %d ) AS data ORDER BY published %s", Ascending.string(), limit, Descending.string())
+	_, err = stmt.ExecContext(ctx, articleID, feedID)
+	if isForeignKeyConstraintError(err) {
+		return ErrNotFound
+	}
 
-	articleList, err := s.doArticleQueries(ctx, nextQuery, prevQuery, opts.Cursor, opts.Limit)
-	return articleList, err
+	return err
 }
 
-func (s *SQLite) ListFavoritedArticles(ctx context.Context, opts *Options) (ArticleList, error) {
-	var articleList ArticleList
-
+// ListArticleFeeds returns the feeds carrying the article, the feed it is stored under first followed by those recorded with AddArticleFeed.
+// ErrNotFound is returned when the article does not exist.
+func (s *SQLite) ListArticleFeeds(ctx context.Context, articleID string) ([]*Feed, error) {
 	if s.db == nil {
-		return articleList, ErrNilDB
+		return nil, ErrNilDB
 	}
 
-	if opts == nil {
-		opts = DefaultOptions()
-	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	limit := opts.Limit + 1
+	query := fmt.Sprintf(`
+	SELECT %[1]s FROM feeds WHERE id = (SELECT feed FROM articles WHERE id = ?)
+	UNION ALL
+	SELECT * FROM (SELECT %[1]s FROM feeds WHERE id IN (SELECT feed_id FROM article_feeds WHERE article_id = ?) ORDER BY id)`, feedColumns)
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
 
-	nextQuery := fmt.Sprintf("SELECT * FROM articles WHERE favorited = true AND published < ? ORDER BY published %s LIMIT %d", Descending.string(), limit)
+	rows, err := stmt.QueryContext(ctx, articleID, articleID)
+	if err != nil {
+		return nil, err
+	}
 
-	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT * FROM articles WHERE favorited = true AND published > ? ORDER BY published %s LIMIT %d ) AS data ORDER BY published %s", Ascending.string(), limit, Descending.string())
+	feeds, err := scanRows(ctx, rows, scanFeed)
+	if err != nil {
+		return nil, err
+	}
+	if len(feeds) == 0 {
+		return nil, ErrNotFound
+	}
 
-	articleList, err := s.doArticleQueries(ctx, nextQuery, prevQuery, opts.Cursor, opts.Limit)
-	return articleList, err
+	return feeds, nil
 }
 
-func (s *SQLite) doArticleQueries(ctx context.Context, nextQuery, prevQuery, cursor string, limit int) (ArticleList, error) {
-	articleList := ArticleList{
-		Articles: make([]*Article, 0),
+// firstArticle returns the first article selected by the query with its tags, or ErrNotFound when it selects none
+func (s *SQLite) firstArticle(ctx context.Context, query string, args ...any) (*Article, error) {
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 
-	nextStmt, err := s.db.PrepareContext(ctx, nextQuery)
+	a, err := scanArticle(stmt.QueryRowContext(ctx, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		return articleList, err
+		return nil, err
 	}
 
-	nextPagination := cursor
-	if nextPagination == "" {
-		nextPagination = maxPublishedDate
-	}
-	next, err := nextStmt.QueryContext(ctx, nextPagination)
+	err = s.loadTags(ctx, []*Article{a})
 	if err != nil {
-		return articleList, err
+		return nil, err
 	}
 
-	nextArticles := make([]*Article, 0)
-	for next.Next() {
-		var a Article
-		err = next.Scan(&a.ID, &a.FeedID, &a.Title, &a.Author, &a.Description, &a.Link, &a.PublishedUnix, &a.Read, &a.ReadDate, &a.Favorited, &a.Timestamp)
-		if err != nil {
-			return articleList, err
-		}
-		a.Published = time.Unix(a.PublishedUnix, 0).UTC().Format("Mon, 02 Jan 2006")
-		nextArticles = append(nextArticles, &a)
+	return a, nil
+}
+
+// MarkAllRead marks every unread article as read, only the feed's articles when feedID is not empty, and returns how many were marked.
+// Articles that were already read keep their original read date.
+func (s *SQLite) MarkAllRead(ctx context.Context, feedID string) (int, error) {
+	if s.db == nil {
+		return 0, ErrNilDB
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE articles SET read = true, read_date = ? WHERE read = false"
+	args := []any{s.Now().UTC().Format(time.RFC3339)}
+	if feedID != "" {
+		query += " AND feed = ?"
+		args = append(args, feedID)
 	}
 
-	prevStmt, err := s.db.PrepareContext(ctx, prevQuery)
+	stmt, err := s.prepare(ctx, query)
 	if err != nil {
-		return articleList, err
+		return 0, err
 	}
 
-	prev, err := prevStmt.QueryContext(ctx, cursor)
+	result, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
-		return articleList, err
+		return 0, err
 	}
 
-	prevArticles := make([]*Article, 0)
-	for prev.Next() {
-		var a Article
-		err = prev.Scan(&a.ID, &a.FeedID, &a.Title, &a.Author, &a.Description, &a.Link, &a.PublishedUnix, &a.Read, &a.ReadDate, &a.Favorited, &a.Timestamp)
-		if err != nil {
-			return articleList, err
-		}
-		a.Published = time.Unix(a.PublishedUnix, 0).UTC().Format("Mon, 02 Jan 2006")
-		prevArticles = append(prevArticles, &a)
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
 
-	nextArticles, nextCursor := getPagination(nextArticles, prevArticles, limit, maxPublishedDate)
-	articleList.Articles = nextArticles
-	articleList.Cursor = nextCursor
-	return articleList, nil
+	return int(n), nil
 }
 
-func (s *SQLite) ListArticles(ctx context.Context, opts *Options) (ArticleList, error) {
-	articleList := ArticleList{
-		Articles: make([]*Article, 0),
+func (s *SQLite) SetArticleFavorited(ctx context.Context, id string, favorited bool) (*Article, error) {
+	if s.db == nil {
+		return nil, ErrNilDB
 	}
 
-	if s.db == nil {
-		return articleList, ErrNilDB
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	article, err := s.getArticleByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	if opts == nil {
-		opts = DefaultOptions()
+	query := "UPDATE articles SET favorited = ? WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 
-	limit := opts.Limit + 1
+	_, err = stmt.ExecContext(ctx, favorited, id)
+	if err != nil {
+		return nil, err
+	}
 
-	nextQuery := fmt.Sprintf("SELECT * FROM articles WHERE read = false AND published < ? ORDER BY published %s LIMIT %d", Descending.string(), limit)
-	prevQuery := fmt.Sprintf("SELECT * FROM ( SELECT * FROM articles WHERE read = false AND published > ? ORDER BY published %s LIMIT %d ) AS data ORDER BY published %s", Ascending.string(), limit, Descending.string())
-	return s.doArticleQueries(ctx, nextQuery, prevQuery, opts.Cursor, opts.Limit)
+	article.Favorited = favorited
+	return article, nil
 }
 
-func (s *SQLite) ListArticlesByFeed(ctx context.Context, feedID string) ([]*Article, error) {
+// SetArticleSaved adds the article to or removes it from the read later list
+func (s *SQLite) SetArticleSaved(ctx context.Context, id string, saved bool) (*Article, error) {
 	if s.db == nil {
 		return nil, ErrNilDB
 	}
 
-	query := "SELECT * FROM articles WHERE feed = ?"
-	stmt, err := s.db.PrepareContext(ctx, query)
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	article, err := s.getArticleByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := stmt.QueryContext(ctx, feedID)
+	query := "UPDATE articles SET saved = ? WHERE id = ?"
+	stmt, err := s.prepare(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	articles := make([]*Article, 0)
-	for rows.Next() {
-		var a Article
-		err = rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.Author, &a.Description, &a.Link, &a.Published, &a.Read, &a.ReadDate, &a.Favorited, &a.Timestamp)
-		if err != nil {
-			return nil, err
-		}
-		articles = append(articles, &a)
+	_, err = stmt.ExecContext(ctx, saved, id)
+	if err != nil {
+		return nil, err
 	}
 
-	return articles, nil
+	article.Saved = saved
+	return article, nil
 }
@@ -0,0 +1,2129 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kdwils/feedreader/pkg/links"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSQLite(t testing.TB) Storage {
+	t.Helper()
+
+	store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+	})
+
+	return store
+}
+
+func TestSQLite_Pragmas(t *testing.T) {
+	type pragmas struct {
+		journalMode string
+		synchronous int
+		busyTimeout int
+		foreignKeys int
+	}
+
+	tests := []struct {
+		name string
+		opts []SQLiteOption
+		want pragmas
+	}{
+		{
+			name: "defaults",
+			want: pragmas{journalMode: "wal", synchronous: 1, busyTimeout: 5000, foreignKeys: 1},
+		},
+		{
+			name: "configured",
+			opts: []SQLiteOption{WithPragmas(Pragmas{JournalMode: "DELETE", Synchronous: "FULL", BusyTimeout: time.Second})},
+			want: pragmas{journalMode: "delete", synchronous: 2, busyTimeout: 1000, foreignKeys: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the pool needs room for the two connections checked at once
+			opts := append([]SQLiteOption{WithPool(Pool{MaxOpenConns: 2, MaxIdleConns: 2})}, tt.opts...)
+			store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"), opts...)
+			err := store.Connect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			db := store.(*SQLite).db
+			ctx := context.Background()
+
+			// pragmas are per connection, so every connection in the pool must have them
+			for i := 0; i < 2; i++ {
+				conn, err := db.Connx(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+
+				var got pragmas
+				for pragma, dest := range map[string]any{
+					"journal_mode": &got.journalMode,
+					"synchronous":  &got.synchronous,
+					"busy_timeout": &got.busyTimeout,
+					"foreign_keys": &got.foreignKeys,
+				} {
+					err = conn.GetContext(ctx, dest, "PRAGMA "+pragma)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSQLite_CreateFeedListFeeds(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	created, err := store.CreateFeed(ctx, "blog.kyledev.co", "https://blog.kyledev.co/index.xml", "https://blog.kyledev.co/", "Recent content on blog.kyledev.co", "https://blog.kyledev.co/logo.png", "en-us", "© 2023 kyle")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "https://blog.kyledev.co/index.xml", created.RSSLink)
+	assert.Equal(t, "https://blog.kyledev.co", created.SiteLink)
+	assert.Equal(t, "https://blog.kyledev.co/logo.png", created.Image)
+	assert.Equal(t, "en-us", created.Language)
+	assert.Equal(t, "© 2023 kyle", created.Copyright)
+
+	feedList, err := store.ListFeeds(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, feedList.Feeds, 1) {
+		assert.Equal(t, created, feedList.Feeds[0])
+	}
+
+	feed, err := store.(*SQLite).getFeedBySite(ctx, created.SiteLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, created, feed)
+}
+
+func TestSQLite_ConnectAddsMissingArticleColumns(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.sqlite")
+
+	db, err := sqlx.Open("sqlite3", filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE feeds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		rssLink TEXT NOT NULL UNIQUE,
+		siteLink TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL,
+		timestamp INT NOT NULL
+	);
+
+	CREATE TABLE articles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		feed INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		author TEXT NOT NULL,
+		description TEXT NOT NULL,
+		link TEXT NOT NULL UNIQUE,
+		published TEXT NOT NULL,
+		read BOOLEAN NOT NULL,
+		read_date TEXT NOT NULL,
+		favorited BOOLEAN NOT NULL,
+		timestamp INT NOT NULL,
+		FOREIGN KEY(feed) REFERENCES feeds(id)
+	);
+
+	INSERT INTO feeds (title, rssLink, siteLink, description, timestamp) VALUES ('example', 'https://example.com/feed.xml', 'https://example.com', '', 0);
+	INSERT INTO articles (feed, title, author, description, link, published, read, read_date, favorited, timestamp) VALUES (1, 'old', 'author', '', 'https://example.com/old', 0, false, '', false, 0);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	store := NewSQLiteStorage(filePath)
+	err = store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	articles, err := store.ListArticlesByFeed(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, articles, 1) {
+		assert.Equal(t, "https://example.com/old", articles[0].Link)
+		assert.Equal(t, "", articles[0].GUID)
+		assert.Equal(t, "", articles[0].Content)
+	}
+
+	created, err := store.CreateArticle(context.Background(), "", "https://example.com/new", "new-guid", "new", "author", "", "", nil, nil, time.Unix(100, 0), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "new-guid", created.GUID)
+}
+
+func seedArticles(t testing.TB, store Storage, count int) []*Article {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "https://example.com", "example feed", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	articles := make([]*Article, 0)
+	for i := 1; i <= count; i++ {
+		link := fmt.Sprintf("https://example.com/posts/%d", i)
+		a, err := store.CreateArticle(ctx, "", link, link, fmt.Sprintf("article %d", i), "author", "", "", nil, nil, time.Date(2023, 1, i, 0, 0, 0, 0, time.UTC), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		articles = append(articles, a)
+	}
+
+	return articles
+}
+
+func pageTitles(t *testing.T, list func(context.Context, *Options) (ArticleList, error), opts *Options) []string {
+	t.Helper()
+
+	titles := make([]string, 0)
+	for {
+		articleList, err := list(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, a := range articleList.Articles {
+			titles = append(titles, a.Title)
+		}
+
+		if !articleList.HasNext {
+			return titles
+		}
+		opts.Cursor = articleList.Next
+	}
+}
+
+func TestSQLite_ListArticlesOrder(t *testing.T) {
+	store := newTestSQLite(t)
+	seedArticles(t, store, 5)
+
+	t.Run("descending", func(t *testing.T) {
+		titles := pageTitles(t, store.ListArticles, &Options{Limit: 2, Order: Descending})
+		assert.Equal(t, []string{"article 5", "article 4", "article 3", "article 2", "article 1"}, titles)
+	})
+
+	t.Run("ascending", func(t *testing.T) {
+		titles := pageTitles(t, store.ListArticles, &Options{Limit: 2, Order: Ascending})
+		assert.Equal(t, []string{"article 1", "article 2", "article 3", "article 4", "article 5"}, titles)
+	})
+
+	t.Run("previous pages", func(t *testing.T) {
+		ctx := context.Background()
+		titles := func(list ArticleList) []string {
+			s := make([]string, 0)
+			for _, a := range list.Articles {
+				s = append(s, a.Title)
+			}
+			return s
+		}
+
+		first, err := store.ListArticles(ctx, &Options{Limit: 2, Order: Descending})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, first.HasPrev)
+
+		second, err := store.ListArticles(ctx, &Options{Limit: 2, Order: Descending, Cursor: first.Next})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		third, err := store.ListArticles(ctx, &Options{Limit: 2, Order: Descending, Cursor: second.Next})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"article 1"}, titles(third))
+		assert.False(t, third.HasNext)
+		assert.True(t, third.HasPrev)
+
+		back, err := store.ListArticles(ctx, &Options{Limit: 2, Order: Descending, Cursor: third.Prev})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, titles(second), titles(back))
+
+		back, err = store.ListArticles(ctx, &Options{Limit: 2, Order: Descending, Cursor: back.Prev})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, titles(first), titles(back))
+	})
+}
+
+func TestSQLite_ListArticlesSortBy(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	_, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each field orders the articles differently: published c, a, e, b, d then added b, d, a, e, c then title a, B, c, D, e
+	articles := []struct {
+		title     string
+		published time.Time
+		added     int64
+	}{
+		{title: "a", published: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), added: 300},
+		{title: "B", published: time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC), added: 100},
+		{title: "c", published: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), added: 500},
+		{title: "D", published: time.Date(2023, 1, 5, 0, 0, 0, 0, time.UTC), added: 200},
+		{title: "e", published: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), added: 400},
+	}
+	for _, a := range articles {
+		link := "https://example.com/posts/" + a.title
+		created, err := store.CreateArticle(ctx, "", link, "", a.title, "author", "", "", nil, nil, a.published, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = store.(*SQLite).db.ExecContext(ctx, "UPDATE articles SET timestamp = ? WHERE id = ?", a.added, created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sortBy SortBy
+		order  order
+		want   []string
+	}{
+		{sortBy: SortPublished, order: Ascending, want: []string{"c", "a", "e", "B", "D"}},
+		{sortBy: SortPublished, order: Descending, want: []string{"D", "B", "e", "a", "c"}},
+		{sortBy: SortAdded, order: Ascending, want: []string{"B", "D", "a", "e", "c"}},
+		{sortBy: SortAdded, order: Descending, want: []string{"c", "e", "a", "D", "B"}},
+		{sortBy: SortTitle, order: Ascending, want: []string{"a", "B", "c", "D", "e"}},
+		{sortBy: SortTitle, order: Descending, want: []string{"e", "D", "c", "B", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.sortBy, tt.order), func(t *testing.T) {
+			titles := pageTitles(t, store.ListArticles, &Options{Limit: 2, Order: tt.order, SortBy: tt.sortBy})
+			assert.Equal(t, tt.want, titles)
+		})
+	}
+}
+
+func TestSQLite_ListArticlesEqualPublished(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	_, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// feeds often publish every item at midnight, so most of these share a published date across the page boundaries
+	midnight := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	published := []time.Time{midnight.AddDate(0, 0, -1), midnight, midnight, midnight, midnight, midnight.AddDate(0, 0, 1)}
+	for i, p := range published {
+		link := fmt.Sprintf("https://example.com/posts/%d", i+1)
+		_, err := store.CreateArticle(ctx, "", link, "", fmt.Sprintf("article %d", i+1), "author", "", "", nil, nil, p, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("descending", func(t *testing.T) {
+		titles := pageTitles(t, store.ListArticles, &Options{Limit: 2, Order: Descending})
+		assert.Equal(t, []string{"article 6", "article 5", "article 4", "article 3", "article 2", "article 1"}, titles)
+	})
+
+	t.Run("ascending", func(t *testing.T) {
+		titles := pageTitles(t, store.ListArticles, &Options{Limit: 2, Order: Ascending})
+		assert.Equal(t, []string{"article 1", "article 2", "article 3", "article 4", "article 5", "article 6"}, titles)
+	})
+
+	t.Run("cursor holds the published date and id", func(t *testing.T) {
+		page, err := store.ListArticles(ctx, &Options{Limit: 2, Order: Descending})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, fmt.Sprintf("%d~5", midnight.Unix()), page.Next)
+	})
+}
+
+func TestSQLite_SearchArticles(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 3)
+
+	_, err := store.CreateArticle(ctx, "", "https://example.com/posts/percent", "", "100% coverage", "author", "", "", nil, nil, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "blank query",
+			query: "  ",
+			want:  []string{},
+		},
+		{
+			name:  "matches title",
+			query: "article 2",
+			want:  []string{"article 2"},
+		},
+		{
+			name:  "matches author",
+			query: "AUTHOR",
+			want:  []string{"100% coverage", "article 3", "article 2", "article 1"},
+		},
+		{
+			name:  "escapes wildcards",
+			query: "0%",
+			want:  []string{"100% coverage"},
+		},
+		{
+			name:  "underscore is literal",
+			query: "article_",
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			articleList, err := store.SearchArticles(ctx, tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			titles := make([]string, 0)
+			for _, a := range articleList.Articles {
+				titles = append(titles, a.Title)
+			}
+
+			assert.Equal(t, tt.want, titles)
+		})
+	}
+}
+
+func TestSQLite_ListFeedArticles(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 3)
+
+	other, err := store.CreateFeed(ctx, "other", "https://other.com/feed.xml", "https://other.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateArticle(ctx, "", "https://other.com/posts/1", "", "other article", "author", "", "", nil, nil, time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	titles := pageTitles(t, func(ctx context.Context, opts *Options) (ArticleList, error) {
+		return store.ListFeedArticles(ctx, "1", opts)
+	}, &Options{Limit: 2, Order: Descending})
+	assert.Equal(t, []string{"article 3", "article 2", "article 1"}, titles)
+
+	articleList, err := store.ListFeedArticles(ctx, other.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, articleList.Articles, 1) {
+		assert.Equal(t, "other article", articleList.Articles[0].Title)
+		assert.Equal(t, "Tue, 10 Jan 2023", articleList.Articles[0].Published)
+	}
+}
+
+func TestSQLite_CreateArticleEnclosure(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 1)
+
+	enclosure := &Enclosure{URL: "https://example.com/episodes/1.mp3", Type: "audio/mpeg", Length: 12345678}
+	created, err := store.CreateArticle(ctx, "", "https://example.com/episodes/1", "", "episode 1", "author", "", "", enclosure, nil, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, enclosure, created.Enclosure)
+
+	articles, err := store.ListArticlesByFeed(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, articles, 2) {
+		for _, a := range articles {
+			if a.ID == created.ID {
+				assert.Equal(t, enclosure, a.Enclosure)
+				continue
+			}
+
+			assert.Nil(t, a.Enclosure)
+		}
+	}
+}
+
+func TestSQLite_ListTaggedArticles(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 1)
+
+	created, err := store.CreateArticle(ctx, "", "https://example.com/posts/tagged", "", "tagged", "author", "", "", nil, []string{" golang ", "Homelab", "GoLang", ""}, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"golang", "Homelab"}, created.Tags)
+
+	for _, tag := range []string{"golang", "HOMELAB"} {
+		articleList, err := store.ListTaggedArticles(ctx, tag, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if assert.Len(t, articleList.Articles, 1, tag) {
+			assert.Equal(t, created.ID, articleList.Articles[0].ID)
+			assert.Equal(t, []string{"golang", "Homelab"}, articleList.Articles[0].Tags)
+		}
+	}
+
+	articleList, err := store.ListTaggedArticles(ctx, "rust", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, articleList.Articles)
+
+	articleList, err = store.ListArticles(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, articleList.Articles, 2) {
+		assert.Equal(t, []string{}, articleList.Articles[1].Tags)
+	}
+}
+
+func TestSQLite_CreateFeedDuplicate(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	created, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	assert.ErrorIs(t, err, ErrDuplicateFeed)
+	assert.Equal(t, created, existing)
+
+	// a site's category feed is another feed of the same site
+	category, err := store.CreateFeed(ctx, "category", "https://example.com/category/go.xml", "https://example.com", "", "", "", "")
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, created.ID, category.ID)
+		assert.Equal(t, created.SiteLink, category.SiteLink)
+	}
+}
+
+func TestSQLite_CreateArticleFeed(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	mainFeed, err := store.CreateFeed(ctx, "main", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	category, err := store.CreateFeed(ctx, "category", "https://example.com/category/go.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := store.CreateArticle(ctx, category.ID, "https://example.com/posts/1", "", "from the category", "author", "", "", nil, nil, time.Now(), "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, category.ID, a.FeedID, "the article belongs to the feed it was given")
+	}
+
+	// without a feed the article goes to the site's first feed, www or not
+	a, err = store.CreateArticle(ctx, "", "https://www.example.com/posts/2", "", "without a feed", "author", "", "", nil, nil, time.Now(), "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, mainFeed.ID, a.FeedID)
+	}
+}
+
+func TestSQLite_UpdateFeedTitle(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	feed, err := store.CreateFeed(ctx, "channel title", "https://example.com/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, feed.CustomTitle)
+
+	listedTitle := func() string {
+		feedList, err := store.ListFeeds(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !assert.Len(t, feedList.Feeds, 1) {
+			return ""
+		}
+		return feedList.Feeds[0].Title
+	}
+
+	err = store.UpdateFeedTitle(ctx, feed.ID, "my title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "my title", listedTitle(), "the custom title overrides the channel title")
+
+	got, err := store.GetFeed(ctx, feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "my title", got.Title)
+	assert.Equal(t, "my title", got.CustomTitle)
+
+	err = store.UpdateFeedTitle(ctx, feed.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "channel title", listedTitle(), "clearing the custom title falls back to the channel title")
+
+	err = store.UpdateFeedTitle(ctx, "404", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLite_CreateArticleDuplicate(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 1)
+
+	article, err := store.CreateArticle(ctx, "", "https://example.com/posts/1", "", "article 1", "author", "", "", nil, nil, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "")
+	assert.ErrorIs(t, err, ErrDuplicateArticle)
+	assert.Nil(t, article)
+}
+
+func TestSQLite_ListArticlesByStatus(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 4)
+
+	for _, a := range articles[:2] {
+		_, err := store.MarkArticleRead(ctx, a.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, a := range articles[1:3] {
+		_, err := store.SetArticleFavorited(ctx, a.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := store.SetArticleSaved(ctx, articles[3].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		status Status
+		want   []string
+	}{
+		{status: StatusAll, want: []string{"article 4", "article 3", "article 2", "article 1"}},
+		{status: StatusRead, want: []string{"article 2", "article 1"}},
+		{status: StatusUnread, want: []string{"article 4", "article 3"}},
+		{status: StatusFavorited, want: []string{"article 3", "article 2"}},
+		{status: StatusSaved, want: []string{"article 4"}},
+		{status: ParseStatus("bogus"), want: []string{"article 4", "article 3", "article 2", "article 1"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			list := func(ctx context.Context, opts *Options) (ArticleList, error) {
+				return store.ListArticlesByStatus(ctx, tt.status, opts)
+			}
+
+			assert.Equal(t, tt.want, pageTitles(t, list, &Options{Limit: 3, Order: Descending}))
+		})
+	}
+}
+
+func TestSQLite_SetArticleSaved(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 3)
+
+	for _, a := range articles[:2] {
+		saved, err := store.SetArticleSaved(ctx, a.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, saved.Saved)
+	}
+
+	_, err := store.SetArticleFavorited(ctx, articles[2].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"article 2", "article 1"}, pageTitles(t, store.ListSavedArticles, &Options{Limit: 1, Order: Descending}))
+
+	unsaved, err := store.SetArticleSaved(ctx, articles[0].ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, unsaved.Saved)
+
+	got, err := store.GetArticle(ctx, articles[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, got.Saved)
+	assert.Equal(t, []string{"article 2"}, pageTitles(t, store.ListSavedArticles, &Options{Limit: 1, Order: Descending}))
+
+	_, err = store.SetArticleSaved(ctx, "404", true)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLite_MarkAllRead(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 3)
+
+	other, err := store.CreateFeed(ctx, "other", "https://other.com/feed.xml", "https://other.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateArticle(ctx, "", "https://other.com/posts/1", "", "other article", "author", "", "", nil, nil, time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := store.MarkArticleRead(ctx, articles[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := store.MarkAllRead(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+
+	unread, err := store.ListUnreadArticles(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, unread.Articles, 1) {
+		assert.Equal(t, other.ID, unread.Articles[0].FeedID)
+	}
+
+	reread, err := store.(*SQLite).getArticleByID(ctx, read.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, read.ReadDate, reread.ReadDate)
+
+	n, err = store.MarkAllRead(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, n)
+}
+
+func TestSQLite_UpdateArticleContent(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 1)
+
+	_, err := store.SetArticleFavorited(ctx, articles[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.UpdateArticleContent(ctx, articles[0].ID, "edited", "new description", "new content")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetArticle(ctx, articles[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "edited", got.Title)
+	assert.Equal(t, "new description", got.Description)
+	assert.Equal(t, "new content", got.Content)
+	assert.True(t, got.Favorited)
+
+	assert.ErrorIs(t, store.UpdateArticleContent(ctx, "404", "edited", "", ""), ErrNotFound)
+}
+
+func TestSQLite_RecordFeedFetch(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	healthy, err := store.CreateFeed(ctx, "healthy", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing, err := store.CreateFeed(ctx, "failing", "https://broken.com/feed.xml", "https://broken.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(id string) *Feed {
+		t.Helper()
+		f, err := store.GetFeed(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	for _, id := range []string{healthy.ID, failing.ID} {
+		assert.NoError(t, store.RecordFeedFetch(ctx, id, ""))
+	}
+
+	f := get(failing.ID)
+	assert.NotZero(t, f.LastFetchTime)
+	assert.Equal(t, f.LastFetchTime, f.LastSuccessTime)
+	assert.Empty(t, f.LastError)
+	assert.Zero(t, f.ConsecutiveFailures)
+
+	failingFeeds, err := store.ListFailingFeeds(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, failingFeeds)
+
+	// failures count up and keep the time of the last success
+	lastSuccess := f.LastSuccessTime
+	assert.NoError(t, store.RecordFeedFetch(ctx, failing.ID, "connection refused"))
+	assert.NoError(t, store.RecordFeedFetch(ctx, failing.ID, "unexpected status code 503"))
+
+	f = get(failing.ID)
+	assert.Equal(t, "unexpected status code 503", f.LastError)
+	assert.Equal(t, 2, f.ConsecutiveFailures)
+	assert.Equal(t, lastSuccess, f.LastSuccessTime)
+
+	failingFeeds, err = store.ListFailingFeeds(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, failingFeeds, 1) {
+		assert.Equal(t, f, failingFeeds[0])
+	}
+
+	// a success clears the error
+	assert.NoError(t, store.RecordFeedFetch(ctx, failing.ID, ""))
+
+	f = get(failing.ID)
+	assert.Empty(t, f.LastError)
+	assert.Zero(t, f.ConsecutiveFailures)
+
+	failingFeeds, err = store.ListFailingFeeds(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, failingFeeds)
+
+	assert.ErrorIs(t, store.RecordFeedFetch(ctx, "404", ""), ErrNotFound)
+}
+
+func TestSQLite_Pool(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []SQLiteOption
+		want Pool
+	}{
+		{
+			name: "defaults",
+			want: Pool{MaxOpenConns: 1, MaxIdleConns: 1},
+		},
+		{
+			name: "configured",
+			opts: []SQLiteOption{WithPool(Pool{MaxOpenConns: 4, MaxIdleConns: 2, ConnMaxLifetime: time.Minute})},
+			want: Pool{MaxOpenConns: 4, MaxIdleConns: 2, ConnMaxLifetime: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"), tt.opts...)
+			err := store.Connect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			db := store.(*SQLite).db
+			ctx := context.Background()
+			assert.Equal(t, tt.want.MaxOpenConns, db.Stats().MaxOpenConnections)
+
+			// open as many connections as the pool allows, then release them to see how many are kept
+			var conns []*sqlx.Conn
+			for i := 0; i < tt.want.MaxOpenConns; i++ {
+				conn, err := db.Connx(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				conns = append(conns, conn)
+			}
+			for _, conn := range conns {
+				conn.Close()
+			}
+
+			stats := db.Stats()
+			assert.Equal(t, tt.want.MaxOpenConns, stats.OpenConnections+int(stats.MaxIdleClosed))
+			assert.Equal(t, tt.want.MaxIdleConns, stats.Idle)
+		})
+	}
+}
+
+func TestSQLite_PreparedStatements(t *testing.T) {
+	store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	articles := seedArticles(t, store, 5)
+	ctx := context.Background()
+	sqlite := store.(*SQLite)
+
+	cached := func() int {
+		sqlite.stmtsMu.Lock()
+		defer sqlite.stmtsMu.Unlock()
+		return len(sqlite.stmts)
+	}
+
+	run := func() {
+		_, err := store.ListFeeds(ctx, &Options{Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := store.ListUnreadArticles(ctx, &Options{Limit: 2, SortBy: SortPublished, Order: Descending})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.ListUnreadArticles(ctx, &Options{Limit: 2, SortBy: SortPublished, Order: Descending, Cursor: list.Cursor.Next})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.GetArticle(ctx, articles[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.MarkArticleRead(ctx, articles[1].ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run()
+	want := cached()
+	assert.NotZero(t, want)
+
+	// the same queries reuse their statements rather than preparing new ones
+	for i := 0; i < 500; i++ {
+		run()
+	}
+	assert.Equal(t, want, cached())
+	assert.Equal(t, 1, sqlite.db.Stats().OpenConnections)
+
+	assert.NoError(t, store.Close())
+	assert.Zero(t, cached())
+}
+
+var errAbandoned = errors.New("abandoned")
+
+func TestSQLite_ListQueriesReleaseConnections(t *testing.T) {
+	// a pool with room to spare, so rows left open hold on to connections instead of blocking the next query
+	store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"), WithPool(Pool{MaxOpenConns: 4, MaxIdleConns: 4}))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	articles := seedArticles(t, store, 5)
+	ctx := context.Background()
+	sqlite := store.(*SQLite)
+	feedID := articles[0].FeedID
+
+	lists := map[string]func() error{
+		"feeds": func() error {
+			_, err := store.ListFeeds(ctx, &Options{Limit: 2, Total: true})
+			return err
+		},
+		"unread articles": func() error {
+			list, err := store.ListUnreadArticles(ctx, &Options{Limit: 2, SortBy: SortPublished, Order: Descending, Total: true})
+			if err != nil {
+				return err
+			}
+			_, err = store.ListUnreadArticles(ctx, &Options{Limit: 2, SortBy: SortPublished, Order: Descending, Cursor: list.Cursor.Next})
+			return err
+		},
+		"search": func() error {
+			_, err := store.SearchArticles(ctx, "article", &Options{Limit: 2, SortBy: SortPublished, Order: Descending})
+			return err
+		},
+		"feed articles": func() error {
+			_, err := store.ListArticlesByFeed(ctx, feedID)
+			return err
+		},
+		"unread counts": func() error {
+			_, err := store.UnreadCounts(ctx)
+			return err
+		},
+		"failing feeds": func() error {
+			_, err := store.ListFailingFeeds(ctx)
+			return err
+		},
+		"folders": func() error {
+			_, err := store.ListFolders(ctx)
+			return err
+		},
+		// rows only close themselves once every row is read, so a scan that stops early must close them
+		"abandoned scan": func() error {
+			rows, err := sqlite.db.QueryContext(ctx, "SELECT id FROM articles")
+			if err != nil {
+				return err
+			}
+			_, err = scanRows(ctx, rows, func(scanner) (string, error) {
+				return "", errAbandoned
+			})
+			if !errors.Is(err, errAbandoned) {
+				return err
+			}
+			return nil
+		},
+	}
+	for name, list := range lists {
+		t.Run(name, func(t *testing.T) {
+			err := list()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sqlite.stmtsMu.Lock()
+			statements := len(sqlite.stmts)
+			sqlite.stmtsMu.Unlock()
+
+			for i := 0; i < 2000; i++ {
+				err := list()
+				if err != nil {
+					t.Fatal(err)
+				}
+				// checked every time, a leak would otherwise block once the pool runs out of connections
+				if inUse := sqlite.db.Stats().InUse; inUse != 0 {
+					t.Fatalf("%d connections held after the list returned", inUse)
+				}
+			}
+
+			sqlite.stmtsMu.Lock()
+			assert.Equal(t, statements, len(sqlite.stmts), "prepared statements")
+			sqlite.stmtsMu.Unlock()
+			assert.LessOrEqual(t, sqlite.db.Stats().OpenConnections, 1)
+		})
+	}
+}
+
+func TestSQLite_QueryTimeout(t *testing.T) {
+	store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"), WithQueryTimeout(time.Nanosecond))
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	_, err = store.ListFolders(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSQLite_NextUnreadArticle(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		// read are the articles, by title, read before the lookup
+		read []string
+		from string
+		want string
+	}{
+		{name: "newest first", from: "article 3", want: "article 2"},
+		{name: "oldest first", opts: &Options{Order: Ascending}, from: "article 3", want: "article 4"},
+		{name: "by title", opts: &Options{SortBy: SortTitle, Order: Ascending}, from: "article 2", want: "article 3"},
+		{name: "skips read articles", read: []string{"article 2", "article 1"}, from: "article 3", want: "article 5"},
+		{name: "starts over from the top", from: "article 1", want: "article 5"},
+		{name: "last unread", read: []string{"article 1", "article 2", "article 4", "article 5"}, from: "article 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestSQLite(t)
+			ctx := context.Background()
+
+			byTitle := make(map[string]*Article)
+			for _, a := range seedArticles(t, store, 5) {
+				byTitle[a.Title] = a
+			}
+			for _, title := range append(tt.read, tt.from) {
+				_, err := store.MarkArticleRead(ctx, byTitle[title].ID, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			next, err := store.NextUnreadArticle(ctx, byTitle[tt.from], tt.opts)
+			if tt.want == "" {
+				assert.ErrorIs(t, err, ErrNotFound)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, next.Title)
+			assert.False(t, next.Read)
+		})
+	}
+}
+
+func TestSQLite_BackfillArticle(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	_, err := store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := store.CreateArticle(ctx, "", "https://example.com/a", "", "a", "author", "", "<p>content</p>", nil, []string{"Go"}, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty values keep what is stored, and tags already on the article are not added again
+	changed, err := store.BackfillArticle(ctx, a.ID, "", "", "", nil, []string{"go"})
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	enclosure := &Enclosure{URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: 10}
+	changed, err = store.BackfillArticle(ctx, a.ID, "guid-a", "", "", enclosure, []string{"GO", "rss"})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	got, err := store.GetArticle(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "guid-a", got.GUID)
+	assert.Equal(t, "author", got.Author)
+	assert.Equal(t, "<p>content</p>", got.Content)
+	assert.Equal(t, enclosure, got.Enclosure)
+	assert.Equal(t, []string{"Go", "rss"}, got.Tags)
+
+	_, err = store.BackfillArticle(ctx, "404", "guid", "", "", nil, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLite_ArticleFullText(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 1)
+
+	got, err := store.GetArticleFullText(ctx, articles[0].ID)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	err = store.SetArticleFullText(ctx, articles[0].ID, "<p>full text</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = store.GetArticleFullText(ctx, articles[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>full text</p>", got)
+
+	// an edited article is fetched again
+	err = store.UpdateArticleContent(ctx, articles[0].ID, "edited", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = store.GetArticleFullText(ctx, articles[0].ID)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = store.GetArticleFullText(ctx, "404")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.SetArticleFullText(ctx, "404", "<p>full text</p>"), ErrNotFound)
+}
+
+func TestSQLite_DeleteArticle(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 2)
+	deleted := articles[0]
+
+	err := store.DeleteArticle(ctx, deleted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.GetArticle(ctx, deleted.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.DeleteArticle(ctx, deleted.ID), ErrNotFound)
+
+	_, err = store.CreateArticle(ctx, "", deleted.Link, "", "recreated", "author", "", "", nil, nil, time.Now(), "")
+	assert.ErrorIs(t, err, ErrArticleDeleted, "the tombstone keeps the link from being stored again")
+
+	_, err = store.CreateArticle(ctx, "", "https://example.com/posts/moved", deleted.GUID, "recreated", "author", "", "", nil, nil, time.Now(), "")
+	assert.ErrorIs(t, err, ErrArticleDeleted, "the tombstone keeps the guid from being stored again")
+
+	remaining, err := store.ListArticlesByFeed(ctx, deleted.FeedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, remaining, 1) {
+		assert.Equal(t, articles[1].ID, remaining[0].ID)
+	}
+
+	err = store.DeleteFeed(ctx, deleted.FeedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateFeed(ctx, "example", "https://example.com/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateArticle(ctx, "", deleted.Link, deleted.GUID, "resubscribed", "author", "", "", nil, nil, time.Now(), "")
+	assert.NoError(t, err, "deleting the feed removes its tombstones")
+}
+
+func TestSQLite_PruneArticles(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 5)
+
+	for _, a := range articles[:4] {
+		_, err := store.MarkArticleRead(ctx, a.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := store.SetArticleFavorited(ctx, articles[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.SetArticleSaved(ctx, articles[2].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remainingIDs := func() []string {
+		t.Helper()
+		remaining, err := store.ListArticlesByFeed(ctx, articles[0].FeedID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]string, 0, len(remaining))
+		for _, a := range remaining {
+			ids = append(ids, a.ID)
+		}
+		return ids
+	}
+
+	n, err := store.PruneArticles(ctx, Retention{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n, "nothing is pruned without a limit")
+
+	// the newest two are kept, of the rest the favorited and saved articles are kept too
+	n, err = store.PruneArticles(ctx, Retention{MaxPerFeed: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.ElementsMatch(t, []string{articles[0].ID, articles[2].ID, articles[3].ID, articles[4].ID}, remainingIDs())
+
+	_, err = store.CreateArticle(ctx, "", articles[1].Link, "", "recreated", "author", "", "", nil, nil, time.Now(), "")
+	assert.ErrorIs(t, err, ErrArticleDeleted, "pruned articles are not stored again by the next refresh")
+
+	recent, err := store.CreateArticle(ctx, "", "https://example.com/posts/recent", "", "recent", "author", "", "", nil, nil, time.Now().Add(-time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.MarkArticleRead(ctx, recent.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the old read article goes, the favorited, saved, and unread articles are kept however old they are
+	n, err = store.PruneArticles(ctx, Retention{MaxAge: 24 * time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.ElementsMatch(t, []string{articles[0].ID, articles[2].ID, articles[4].ID, recent.ID}, remainingIDs())
+}
+
+func TestSQLite_Folders(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	news, err := store.CreateFolder(ctx, " News ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "News", news.Name)
+
+	_, err = store.CreateFolder(ctx, "news")
+	assert.ErrorIs(t, err, ErrDuplicateFolder)
+
+	blogs, err := store.CreateFolder(ctx, "blogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folders, err := store.ListFolders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*Folder{blogs, news}, folders)
+
+	feeds := make([]*Feed, 0)
+	for _, link := range []string{"https://a.com/feed.xml", "https://b.com/feed.xml", "https://c.com/feed.xml"} {
+		f, err := store.CreateFeed(ctx, link, link, "", "", "", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		feeds = append(feeds, f)
+	}
+
+	for _, f := range feeds[:2] {
+		err = store.AssignFeedToFolder(ctx, f.ID, news.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.ErrorIs(t, store.AssignFeedToFolder(ctx, feeds[2].ID, "404"), ErrNotFound)
+	assert.ErrorIs(t, store.AssignFeedToFolder(ctx, "404", news.ID), ErrNotFound)
+
+	feedIDs := func(folderID string) []string {
+		t.Helper()
+
+		list, err := store.ListFeedsByFolder(ctx, folderID, &Options{Limit: 1, Order: Descending, Total: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := make([]string, 0)
+		for _, f := range list.Feeds {
+			assert.Equal(t, folderID, f.FolderID)
+			ids = append(ids, f.ID)
+		}
+		if list.HasNext {
+			next, err := store.ListFeedsByFolder(ctx, folderID, &Options{Limit: 10, Order: Descending, Cursor: list.Next})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range next.Feeds {
+				ids = append(ids, f.ID)
+			}
+		}
+
+		return ids
+	}
+
+	assert.Equal(t, []string{feeds[1].ID, feeds[0].ID}, feedIDs(news.ID))
+	assert.Equal(t, []string{feeds[2].ID}, feedIDs(""), "feeds without a folder are uncategorized")
+	assert.Empty(t, feedIDs(blogs.ID))
+
+	err = store.AssignFeedToFolder(ctx, feeds[0].ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{feeds[2].ID, feeds[0].ID}, feedIDs(""))
+
+	renamed, err := store.RenameFolder(ctx, news.ID, "world news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "world news", renamed.Name)
+
+	_, err = store.RenameFolder(ctx, blogs.ID, "World News")
+	assert.ErrorIs(t, err, ErrDuplicateFolder)
+
+	err = store.DeleteFolder(ctx, news.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorIs(t, store.DeleteFolder(ctx, news.ID), ErrNotFound)
+	assert.Len(t, feedIDs(""), 3, "deleting a folder leaves its feeds uncategorized")
+}
+
+func TestSQLite_GetFeedGetArticle(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 2)
+
+	feed, err := store.GetFeed(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://example.com/feed.xml", feed.RSSLink)
+
+	_, err = store.GetFeed(ctx, "100")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	article, err := store.GetArticle(ctx, articles[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "article 2", article.Title)
+	assert.Equal(t, "Mon, 02 Jan 2023", article.Published)
+	assert.Equal(t, []string{}, article.Tags)
+
+	_, err = store.GetArticle(ctx, "100")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSQLite_UnreadCounts(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 3)
+
+	_, err := store.CreateFeed(ctx, "other", "https://other.com/feed.xml", "https://other.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.MarkArticleRead(ctx, articles[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := store.UnreadCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]int{"1": 2, "2": 0}, counts)
+}
+
+func TestSQLite_ListArticlesBetween(t *testing.T) {
+	store := newTestSQLite(t)
+	seedArticles(t, store, 5)
+
+	// unix times before 2001 have fewer digits, so they only sort and compare correctly as numbers
+	_, err := store.CreateArticle(context.Background(), "", "https://example.com/posts/1999", "", "1999", "author", "", "", nil, nil, time.Date(1999, 6, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time {
+		return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	year := func(y int) time.Time {
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{name: "closed range", from: day(2), to: day(4), want: []string{"article 4", "article 3", "article 2"}},
+		{name: "only from", from: day(3), want: []string{"article 5", "article 4", "article 3"}},
+		{name: "only to", to: day(2), want: []string{"article 2", "article 1", "1999"}},
+		{name: "open range", want: []string{"article 5", "article 4", "article 3", "article 2", "article 1", "1999"}},
+		{name: "single day", from: day(3), to: day(3), want: []string{"article 3"}},
+		{name: "from before 2001", from: year(1999), want: []string{"article 5", "article 4", "article 3", "article 2", "article 1", "1999"}},
+		{name: "from after 1999", from: year(2000), want: []string{"article 5", "article 4", "article 3", "article 2", "article 1"}},
+		{name: "to before 2001", to: year(2000), want: []string{"1999"}},
+		{name: "to before every article", to: year(1999), want: []string{}},
+		{name: "closed range before 2001", from: year(1999), to: year(2000), want: []string{"1999"}},
+		{name: "closed range across 2001", from: year(1999), to: day(1), want: []string{"article 1", "1999"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := func(ctx context.Context, opts *Options) (ArticleList, error) {
+				return store.ListArticlesBetween(ctx, StatusAll, tt.from, tt.to, opts)
+			}
+
+			// a limit smaller than the range pages through it
+			titles := pageTitles(t, list, &Options{Limit: 2, Order: Descending})
+			assert.Equal(t, tt.want, titles)
+		})
+	}
+
+	t.Run("from after to", func(t *testing.T) {
+		_, err := store.ListArticlesBetween(context.Background(), StatusAll, day(4), day(2), DefaultOptions())
+		assert.ErrorIs(t, err, ErrInvalidRange)
+	})
+}
+
+func TestSQLite_ListFilteredArticles(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 5)
+
+	for _, site := range []string{"https://other.com", "https://third.com"} {
+		_, err := store.CreateFeed(ctx, site, site+"/feed.xml", site, "", "", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var other *Article
+	for i, site := range []string{"https://other.com", "https://other.com", "https://third.com"} {
+		var tags []string
+		if i > 0 {
+			tags = []string{"golang"}
+		}
+		a, err := store.CreateArticle(ctx, "", fmt.Sprintf("%s/posts/%d", site, i), "", fmt.Sprintf("%s %d", site, i), "author", "", "", nil, tags, time.Date(2023, 1, 2+2*i, 12, 0, 0, 0, time.UTC), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			other = a
+		}
+	}
+
+	_, err := store.MarkArticleRead(ctx, articles[2].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byFeeds := func(status Status, feedIDs ...string) func(context.Context, *Options) (ArticleList, error) {
+		return func(ctx context.Context, opts *Options) (ArticleList, error) {
+			return store.ListFilteredArticles(ctx, ArticleFilter{Status: status, FeedIDs: feedIDs}, opts)
+		}
+	}
+
+	// pages interleave the feeds' articles by date and leave out the third feed
+	titles := pageTitles(t, byFeeds(StatusAll, articles[0].FeedID, other.FeedID), &Options{Limit: 2, Order: Descending})
+	assert.Equal(t, []string{"article 5", "https://other.com 1", "article 4", "article 3", "https://other.com 0", "article 2", "article 1"}, titles)
+
+	titles = pageTitles(t, byFeeds(StatusUnread, articles[0].FeedID, " "+other.FeedID, other.FeedID), &Options{Limit: 3, Order: Ascending})
+	assert.Equal(t, []string{"article 1", "article 2", "https://other.com 0", "article 4", "https://other.com 1", "article 5"}, titles)
+
+	list, err := store.ListFilteredArticles(ctx, ArticleFilter{FeedIDs: []string{}}, DefaultOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, list.Articles, "an empty list of feeds matches no articles")
+
+	list, err = store.ListFilteredArticles(ctx, ArticleFilter{}, DefaultOptions())
+	assert.NoError(t, err)
+	assert.Len(t, list.Articles, 8, "the zero filter matches every article")
+
+	// every field of the filter narrows the list
+	filter := ArticleFilter{Status: StatusUnread, FeedIDs: []string{articles[0].FeedID, other.FeedID}, From: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Tag: "GoLang"}
+	list, err = store.ListFilteredArticles(ctx, filter, DefaultOptions())
+	assert.NoError(t, err)
+	if assert.Len(t, list.Articles, 1) {
+		assert.Equal(t, "https://other.com 1", list.Articles[0].Title)
+	}
+
+	filter.Tag = ""
+	filter.To = time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC)
+	titles = pageTitles(t, func(ctx context.Context, opts *Options) (ArticleList, error) {
+		return store.ListFilteredArticles(ctx, filter, opts)
+	}, &Options{Limit: 1, Order: Descending})
+	assert.Equal(t, []string{"article 4"}, titles, "article 3 is read")
+
+	_, err = store.ListFilteredArticles(ctx, ArticleFilter{From: filter.To, To: filter.From}, DefaultOptions())
+	assert.ErrorIs(t, err, ErrInvalidRange)
+
+	for _, ids := range [][]string{{articles[0].FeedID, ""}, {articles[0].FeedID, "2; DROP TABLE articles"}, {"1.5"}} {
+		_, err = store.ListFilteredArticles(ctx, ArticleFilter{FeedIDs: ids}, DefaultOptions())
+		assert.ErrorIs(t, err, ErrInvalidFeedID, ids)
+	}
+}
+
+func TestSQLite_CountArticlesSince(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 3)
+
+	head := func(sortBy SortBy, order order) string {
+		t.Helper()
+		list, err := store.ListArticlesByStatus(ctx, StatusAll, &Options{Limit: 2, SortBy: sortBy, Order: order})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list.Head
+	}
+	published, added, ascending := head(SortPublished, Descending), head(SortAdded, Descending), head(SortPublished, Ascending)
+
+	count := func(status Status, opts *Options) int {
+		t.Helper()
+		n, err := store.CountArticlesSince(ctx, ArticleFilter{Status: status}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	assert.Equal(t, 0, count(StatusAll, &Options{Cursor: published, Order: Descending}), "nothing has arrived yet")
+
+	latest, err := store.CreateArticle(ctx, "", "https://example.com/posts/latest", "", "latest", "author", "", "", nil, nil, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.CreateArticle(ctx, "", "https://example.com/posts/backdated", "", "backdated", "author", "", "", nil, nil, time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 1, count(StatusAll, &Options{Cursor: published, Order: Descending}), "the backdated article is not ahead of the newest published")
+	assert.Equal(t, 2, count(StatusAll, &Options{Cursor: added, SortBy: SortAdded, Order: Descending}), "both articles were added after the head")
+	assert.Equal(t, 1, count(StatusAll, &Options{Cursor: ascending, Order: Ascending}), "oldest first, only the backdated article comes ahead")
+
+	_, err = store.MarkArticleRead(ctx, latest.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, count(StatusUnread, &Options{Cursor: published, Order: Descending}), "the status filters the count")
+
+	_, err = store.CreateFeed(ctx, "other", "https://other.com/feed.xml", "https://other.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.CreateArticle(ctx, "", "https://other.com/posts/1", "", "other", "author", "", "", nil, nil, time.Date(2023, 2, 2, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &Options{Cursor: published, Order: Descending}
+	for _, tt := range []struct {
+		filter ArticleFilter
+		want   int
+	}{
+		{filter: ArticleFilter{}, want: 2},
+		{filter: ArticleFilter{FeedIDs: []string{latest.FeedID}}, want: 1},
+		{filter: ArticleFilter{FeedIDs: []string{other.FeedID}}, want: 1},
+		{filter: ArticleFilter{Status: StatusUnread, FeedIDs: []string{latest.FeedID}}, want: 0},
+		{filter: ArticleFilter{From: time.Date(2023, 2, 2, 0, 0, 0, 0, time.UTC)}, want: 1},
+	} {
+		n, err := store.CountArticlesSince(ctx, tt.filter, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, n, "%+v", tt.filter)
+	}
+
+	_, err = store.CountArticlesSince(ctx, ArticleFilter{FeedIDs: []string{"abc"}}, opts)
+	assert.ErrorIs(t, err, ErrInvalidFeedID)
+
+	_, err = store.CountArticlesSince(ctx, ArticleFilter{}, DefaultOptions())
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestSQLite_Stats(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Stats{}, stats)
+
+	articles := seedArticles(t, store, 3)
+	_, err = store.CreateFeed(ctx, "other", "https://other.com/feed.xml", "https://other.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.MarkArticleRead(ctx, articles[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.SetArticleFavorited(ctx, articles[1].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err = store.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, Stats{
+		Feeds:     2,
+		Articles:  3,
+		Unread:    2,
+		Favorited: 1,
+		LastAdded: articles[2].Timestamp,
+	}, stats)
+}
+
+func TestSQLite_ListMeta(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 3)
+
+	_, err := store.MarkArticleRead(ctx, articles[0].ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("total is only counted when asked for", func(t *testing.T) {
+		list, err := store.ListUnreadArticles(ctx, &Options{Limit: 1, Order: Descending})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, Meta{Limit: 1}, list.Meta)
+	})
+
+	t.Run("article total", func(t *testing.T) {
+		list, err := store.ListUnreadArticles(ctx, &Options{Limit: 1, Order: Descending, Total: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		total := 2
+		assert.Equal(t, Meta{Limit: 1, Total: &total}, list.Meta)
+		assert.Len(t, list.Articles, 1)
+	})
+
+	t.Run("feed total", func(t *testing.T) {
+		list, err := store.ListFeeds(ctx, &Options{Limit: 5, Order: Descending, Total: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		total := 1
+		assert.Equal(t, Meta{Limit: 5, Total: &total}, list.Meta)
+	})
+}
+
+func TestSQLite_Ping(t *testing.T) {
+	store := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.sqlite"))
+	assert.ErrorIs(t, store.Ping(context.Background()), ErrNilDB)
+
+	err := store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, store.Ping(context.Background()))
+
+	store.Close()
+	assert.Error(t, store.Ping(context.Background()))
+}
+
+// schema describes the columns of every table so the schemas of two databases can be compared regardless of how the tables were created
+func schema(t *testing.T, store Storage) map[string][]string {
+	t.Helper()
+	db := store.(*SQLite).db
+
+	var tables []string
+	err := db.Select(&tables, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := make(map[string][]string)
+	for _, table := range tables {
+		var info []string
+		err = db.Select(&info, fmt.Sprintf("SELECT name || ' ' || type || ' ' || \"notnull\" || ' ' || COALESCE(dflt_value, 'NULL') || ' ' || pk FROM pragma_table_info('%s')", table))
+		if err != nil {
+			t.Fatal(err)
+		}
+		columns[table] = info
+	}
+
+	return columns
+}
+
+func schemaVersions(t *testing.T, store Storage) []int {
+	t.Helper()
+
+	var versions []int
+	err := store.(*SQLite).db.Select(&versions, "SELECT version FROM schema_version ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return versions
+}
+
+func TestSQLite_Migrate(t *testing.T) {
+	all := make([]int, 0, len(migrations))
+	for _, m := range migrations {
+		all = append(all, m.version)
+	}
+
+	fresh := newTestSQLite(t)
+	assert.Equal(t, all, schemaVersions(t, fresh))
+	assert.Len(t, schema(t, fresh), 7, "feeds, folders, articles, article_tags, article_tombstones, article_feeds, and schema_version")
+
+	t.Run("unversioned v1 database", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "test.sqlite")
+		db, err := sqlx.Open("sqlite3", filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// databases created before the schema was versioned have the v1 tables but no schema_version table
+		tx := db.MustBegin()
+		err = migrations[0].up(context.Background(), tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.MustExec("INSERT INTO feeds (title, rssLink, siteLink, description, timestamp) VALUES ('example', 'https://example.com/feed.xml', 'https://example.com', '', 0)")
+		tx.MustExec("INSERT INTO articles (feed, title, author, description, link, published, read, read_date, favorited, timestamp) VALUES (1, 'article', 'author', '', 'http://www.example.com/posts/1?utm_source=rss', 0, false, '', false, 0)")
+		err = tx.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		store := NewSQLiteStorage(filePath)
+		err = store.Connect()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+
+		assert.Equal(t, schema(t, fresh), schema(t, store))
+		assert.Equal(t, all, schemaVersions(t, store))
+
+		a, err := store.GetArticle(context.Background(), "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "https://example.com/posts/1", a.CanonicalLink, "existing articles have their canonical link filled in")
+
+		var publishedType string
+		err = store.(*SQLite).db.Get(&publishedType, "SELECT typeof(published) FROM articles WHERE id = 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "integer", publishedType, "publish dates stored as text are converted")
+
+		var site string
+		err = store.(*SQLite).db.Get(&site, "SELECT site FROM feeds WHERE id = 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "example.com", site, "existing feeds have their site filled in")
+
+		_, err = store.CreateFeed(context.Background(), "category", "https://example.com/category/go.xml", "https://example.com", "", "", "", "")
+		assert.NoError(t, err, "site links are no longer unique")
+	})
+
+	t.Run("connecting again is a no-op", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "test.sqlite")
+		for i := 0; i < 2; i++ {
+			store := NewSQLiteStorage(filePath)
+			err := store.Connect()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, schema(t, fresh), schema(t, store))
+			assert.Equal(t, all, schemaVersions(t, store))
+			store.Close()
+		}
+	})
+}
+
+func TestSQLite_ArticlesAcrossFeeds(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	articles := seedArticles(t, store, 2)
+
+	// the site's category feed publishes some of the same articles, linking to them under www
+	category, err := store.CreateFeed(ctx, "category", "https://example.com/category/go.xml", "https://example.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "https://example.com/posts/1", articles[0].CanonicalLink)
+
+	// an unrelated site numbers its articles the same way
+	numbered, err := store.CreateArticle(ctx, "", "https://example.com/posts/numbered", "42", "numbered", "author", "", "", nil, nil, time.Now(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unrelated, err := store.CreateFeed(ctx, "unrelated", "https://unrelated.com/feed.xml", "https://unrelated.com", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("find duplicates", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			feedID string
+			link   string
+			guid   string
+			want   string
+		}{
+			{name: "canonical link", feedID: category.ID, link: "http://www.example.com/posts/1/?utm_source=go", want: articles[0].ID},
+			{name: "guid", feedID: category.ID, link: "https://www.example.com/p/2", guid: "https://example.com/posts/2", want: articles[1].ID},
+			{name: "new article", feedID: category.ID, link: "https://www.example.com/posts/3"},
+			{name: "empty guids do not match", feedID: category.ID, link: "https://www.example.com/posts/3", guid: ""},
+			{name: "the feed's own article", feedID: articles[0].FeedID, link: "https://www.example.com/posts/1"},
+			{name: "numeric guid on the same site", feedID: category.ID, link: "https://www.example.com/p/42", guid: "42", want: numbered.ID},
+			{name: "numeric guid on another site", feedID: unrelated.ID, link: "https://unrelated.com/posts/42", guid: "42"},
+			{name: "url guid on another site", feedID: unrelated.ID, link: "https://unrelated.com/posts/2", guid: "https://example.com/posts/2", want: articles[1].ID},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.FindDuplicateArticle(ctx, tt.feedID, tt.link, tt.guid)
+				if tt.want == "" {
+					assert.ErrorIs(t, err, ErrNotFound)
+					return
+				}
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.want, got.ID)
+				}
+			})
+		}
+	})
+
+	t.Run("feeds carrying an article", func(t *testing.T) {
+		feeds, err := store.ListArticleFeeds(ctx, articles[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, feeds, 1)
+
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, store.AddArticleFeed(ctx, articles[0].ID, category.ID), "recording a feed again does nothing")
+		}
+		assert.ErrorIs(t, store.AddArticleFeed(ctx, "404", category.ID), ErrNotFound)
+		assert.ErrorIs(t, store.AddArticleFeed(ctx, articles[0].ID, "404"), ErrNotFound)
+
+		feeds, err = store.ListArticleFeeds(ctx, articles[0].ID)
+		if assert.NoError(t, err) && assert.Len(t, feeds, 2) {
+			assert.Equal(t, articles[0].FeedID, feeds[0].ID, "the feed the article is stored under is first")
+			assert.Equal(t, category.ID, feeds[1].ID)
+		}
+
+		_, err = store.ListArticleFeeds(ctx, "404")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		err = store.DeleteFeed(ctx, category.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		feeds, err = store.ListArticleFeeds(ctx, articles[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, feeds, 1, "a deleted feed no longer carries the article")
+
+		err = store.DeleteArticle(ctx, articles[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = store.ListArticleFeeds(ctx, articles[0].ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestSQLite_CreateArticleOriginalPublished(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+	seedArticles(t, store, 0)
+
+	original := "Tue, 25 Apr 2023 14:30:00 +0200"
+	published, err := time.Parse(time.RFC1123Z, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := store.CreateArticle(ctx, "", "https://example.com/posts/dated", "", "dated", "author", "", "", nil, nil, published, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetArticle(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]any
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, float64(published.Unix()), fields["published"])
+	assert.Equal(t, "Tue, 25 Apr 2023", fields["publishedOn"])
+	assert.Equal(t, original, fields["originalPublished"])
+}
+
+func TestSQLite_CreateArticleMissingFeed(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	_, err := store.CreateArticle(ctx, "", "https://missing.com/posts/1", "", "article", "author", "", "", nil, nil, time.Now(), "")
+	assert.ErrorIs(t, err, ErrFeedMissing)
+
+	seedArticles(t, store, 0)
+	_, err = store.CreateArticle(ctx, "999", "https://example.com/posts/1", "", "article", "author", "", "", nil, nil, time.Now(), "")
+	assert.ErrorIs(t, err, ErrFeedMissing)
+
+	// the constraint also holds for rows written without going through CreateArticle
+	_, err = store.(*SQLite).db.Exec("INSERT INTO articles (feed, title, author, description, link, published, read, read_date, favorited, timestamp) VALUES (999, 'orphan', 'author', '', 'https://missing.com/posts/2', 0, false, '', false, 0)")
+	assert.True(t, isForeignKeyConstraintError(err), "got %v", err)
+}
+
+func TestSQLite_MigrateRemovesOrphans(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.sqlite")
+	ctx := context.Background()
+
+	// databases written before foreign keys were enforced can hold articles of deleted feeds
+	unenforced := NewSQLiteStorage(filePath, WithPragmas(Pragmas{}))
+	err := unenforced.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	articles := seedArticles(t, unenforced, 2)
+	db := unenforced.(*SQLite).db
+	for _, statement := range []string{
+		"INSERT INTO articles (feed, title, author, description, link, published, read, read_date, favorited, timestamp) VALUES (999, 'orphan', 'author', '', 'https://missing.com/posts/1', 0, false, '', false, 0)",
+		"INSERT INTO article_tags (article_id, tag) SELECT id, 'go' FROM articles WHERE feed = 999",
+		"INSERT INTO article_tombstones (link, guid, feed, deleted) VALUES ('https://missing.com/posts/2', '', 999, 0)",
+		"DELETE FROM schema_version WHERE version >= 12",
+	} {
+		_, err = db.Exec(statement)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	unenforced.Close()
+
+	store := NewSQLiteStorage(filePath)
+	err = store.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	var counts struct {
+		Articles   int `db:"articles"`
+		Tags       int `db:"tags"`
+		Tombstones int `db:"tombstones"`
+	}
+	err = store.(*SQLite).db.GetContext(ctx, &counts, "SELECT (SELECT COUNT(*) FROM articles) AS articles, (SELECT COUNT(*) FROM article_tags) AS tags, (SELECT COUNT(*) FROM article_tombstones) AS tombstones")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(articles), counts.Articles)
+	assert.Zero(t, counts.Tags)
+	assert.Zero(t, counts.Tombstones)
+}
+
+func TestScanRowsCanceled(t *testing.T) {
+	store := newTestSQLite(t)
+	seedArticles(t, store, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rows, err := store.(*SQLite).db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM articles", articleColumns))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned int
+	// a slow consumer whose request is abandoned after the first row
+	slowScan := func(row scanner) (*Article, error) {
+		scanned++
+		cancel()
+		return scanArticle(row)
+	}
+
+	articles, err := scanRows(ctx, rows, slowScan)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, articles)
+	assert.Equal(t, 1, scanned)
+}
+
+func TestSQLite_CreateFeedNormalizesLink(t *testing.T) {
+	store := newTestSQLite(t)
+	ctx := context.Background()
+
+	_, err := store.CreateFeed(ctx, "relative", "/feed.xml", "", "", "", "", "")
+	assert.ErrorIs(t, err, links.ErrInvalidURL)
+
+	feed, err := store.CreateFeed(ctx, "example", "HTTPS://Example.com:443/feed.xml", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://example.com/feed.xml", feed.RSSLink)
+	assert.Equal(t, "https://example.com", feed.SiteLink)
+
+	_, err = store.CreateFeed(ctx, "example", "https://example.com/feed.xml/", "", "", "", "", "")
+	assert.ErrorIs(t, err, ErrDuplicateFeed)
+}
+
+func BenchmarkSQLite_ListArticles(b *testing.B) {
+	store := newTestSQLite(b)
+	seedArticles(b, store, 28)
+	ctx := context.Background()
+	opts := &Options{Limit: 10, SortBy: SortPublished, Order: Descending}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := store.ListUnreadArticles(ctx, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSQLite_ListFeeds(b *testing.B) {
+	store := newTestSQLite(b)
+	seedArticles(b, store, 1)
+	ctx := context.Background()
+	opts := &Options{Limit: 10}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := store.ListFeeds(ctx, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
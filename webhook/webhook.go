@@ -0,0 +1,301 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/kdwils/feedreader/events"
+	"github.com/kdwils/feedreader/service"
+	"go.uber.org/zap"
+)
+
+const (
+	// SignatureHeader holds sha256= followed by the hex HMAC-SHA256 of the request body keyed with the secret
+	SignatureHeader = "X-Feedreader-Signature"
+	// EventHeader names the event the request was sent for
+	EventHeader = "X-Feedreader-Event"
+)
+
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultMaxAttempts  = 3
+	DefaultRetryBackoff = time.Second
+)
+
+// Payload is the json body posted to each webhook
+type Payload struct {
+	Event        events.Type `json:"event"`
+	FeedID       string      `json:"feedId"`
+	FeedTitle    string      `json:"feedTitle"`
+	ArticleID    string      `json:"articleId"`
+	ArticleTitle string      `json:"articleTitle"`
+	Link         string      `json:"link"`
+	// Published is the unix time the article was published
+	Published int64     `json:"published"`
+	Time      time.Time `json:"time"`
+}
+
+// StatusError is returned when a webhook responds with a status outside 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d", e.StatusCode)
+}
+
+// Notifier posts every new article to each of its webhooks
+type Notifier struct {
+	service      service.Service
+	logger       *zap.Logger
+	urls         []string
+	http         *http.Client
+	secret       []byte
+	timeout      time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+// Option configures optional Notifier settings
+type Option func(*Notifier)
+
+// WithSecret signs each request so receivers can tell it came from the reader, see SignatureHeader
+func WithSecret(secret string) Option {
+	return func(n *Notifier) {
+		n.secret = []byte(secret)
+	}
+}
+
+// WithTimeout sets the deadline for each attempt at delivering to a webhook. A zero timeout means no deadline.
+func WithTimeout(timeout time.Duration) Option {
+	return func(n *Notifier) {
+		n.timeout = timeout
+	}
+}
+
+// WithRetry retries deliveries that fail with a server error, a 429, or a connection error up to maxAttempts times in total.
+// The delay between attempts starts at backoff and doubles after each attempt, with jitter added.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(n *Notifier) {
+		if maxAttempts > 0 {
+			n.maxAttempts = maxAttempts
+		}
+		n.retryBackoff = backoff
+	}
+}
+
+// WithHTTPClient sets the client webhooks are posted with
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *Notifier) {
+		if client != nil {
+			n.http = client
+		}
+	}
+}
+
+// New creates a notifier for the webhooks, returning an error when one is not an absolute http or https url
+func New(svc service.Service, logger *zap.Logger, urls []string, opts ...Option) (*Notifier, error) {
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook url %q: %w", u, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid webhook url %q: must be an absolute http or https url", u)
+		}
+	}
+
+	n := &Notifier{
+		service:      svc,
+		logger:       logger,
+		urls:         urls,
+		http:         &http.Client{},
+		timeout:      DefaultTimeout,
+		maxAttempts:  DefaultMaxAttempts,
+		retryBackoff: DefaultRetryBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n, nil
+}
+
+// Notify posts each article created from now on to every webhook until the context is cancelled.
+// Each webhook is sent articles in order by its own subscription to the service's events, so one that is slow or failing falls behind and has its oldest articles dropped without delaying the others.
+func (n *Notifier) Notify(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, u := range n.urls {
+		published, unsubscribe := n.service.SubscribeEvents()
+
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			defer unsubscribe()
+			n.notify(ctx, u, published)
+		}(u)
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (n *Notifier) notify(ctx context.Context, u string, published <-chan events.Event) {
+	l := n.logger.With(zap.String("webhook", u))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-published:
+			if !ok {
+				return
+			}
+			if e.Type != events.ArticleCreated {
+				continue
+			}
+
+			payload := n.payload(ctx, e)
+			err := n.Send(ctx, u, payload)
+			if err != nil && ctx.Err() == nil {
+				l.Error("failed to deliver webhook", zap.String("article", payload.ArticleID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// payload describes the event's article, leaving the feed title empty when the feed cannot be read
+func (n *Notifier) payload(ctx context.Context, e events.Event) Payload {
+	a := e.Article
+	p := Payload{
+		Event:        e.Type,
+		FeedID:       a.FeedID,
+		ArticleID:    a.ID,
+		ArticleTitle: a.Title,
+		Link:         a.Link,
+		Published:    a.PublishedUnix,
+		Time:         e.Time,
+	}
+
+	feed, err := n.service.GetFeed(ctx, a.FeedID)
+	if err != nil {
+		n.logger.Warn("failed to get the feed of a webhook article", zap.String("feed", a.FeedID), zap.Error(err))
+		return p
+	}
+
+	p.FeedTitle = feed.Title
+	return p
+}
+
+// Send posts the payload to the webhook, retrying failures that may succeed if attempted again
+func (n *Notifier) Send(ctx context.Context, u string, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := n.send(ctx, u, payload.Event, body)
+
+		var retryable retryableError
+		if !errors.As(err, &retryable) {
+			return err
+		}
+
+		if attempt >= n.maxAttempts {
+			return retryable.err
+		}
+
+		select {
+		case <-ctx.Done():
+			return retryable.err
+		case <-time.After(backoff(n.retryBackoff, attempt)):
+		}
+	}
+}
+
+// send makes a single attempt at delivering the body. Errors worth retrying are wrapped in a retryableError.
+func (n *Notifier) send(ctx context.Context, u string, event events.Type, body []byte) error {
+	if n.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "feedreader-webhook")
+	req.Header.Set(EventHeader, string(event))
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(n.secret, body))
+	}
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		// the attempt timing out is worth retrying, the notifier stopping is not
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		return retryableError{err: err}
+	}
+	defer resp.Body.Close()
+	// the body is drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := &StatusError{StatusCode: resp.StatusCode}
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return retryableError{err: err}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Sign returns the value of the SignatureHeader for the body, receivers compute the same from the body they were sent and compare the two
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryableError marks an error from a delivery attempt that may succeed if attempted again
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
+// backoff is the delay before the next attempt. It doubles with each attempt and adds up to one base delay of jitter.
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base << (attempt - 1)
+	return delay + time.Duration(rand.Int63n(int64(base)))
+}
@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/kdwils/feedreader/events"
+	serviceMocks "github.com/kdwils/feedreader/service/mocks"
+	"github.com/kdwils/feedreader/storage"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// captured is a request a capturing server received
+type captured struct {
+	header http.Header
+	body   []byte
+}
+
+// capturingServer records every request it receives and responds with the next status, or 200 once they run out
+func capturingServer(t *testing.T, statuses ...int) (*httptest.Server, func() []captured) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []captured
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		requests = append(requests, captured{header: r.Header, body: body})
+		status := http.StatusOK
+		if len(requests) <= len(statuses) {
+			status = statuses[len(requests)-1]
+		}
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []captured {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]captured(nil), requests...)
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := serviceMocks.NewMockService(ctrl)
+	bus := events.NewBus(events.DefaultBuffer)
+	svc.EXPECT().SubscribeEvents().DoAndReturn(bus.Subscribe).Times(2)
+	svc.EXPECT().GetFeed(gomock.Any(), "feed-1").Return(&storage.Feed{ID: "feed-1", Title: "example"}, nil).Times(2)
+
+	first, firstRequests := capturingServer(t)
+	second, secondRequests := capturingServer(t)
+
+	n, err := New(svc, zap.NewNop(), []string{first.URL, second.URL}, WithSecret("shh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- n.Notify(ctx)
+	}()
+
+	// the article is published once both webhooks have subscribed so neither misses it
+	assert.Eventually(t, func() bool { return bus.Subscribers() == 2 }, 5*time.Second, time.Millisecond)
+	published := time.Date(2023, 4, 25, 0, 0, 0, 0, time.UTC)
+	bus.Publish(events.Event{
+		Type:    events.ArticleCreated,
+		Article: &storage.Article{ID: "1", FeedID: "feed-1", Title: "article 1", Link: "https://example.com/posts/1", PublishedUnix: published.Unix()},
+		Time:    published,
+	})
+
+	for _, requests := range []func() []captured{firstRequests, secondRequests} {
+		assert.Eventually(t, func() bool { return len(requests()) == 1 }, 5*time.Second, time.Millisecond)
+		req := requests()[0]
+
+		var got Payload
+		err := json.Unmarshal(req.body, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, Payload{
+			Event:        events.ArticleCreated,
+			FeedID:       "feed-1",
+			FeedTitle:    "example",
+			ArticleID:    "1",
+			ArticleTitle: "article 1",
+			Link:         "https://example.com/posts/1",
+			Published:    published.Unix(),
+			Time:         published,
+		}, got)
+		assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+		assert.Equal(t, "article.created", req.header.Get(EventHeader))
+
+		// the signature is checked the way a receiver would check it
+		mac := hmac.New(sha256.New, []byte("shh"))
+		mac.Write(req.body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.header.Get(SignatureHeader))
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notify did not stop when the context was cancelled")
+	}
+	assert.Equal(t, 0, bus.Subscribers(), "the webhooks unsubscribe when they stop")
+}
+
+func TestNotifier_Send(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantRequests int
+		wantStatus   int
+	}{
+		{name: "delivered", wantRequests: 1},
+		{name: "server errors are retried", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}, wantRequests: 3},
+		{name: "gives up after the last attempt", statuses: []int{500, 500, 500, 500}, wantRequests: 3, wantStatus: 500},
+		{name: "client errors are not retried", statuses: []int{http.StatusGone}, wantRequests: 1, wantStatus: http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := capturingServer(t, tt.statuses...)
+			n, err := New(nil, zap.NewNop(), []string{srv.URL}, WithRetry(3, time.Millisecond))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = n.Send(context.Background(), srv.URL, Payload{Event: events.ArticleCreated, ArticleID: "1"})
+			assert.Len(t, requests(), tt.wantRequests)
+			if tt.wantStatus == 0 {
+				assert.NoError(t, err)
+				assert.Empty(t, requests()[0].header.Get(SignatureHeader), "requests are only signed with a secret")
+				return
+			}
+
+			var statusErr *StatusError
+			if assert.True(t, errors.As(err, &statusErr), err) {
+				assert.Equal(t, tt.wantStatus, statusErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotifier_SendTimeout(t *testing.T) {
+	var attempts int
+	var mu sync.Mutex
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	n, err := New(nil, zap.NewNop(), []string{srv.URL}, WithTimeout(10*time.Millisecond), WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.Send(context.Background(), srv.URL, Payload{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts, "each attempt has its own timeout")
+}
+
+func TestNew(t *testing.T) {
+	for _, u := range []string{"example.com/hook", "ftp://example.com/hook", "https://", "http://exa mple.com"} {
+		_, err := New(nil, zap.NewNop(), []string{u})
+		assert.Error(t, err, u)
+	}
+
+	_, err := New(nil, zap.NewNop(), []string{"https://example.com/hook", "http://localhost:9000"})
+	assert.NoError(t, err)
+}